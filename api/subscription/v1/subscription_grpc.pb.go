@@ -0,0 +1,262 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: subscription/v1/subscription.proto
+
+// gRPC API of the subscription aggregator. Every call needs the same
+// credentials and tenant as the HTTP API, passed as metadata:
+// x-api-key or authorization ("Bearer ...") and x-tenant-id.
+
+package subscriptionv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	SubscriptionService_GetSubscription_FullMethodName        = "/subscription.v1.SubscriptionService/GetSubscription"
+	SubscriptionService_ListSubscriptions_FullMethodName      = "/subscription.v1.SubscriptionService/ListSubscriptions"
+	SubscriptionService_StreamSubscriptions_FullMethodName    = "/subscription.v1.SubscriptionService/StreamSubscriptions"
+	SubscriptionService_AggregateSubscriptions_FullMethodName = "/subscription.v1.SubscriptionService/AggregateSubscriptions"
+)
+
+// SubscriptionServiceClient is the client API for SubscriptionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SubscriptionServiceClient interface {
+	// Returns a subscription by ID
+	GetSubscription(ctx context.Context, in *GetSubscriptionRequest, opts ...grpc.CallOption) (*Subscription, error)
+	// Returns a page of subscriptions, newest first
+	ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*ListSubscriptionsResponse, error)
+	// Streams every subscription matching the filter, newest first; limit
+	// and offset are ignored
+	StreamSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (SubscriptionService_StreamSubscriptionsClient, error)
+	// Sums subscription costs over a period
+	AggregateSubscriptions(ctx context.Context, in *AggregateSubscriptionsRequest, opts ...grpc.CallOption) (*AggregateSubscriptionsResponse, error)
+}
+
+type subscriptionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSubscriptionServiceClient(cc grpc.ClientConnInterface) SubscriptionServiceClient {
+	return &subscriptionServiceClient{cc}
+}
+
+func (c *subscriptionServiceClient) GetSubscription(ctx context.Context, in *GetSubscriptionRequest, opts ...grpc.CallOption) (*Subscription, error) {
+	out := new(Subscription)
+	err := c.cc.Invoke(ctx, SubscriptionService_GetSubscription_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *subscriptionServiceClient) ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*ListSubscriptionsResponse, error) {
+	out := new(ListSubscriptionsResponse)
+	err := c.cc.Invoke(ctx, SubscriptionService_ListSubscriptions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *subscriptionServiceClient) StreamSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (SubscriptionService_StreamSubscriptionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SubscriptionService_ServiceDesc.Streams[0], SubscriptionService_StreamSubscriptions_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &subscriptionServiceStreamSubscriptionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SubscriptionService_StreamSubscriptionsClient interface {
+	Recv() (*Subscription, error)
+	grpc.ClientStream
+}
+
+type subscriptionServiceStreamSubscriptionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *subscriptionServiceStreamSubscriptionsClient) Recv() (*Subscription, error) {
+	m := new(Subscription)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *subscriptionServiceClient) AggregateSubscriptions(ctx context.Context, in *AggregateSubscriptionsRequest, opts ...grpc.CallOption) (*AggregateSubscriptionsResponse, error) {
+	out := new(AggregateSubscriptionsResponse)
+	err := c.cc.Invoke(ctx, SubscriptionService_AggregateSubscriptions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SubscriptionServiceServer is the server API for SubscriptionService service.
+// All implementations must embed UnimplementedSubscriptionServiceServer
+// for forward compatibility
+type SubscriptionServiceServer interface {
+	// Returns a subscription by ID
+	GetSubscription(context.Context, *GetSubscriptionRequest) (*Subscription, error)
+	// Returns a page of subscriptions, newest first
+	ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*ListSubscriptionsResponse, error)
+	// Streams every subscription matching the filter, newest first; limit
+	// and offset are ignored
+	StreamSubscriptions(*ListSubscriptionsRequest, SubscriptionService_StreamSubscriptionsServer) error
+	// Sums subscription costs over a period
+	AggregateSubscriptions(context.Context, *AggregateSubscriptionsRequest) (*AggregateSubscriptionsResponse, error)
+	mustEmbedUnimplementedSubscriptionServiceServer()
+}
+
+// UnimplementedSubscriptionServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSubscriptionServiceServer struct {
+}
+
+func (UnimplementedSubscriptionServiceServer) GetSubscription(context.Context, *GetSubscriptionRequest) (*Subscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSubscription not implemented")
+}
+func (UnimplementedSubscriptionServiceServer) ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*ListSubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubscriptions not implemented")
+}
+func (UnimplementedSubscriptionServiceServer) StreamSubscriptions(*ListSubscriptionsRequest, SubscriptionService_StreamSubscriptionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSubscriptions not implemented")
+}
+func (UnimplementedSubscriptionServiceServer) AggregateSubscriptions(context.Context, *AggregateSubscriptionsRequest) (*AggregateSubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AggregateSubscriptions not implemented")
+}
+func (UnimplementedSubscriptionServiceServer) mustEmbedUnimplementedSubscriptionServiceServer() {}
+
+// UnsafeSubscriptionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SubscriptionServiceServer will
+// result in compilation errors.
+type UnsafeSubscriptionServiceServer interface {
+	mustEmbedUnimplementedSubscriptionServiceServer()
+}
+
+func RegisterSubscriptionServiceServer(s grpc.ServiceRegistrar, srv SubscriptionServiceServer) {
+	s.RegisterService(&SubscriptionService_ServiceDesc, srv)
+}
+
+func _SubscriptionService_GetSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubscriptionServiceServer).GetSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SubscriptionService_GetSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubscriptionServiceServer).GetSubscription(ctx, req.(*GetSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SubscriptionService_ListSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubscriptionServiceServer).ListSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SubscriptionService_ListSubscriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubscriptionServiceServer).ListSubscriptions(ctx, req.(*ListSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SubscriptionService_StreamSubscriptions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListSubscriptionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SubscriptionServiceServer).StreamSubscriptions(m, &subscriptionServiceStreamSubscriptionsServer{stream})
+}
+
+type SubscriptionService_StreamSubscriptionsServer interface {
+	Send(*Subscription) error
+	grpc.ServerStream
+}
+
+type subscriptionServiceStreamSubscriptionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *subscriptionServiceStreamSubscriptionsServer) Send(m *Subscription) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SubscriptionService_AggregateSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AggregateSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubscriptionServiceServer).AggregateSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SubscriptionService_AggregateSubscriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubscriptionServiceServer).AggregateSubscriptions(ctx, req.(*AggregateSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SubscriptionService_ServiceDesc is the grpc.ServiceDesc for SubscriptionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SubscriptionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "subscription.v1.SubscriptionService",
+	HandlerType: (*SubscriptionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSubscription",
+			Handler:    _SubscriptionService_GetSubscription_Handler,
+		},
+		{
+			MethodName: "ListSubscriptions",
+			Handler:    _SubscriptionService_ListSubscriptions_Handler,
+		},
+		{
+			MethodName: "AggregateSubscriptions",
+			Handler:    _SubscriptionService_AggregateSubscriptions_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSubscriptions",
+			Handler:       _SubscriptionService_StreamSubscriptions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "subscription/v1/subscription.proto",
+}
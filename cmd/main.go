@@ -1,15 +1,32 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/budgets"
 	"subscription-aggregator/internal/config"
 	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/grpcapi"
 	"subscription-aggregator/internal/handlers"
+	"subscription-aggregator/internal/idempotency"
 	"subscription-aggregator/internal/middleware"
-	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/internal/openapi"
+	"subscription-aggregator/internal/outbox"
+	"subscription-aggregator/internal/pricing"
+	"subscription-aggregator/internal/ratelimit"
+	"subscription-aggregator/internal/reminders"
+	"subscription-aggregator/internal/rollup"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/webhooks"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
@@ -31,39 +48,153 @@ func main() {
 
 	logger.Info("Successfully connected to database")
 
-	subscriptionHandler := handlers.NewSubscriptionHandler(db, logger)
+	subscriptionService := subscriptions.NewService(db, authz.NewPolicy(), logger)
+	routes := handlers.NewRoutes(db, subscriptionService, logger)
+
+	defaultTenant, err := parseDefaultTenant(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid default tenant ID")
+	}
+
+	var authenticator *auth.Authenticator
+	if cfg.Auth.Enabled {
+		authenticator, err = setupAuth(cfg, db, defaultTenant)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to set up authentication")
+		}
+	} else {
+		logger.Warn("Authentication is disabled, do not expose the service outside a trusted network")
+	}
 
 	router := mux.NewRouter()
 
 	router.Use(middleware.LoggingMiddleware)
 
-	// Health check
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "healthy"}`))
-	}).Methods("GET")
+	// Health check and API description
+	spec := openapi.Build()
+	handlers.RegisterSystem(router, spec)
+
+	deprecation, err := setupDeprecation(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid API version configuration")
+	}
+
+	// Every other route is authenticated and scoped to a tenant
+	api := router.PathPrefix("/").Subrouter()
+	if cfg.OpenAPI.ValidateResponses {
+		api.Use(middleware.OpenAPIResponseMiddleware(spec, logger))
+	}
+	api.Use(middleware.EnvelopeMiddleware(deprecation.Current))
+	api.Use(middleware.DeprecationMiddleware(deprecation))
+	if authenticator != nil {
+		api.Use(middleware.AuthMiddleware(authenticator, logger))
+	}
+	var limiter ratelimit.Limiter
+	var limits middleware.RateLimitOptions
+	if cfg.RateLimit.Enabled {
+		limiter, limits, err = setupRateLimit(cfg, db)
+		if err != nil {
+			logger.WithError(err).Fatal("Invalid rate limit configuration")
+		}
+		api.Use(middleware.RateLimitMiddleware(limiter, limits, logger))
+	}
+	api.Use(middleware.TenantMiddleware(cfg.Tenancy.Header, cfg.Tenancy.Required, defaultTenant))
+	api.Use(middleware.ScopeMiddleware("/subscriptions/aggregate", "/subscriptions/forecast", "/graphql"))
+	api.Use(middleware.BodyLimitMiddleware(maxBodyBytes(cfg), cfg.Server.RouteMaxBodyBytes))
+	if cfg.OpenAPI.ValidateRequests {
+		api.Use(middleware.OpenAPIMiddleware(spec, logger))
+	}
+	api.Use(middleware.IdempotencyMiddleware(idempotency.NewStore(db.System()), logger))
+
+	routes.Register(api)
+
+	if err := openapi.CheckRoutes(router, spec); err != nil {
+		logger.WithError(err).Fatal("Routes and OpenAPI document differ")
+	}
+
+	if cfg.GRPC.Enabled {
+		grpcServer := grpcapi.NewServer(subscriptionService, grpcapi.Options{
+			Authenticator:     authenticator,
+			TenantRequired:    cfg.Tenancy.Required,
+			DefaultTenant:     defaultTenant,
+			Limiter:           limiter,
+			DefaultLimit:      limits.Default,
+			RouteLimits:       limits.Routes,
+			TrustForwardedFor: limits.TrustForwardedFor,
+			Reflection:        cfg.GRPC.Reflection,
+		}, logger)
+
+		grpcAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.GRPC.Port)
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to listen for gRPC")
+		}
 
+		go func() {
+			logger.WithField("addr", grpcAddr).Info("Starting gRPC server")
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.WithError(err).Fatal("Failed to start gRPC server")
+			}
+		}()
+	}
+
+	if cfg.Outbox.Enabled {
+		sink, err := setupOutboxSink(cfg, db)
+		if err != nil {
+			logger.WithError(err).Fatal("Invalid outbox configuration")
+		}
+		defer sink.Close()
+
+		relay := outbox.NewRelay(db.System(), sink, outbox.RelayOptions{}, logger)
+		go relay.Run(context.Background())
+	}
+
+	if cfg.Webhooks.Enabled {
+		if err := checkWebhookSink(cfg); err != nil {
+			logger.WithError(err).Fatal("Invalid webhooks configuration")
+		}
+		if !cfg.Outbox.Enabled {
+			logger.Warn("Webhooks are enabled without the outbox relay, deliveries are only created while another replica runs it with the webhook sink")
+		}
 
-	router.HandleFunc("/subscriptions", subscriptionHandler.ListSubscriptions).Methods("GET")
+		dispatcher := webhooks.NewDispatcher(db.System(), webhooks.Options{
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Timeout:     time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second,
+		}, logger)
+		go dispatcher.Run(context.Background())
+	}
 
-	// Create subscription
-	createSubRouter := router.Path("/subscriptions").Subrouter()
-	createSubRouter.Use(middleware.ValidationMiddleware(validation.GetAllowedFieldsForCreate()))
-	createSubRouter.HandleFunc("", subscriptionHandler.CreateSubscription).Methods("POST")
+	if cfg.Reminders.Enabled {
+		notifiers, err := setupNotifiers(cfg, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Invalid reminders configuration")
+		}
 
-	// Update subscription
-	updateSubRouter := router.Path("/subscriptions/{id}").Subrouter()
-	updateSubRouter.Use(middleware.ValidationMiddleware(validation.GetAllowedFieldsForUpdate()))
-	updateSubRouter.HandleFunc("", subscriptionHandler.UpdateSubscription).Methods("PUT")
+		scheduler := reminders.NewScheduler(db.System(), notifiers, reminders.Options{
+			Window: time.Duration(cfg.Reminders.WindowDays) * 24 * time.Hour,
+		}, logger)
+		go scheduler.Run(context.Background())
+	}
 
-	router.HandleFunc("/subscriptions/{id}", subscriptionHandler.GetSubscription).Methods("GET")
-	router.HandleFunc("/subscriptions/{id}", subscriptionHandler.DeleteSubscription).Methods("DELETE")
+	if cfg.PriceChanges.Enabled {
+		applier := pricing.NewApplier(db.System(), pricing.Options{}, logger)
+		go applier.Run(context.Background())
+	}
 
-	// Aggregate subscriptions
-	aggregateSubRouter := router.Path("/subscriptions/aggregate").Subrouter()
-	aggregateSubRouter.Use(middleware.ValidationMiddleware(validation.GetAllowedFieldsForAggregation()))
-	aggregateSubRouter.HandleFunc("", subscriptionHandler.AggregateSubscriptions).Methods("POST")
+	if cfg.Budgets.Enabled {
+		evaluator := budgets.NewEvaluator(db, subscriptionService, budgets.Options{
+			Interval: time.Duration(cfg.Budgets.IntervalMinutes) * time.Minute,
+		}, logger)
+		go evaluator.Run(context.Background())
+	}
+
+	if cfg.Rollups.Enabled {
+		rebuilder := rollup.NewRebuilder(db.System(), rollup.Options{
+			Interval: time.Duration(cfg.Rollups.IntervalMinutes) * time.Minute,
+			Months:   cfg.Rollups.Months,
+		}, logger)
+		go rebuilder.Run(context.Background())
+	}
 
 	// Start
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -91,3 +222,205 @@ func setupLogger(cfg *config.Config) *logrus.Logger {
 
 	return logger
 }
+
+// parseDefaultTenant returns the configured fallback tenant, or the nil UUID
+// when none is configured
+func parseDefaultTenant(cfg *config.Config) (uuid.UUID, error) {
+	if cfg.Tenancy.DefaultTenantID == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(cfg.Tenancy.DefaultTenantID)
+}
+
+// setupAuth builds the authenticator and stores the bootstrap API key
+func setupAuth(cfg *config.Config, db *database.DB, defaultTenant uuid.UUID) (*auth.Authenticator, error) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+		Issuer:      cfg.Auth.JWT.Issuer,
+		Audience:    cfg.Auth.JWT.Audience,
+		HMACSecrets: append([]string{cfg.Auth.JWT.HMACSecret}, cfg.Auth.JWT.HMACSecrets...),
+		JWKSFiles:   cfg.Auth.JWT.JWKSFiles,
+		TenantClaim: cfg.Auth.JWT.TenantClaim,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Auth.BootstrapAPIKey != "" {
+		if defaultTenant == uuid.Nil {
+			return nil, fmt.Errorf("bootstrap API key requires a default tenant")
+		}
+		if err := auth.EnsureAPIKey(db.System(), defaultTenant, "bootstrap", cfg.Auth.BootstrapAPIKey); err != nil {
+			return nil, fmt.Errorf("failed to store bootstrap API key: %w", err)
+		}
+	}
+
+	return auth.NewAuthenticator(db.System(), verifier), nil
+}
+
+// setupRateLimit builds the limiter and the limits shared by the HTTP and
+// gRPC APIs from the configuration
+func setupRateLimit(cfg *config.Config, db *database.DB) (ratelimit.Limiter, middleware.RateLimitOptions, error) {
+	var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+	if cfg.RateLimit.Shared {
+		limiter = ratelimit.NewPostgresLimiter(db.System())
+	}
+
+	opts := middleware.RateLimitOptions{
+		Default: ratelimit.Limit{
+			RequestsPerMinute: cfg.RateLimit.RequestsPerMinute,
+			Burst:             cfg.RateLimit.Burst,
+		},
+		Routes:            make(map[string]ratelimit.Limit),
+		TrustForwardedFor: cfg.RateLimit.TrustForwardedFor,
+	}
+	if !opts.Default.Valid() {
+		return nil, opts, fmt.Errorf("requests_per_minute and burst must be positive")
+	}
+
+	for route, limit := range cfg.RateLimit.Routes {
+		opts.Routes[route] = ratelimit.Limit{
+			RequestsPerMinute: limit.RequestsPerMinute,
+			Burst:             limit.Burst,
+		}
+		if !opts.Routes[route].Valid() {
+			return nil, opts, fmt.Errorf("limit of route %s must be positive", route)
+		}
+	}
+
+	return limiter, opts, nil
+}
+
+// setupDeprecation reads the deprecation schedule of the v1 API
+func setupDeprecation(cfg *config.Config) (middleware.DeprecationOptions, error) {
+	opts := middleware.DeprecationOptions{Current: "/v2", Unversioned: []string{"/graphql"}}
+
+	var err error
+	if cfg.API.V1.DeprecatedAt != "" {
+		if opts.DeprecatedAt, err = time.Parse("2006-01-02", cfg.API.V1.DeprecatedAt); err != nil {
+			return opts, fmt.Errorf("invalid deprecated_at: %w", err)
+		}
+	}
+	if cfg.API.V1.Sunset != "" {
+		if opts.Sunset, err = time.Parse("2006-01-02", cfg.API.V1.Sunset); err != nil {
+			return opts, fmt.Errorf("invalid sunset: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// setupOutboxSink builds the sinks the outbox relay publishes to
+// checkWebhookSink fails when this replica relays events but not to
+// webhooks, which leaves the webhook dispatcher without deliveries
+func checkWebhookSink(cfg *config.Config) error {
+	if !cfg.Outbox.Enabled {
+		return nil
+	}
+	for _, name := range splitList(cfg.Outbox.Sinks) {
+		if name == "webhook" {
+			return nil
+		}
+	}
+	return fmt.Errorf("webhooks are enabled but outbox sinks %q do not include webhook", cfg.Outbox.Sinks)
+}
+
+func setupOutboxSink(cfg *config.Config, db *database.DB) (outbox.Sink, error) {
+	var sinks outbox.MultiSink
+	for _, name := range splitList(cfg.Outbox.Sinks) {
+		switch name {
+		case "webhook":
+			sinks = append(sinks, webhooks.NewSink(db.System()))
+		case "kafka":
+			brokers := splitList(cfg.Outbox.Kafka.Brokers)
+			if len(brokers) == 0 || cfg.Outbox.Kafka.Topic == "" {
+				return nil, fmt.Errorf("kafka sink requires brokers and a topic")
+			}
+			sinks = append(sinks, outbox.NewKafkaSink(brokers, cfg.Outbox.Kafka.Topic))
+		case "nats":
+			if cfg.Outbox.NATS.Subject == "" {
+				return nil, fmt.Errorf("nats sink requires a subject")
+			}
+			sink, err := outbox.NewNATSSink(cfg.Outbox.NATS.URL, cfg.Outbox.NATS.Subject, cfg.Outbox.NATS.JetStream)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+			}
+			sinks = append(sinks, sink)
+		case "file":
+			sink, err := outbox.NewFileSink(cfg.Outbox.File)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open outbox file: %w", err)
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no outbox sinks configured")
+	}
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
+
+// setupNotifiers builds the notifiers reminders are sent through
+func setupNotifiers(cfg *config.Config, logger *logrus.Logger) ([]reminders.Notifier, error) {
+	var notifiers []reminders.Notifier
+	for _, name := range splitList(cfg.Reminders.Notifiers) {
+		switch name {
+		case "log":
+			notifiers = append(notifiers, reminders.NewLogNotifier(logger))
+		case "file":
+			notifier, err := reminders.NewFileNotifier(cfg.Reminders.File)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open reminders file: %w", err)
+			}
+			notifiers = append(notifiers, notifier)
+		case "smtp":
+			smtp := cfg.Reminders.SMTP
+			if smtp.Host == "" || smtp.From == "" {
+				return nil, fmt.Errorf("smtp notifier requires a host and a sender")
+			}
+			notifiers = append(notifiers, reminders.NewSMTPNotifier(smtp.Host, smtp.Port, smtp.Username, smtp.Password, smtp.From))
+		case "telegram":
+			telegram := cfg.Reminders.Telegram
+			if telegram.BotToken == "" || telegram.ChatID == "" {
+				return nil, fmt.Errorf("telegram notifier requires a bot token and a chat ID")
+			}
+			notifiers = append(notifiers, reminders.NewTelegramNotifier(telegram.BotToken, telegram.ChatID))
+		case "webhook":
+			if cfg.Reminders.Webhook.URL == "" {
+				return nil, fmt.Errorf("webhook notifier requires a URL")
+			}
+			notifiers = append(notifiers, reminders.NewWebhookNotifier(cfg.Reminders.Webhook.URL, cfg.Reminders.Webhook.Secret))
+		default:
+			return nil, fmt.Errorf("unknown notifier %q", name)
+		}
+	}
+
+	if len(notifiers) == 0 {
+		return nil, fmt.Errorf("no notifiers configured")
+	}
+	return notifiers, nil
+}
+
+// splitList splits a comma-separated configuration value, dropping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// maxBodyBytes returns the default request body limit, 1 MiB when unset
+func maxBodyBytes(cfg *config.Config) int64 {
+	if cfg.Server.MaxBodyBytes <= 0 {
+		return 1 << 20
+	}
+	return cfg.Server.MaxBodyBytes
+}
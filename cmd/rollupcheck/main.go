@@ -0,0 +1,127 @@
+// rollupcheck compares the monthly spend rollups with the subscriptions
+// they are computed from and prints every month that differs. It connects
+// to the database with the configuration of the server; with -fix the
+// rollups that differ are rebuilt. Exits with 1 when differences remain.
+//
+// The rollups hold the full prices of subscriptions by owner, so only
+// writes changing price, owner, service or dates have to keep them up to
+// date. Cost-sharing updates leave all of these alone and do not touch the
+// rollups; the check compares owners' full prices as well, so shares never
+// show up as differences.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"subscription-aggregator/internal/config"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/rollup"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	tenantFlag := flag.String("tenant", "", "tenant ID, every tenant with a rollup by default")
+	fix := flag.Bool("fix", false, "rebuild rollups that differ")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: rollupcheck [-tenant ID] [-fix]")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "rollupcheck:", err)
+		os.Exit(1)
+	}
+
+	db, err := database.New(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "rollupcheck:", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	var tenants []uuid.UUID
+	if *tenantFlag != "" {
+		id, err := uuid.Parse(*tenantFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "rollupcheck: invalid tenant ID:", err)
+			os.Exit(2)
+		}
+		tenants = append(tenants, id)
+	} else if err := db.System().Select(&tenants, `SELECT tenant_id FROM monthly_spend_state ORDER BY tenant_id`); err != nil {
+		fmt.Fprintln(os.Stderr, "rollupcheck:", err)
+		os.Exit(1)
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "TENANT\tMONTH\tUSER\tSERVICE\tEXPECTED\tACTUAL")
+
+	differing, failed := 0, false
+	for _, tenantID := range tenants {
+		mismatches, err := check(db, tenantID, *fix)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rollupcheck: tenant %s: %v\n", tenantID, err)
+			failed = true
+			continue
+		}
+
+		for _, m := range mismatches {
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%d\t%d\n",
+				tenantID, m.Month.Format("01-2006"), m.UserID, m.ServiceID, m.Expected, m.Actual)
+		}
+		if len(mismatches) > 0 {
+			differing++
+		}
+	}
+	out.Flush()
+
+	switch {
+	case differing == 0:
+		fmt.Fprintf(os.Stderr, "%d tenants checked, rollups match\n", len(tenants))
+	case *fix:
+		fmt.Fprintf(os.Stderr, "%d of %d tenants differed, rebuilt\n", differing, len(tenants))
+	default:
+		fmt.Fprintf(os.Stderr, "%d of %d tenants differ, run with -fix to rebuild\n", differing, len(tenants))
+		failed = true
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// check compares the rollup of the tenant, rebuilding it through the same
+// month when it differs and fix is set
+func check(db *database.DB, tenantID uuid.UUID, fix bool) ([]rollup.Mismatch, error) {
+	tx, err := db.System().Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	until, ok, err := rollup.Until(tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("rollup is not built")
+	}
+
+	mismatches, err := rollup.Check(tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if fix && len(mismatches) > 0 {
+		if err := rollup.Rebuild(tx, tenantID, until); err != nil {
+			return nil, err
+		}
+	}
+
+	return mismatches, tx.Commit()
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"subscription-aggregator/pkg/client"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// filterFlags select subscriptions
+type filterFlags struct {
+	userID    string
+	serviceID string
+	service   string
+	category  string
+	tags      stringList
+}
+
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.userID, "user-id", "", "only subscriptions of the user")
+	fs.StringVar(&f.serviceID, "service-id", "", "only subscriptions of the catalog service")
+	fs.StringVar(&f.service, "service", "", "only subscriptions of the service with the name or alias")
+	fs.StringVar(&f.category, "category", "", "only subscriptions of the category")
+	fs.Var(&f.tags, "tag", "only subscriptions with the tag; repeat or separate with commas for several")
+}
+
+func (f *filterFlags) filter() (models.SubscriptionFilter, error) {
+	filter := models.SubscriptionFilter{
+		ServiceName: nonEmpty(f.service),
+		Category:    nonEmpty(f.category),
+		Tags:        f.tags,
+	}
+
+	var err error
+	if filter.UserID, err = optionalUUID("user-id", f.userID); err != nil {
+		return filter, err
+	}
+	if filter.ServiceID, err = optionalUUID("service-id", f.serviceID); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func runList(ctx context.Context, args []string) error {
+	var global globalFlags
+	var filter filterFlags
+	var limit, offset int
+
+	fs := newFlagSet("list", "", &global, formatTable)
+	filter.register(fs)
+	fs.IntVar(&limit, "limit", 0, "number of subscriptions, all when 0")
+	fs.IntVar(&offset, "offset", 0, "subscriptions to skip, with -limit")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if offset > 0 && limit <= 0 {
+		return usageError{"-offset needs -limit"}
+	}
+
+	c, p, err := setup(&global)
+	if err != nil {
+		return err
+	}
+	f, err := filter.filter()
+	if err != nil {
+		return err
+	}
+
+	var list []models.Subscription
+	if limit > 0 {
+		list, err = c.ListSubscriptions(ctx, f, limit, offset)
+	} else {
+		list, err = collect(c.Subscriptions(ctx, f))
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.subscriptions(list)
+}
+
+func runGet(ctx context.Context, args []string) error {
+	var global globalFlags
+
+	fs := newFlagSet("get", "<id>...", &global, formatTable)
+	ids, err := parseIDs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, p, err := setup(&global)
+	if err != nil {
+		return err
+	}
+
+	list := make([]models.Subscription, 0, len(ids))
+	for _, id := range ids {
+		subscription, err := c.GetSubscription(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		list = append(list, *subscription)
+	}
+
+	return p.subscriptions(list)
+}
+
+func runCreate(ctx context.Context, args []string) error {
+	var global globalFlags
+	var req models.CreateSubscriptionRequest
+	var tags stringList
+
+	fs := newFlagSet("create", "", &global, formatTable)
+	fs.StringVar(&req.ServiceName, "service", "", "service name or alias, registered in the catalog when unknown")
+	fs.StringVar(&req.ServiceID, "service-id", "", "catalog service ID instead of -service")
+	fs.IntVar(&req.Price, "price", 0, "monthly price in rubles (required)")
+	fs.StringVar(&req.UserID, "user-id", "", "owner (required)")
+	fs.StringVar(&req.StartDate, "start", "", "start date, MM-YYYY or YYYY-MM-DD (required)")
+	fs.StringVar(&req.EndDate, "end", "", "end date, MM-YYYY or YYYY-MM-DD")
+	fs.StringVar(&req.Category, "category", "", "category, the one of the catalog service by default")
+	fs.Var(&tags, "tag", "tag; repeat or separate with commas for several")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	req.Tags = tags
+
+	if req.ServiceName == "" && req.ServiceID == "" {
+		return usageError{"-service or -service-id is required"}
+	}
+	if req.UserID == "" || req.StartDate == "" {
+		return usageError{"-user-id and -start are required"}
+	}
+	if _, err := optionalUUID("user-id", req.UserID); err != nil {
+		return err
+	}
+	if _, err := optionalUUID("service-id", req.ServiceID); err != nil {
+		return err
+	}
+
+	c, p, err := setup(&global)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := c.CreateSubscription(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return p.subscriptions([]models.Subscription{*subscription})
+}
+
+func runUpdate(ctx context.Context, args []string) error {
+	var global globalFlags
+	id, patch, err := parseUpdate(args, &global)
+	if err != nil {
+		return err
+	}
+
+	c, p, err := setup(&global)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := c.UpdateSubscription(ctx, id, patch)
+	if err != nil {
+		return err
+	}
+
+	return p.subscriptions([]models.Subscription{*subscription})
+}
+
+// parseUpdate reads the arguments of update into a patch of the fields set
+// on the command line. Empty -end, -category and -tag remove the field.
+func parseUpdate(args []string, global *globalFlags) (uuid.UUID, models.SubscriptionPatch, error) {
+	var patch models.SubscriptionPatch
+	var end, category string
+	var price int
+	var tags stringList
+
+	fs := newFlagSet("update", "<id>", global, formatTable)
+	fs.StringVar(&patch.ServiceName, "service", "", "service name or alias")
+	fs.StringVar(&patch.ServiceID, "service-id", "", "catalog service ID")
+	fs.IntVar(&price, "price", 0, "monthly price in rubles")
+	fs.StringVar(&patch.StartDate, "start", "", "start date, MM-YYYY or YYYY-MM-DD")
+	fs.StringVar(&end, "end", "", "end date, MM-YYYY or YYYY-MM-DD; empty removes it")
+	fs.StringVar(&category, "category", "", "category; empty removes it")
+	fs.Var(&tags, "tag", "replaces the tags; repeat or separate with commas for several, empty removes them")
+	ids, err := parseIDs(fs, args)
+	if err != nil {
+		return uuid.Nil, patch, err
+	}
+	if len(ids) != 1 {
+		return uuid.Nil, patch, usageError{"update takes one subscription ID"}
+	}
+
+	changed := 0
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "price":
+			patch.Price = &price
+		case "end":
+			patch.EndDate = nonEmpty(end)
+			patch.ClearEndDate = end == ""
+		case "category":
+			patch.Category = nonEmpty(category)
+			patch.ClearCategory = category == ""
+		case "tag":
+			patch.Tags = tags
+			patch.ClearTags = len(tags) == 0
+		case "service", "service-id", "start":
+		default:
+			return
+		}
+		changed++
+	})
+	if changed == 0 {
+		return uuid.Nil, patch, usageError{"nothing to update, set at least one field flag"}
+	}
+
+	return ids[0], patch, nil
+}
+
+func runDelete(ctx context.Context, args []string) error {
+	var global globalFlags
+
+	fs := newFlagSet("delete", "<id>...", &global, formatTable)
+	ids, err := parseIDs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := global.client()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := c.DeleteSubscription(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		fmt.Fprintln(os.Stderr, "Deleted", id)
+	}
+	return nil
+}
+
+func runAggregate(ctx context.Context, args []string) error {
+	var global globalFlags
+	var filter filterFlags
+	var req models.AggregationRequest
+
+	fs := newFlagSet("aggregate", "", &global, formatTable)
+	filter.register(fs)
+	fs.StringVar(&req.StartDate, "start", "", "start of the period, MM-YYYY or YYYY-MM-DD (required)")
+	fs.StringVar(&req.EndDate, "end", "", "end of the period, inclusive (required)")
+	fs.StringVar(&req.GroupBy, "group-by", "", "break the total down by service, category or tag")
+	fs.BoolVar(&req.Prorate, "prorate", false, "charge partial months by days")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if req.StartDate == "" || req.EndDate == "" {
+		return usageError{"-start and -end are required"}
+	}
+
+	f, err := filter.filter()
+	if err != nil {
+		return err
+	}
+	req.UserID = f.UserID
+	req.ServiceID = f.ServiceID
+	req.ServiceName = f.ServiceName
+	req.Category = f.Category
+	req.Tags = f.Tags
+
+	c, p, err := setup(&global)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.AggregateSubscriptions(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return p.aggregation(result)
+}
+
+func setup(global *globalFlags) (*client.Client, *printer, error) {
+	p, err := global.printer()
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := global.client()
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, p, nil
+}
+
+// collect reads all subscriptions of the iterator
+func collect(it *client.Iterator[models.Subscription]) ([]models.Subscription, error) {
+	var list []models.Subscription
+	for it.Next() {
+		list = append(list, it.Value())
+	}
+	return list, it.Err()
+}
+
+// parseIDs parses the flags and requires at least one subscription ID
+func parseIDs(fs *flag.FlagSet, args []string) ([]uuid.UUID, error) {
+	positional, err := parse(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) == 0 {
+		return nil, usageError{"subscription ID is required"}
+	}
+
+	ids := make([]uuid.UUID, 0, len(positional))
+	for _, arg := range positional {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return nil, usageError{fmt.Sprintf("invalid subscription ID %q", arg)}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func optionalUUID(name, value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, usageError{fmt.Sprintf("invalid -%s %q, expected a UUID", name, value)}
+	}
+	return &id, nil
+}
+
+func nonEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseUpdate(t *testing.T) {
+	const id = "9e525b05-16c9-4cdf-ace8-d3505334eada"
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantBody string
+		wantErr  bool
+	}{
+		{name: "price", args: []string{id, "-price", "450"}, wantBody: `{"price":450}`},
+		{name: "free", args: []string{id, "-price", "0"}, wantBody: `{"price":0}`},
+		{name: "values", args: []string{id, "-end", "12-2025", "-category", "video", "-tag", "family,video"},
+			wantBody: `{"end_date":"12-2025","category":"video","tags":["family","video"]}`},
+		{name: "empty end removes it", args: []string{id, "-end", ""}, wantBody: `{"end_date":null}`},
+		{name: "empty category removes it", args: []string{id, "-category="}, wantBody: `{"category":null}`},
+		{name: "empty tag removes tags", args: []string{id, "-tag", ""}, wantBody: `{"tags":null}`},
+		{name: "removals with a change", args: []string{id, "-service", "Kion", "-end", "", "-category", "", "-tag", ""},
+			wantBody: `{"category":null,"end_date":null,"service_name":"Kion","tags":null}`},
+		{name: "global flags only", args: []string{id, "-o", "json"}, wantErr: true},
+		{name: "no ID", args: []string{"-price", "1"}, wantErr: true},
+		{name: "two IDs", args: []string{id, id, "-price", "1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var global globalFlags
+			got, patch, err := parseUpdate(tt.args, &global)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUpdate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != id {
+				t.Errorf("parseUpdate() id = %s, want %s", got, id)
+			}
+
+			body, err := json.Marshal(patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("patch body = %s, want %s", body, tt.wantBody)
+			}
+		})
+	}
+}
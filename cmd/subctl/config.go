@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile holds the connection settings of one deployment
+type Profile struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	Token  string `yaml:"token"`
+	Tenant string `yaml:"tenant"`
+}
+
+// Config is the file of named profiles, by default
+// ~/.config/subctl/config.yaml:
+//
+//	current: prod
+//	profiles:
+//	  prod:
+//	    url: https://subscriptions.example.com
+//	    api_key: sa_...
+//	    tenant: 00000000-0000-0000-0000-000000000001
+//	  local:
+//	    url: http://localhost:8080
+type Config struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("SUBCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "subctl", "config.yaml")
+}
+
+// loadProfile reads the named profile, the current one when name is empty.
+// A missing config file yields an empty profile unless a name was given.
+func loadProfile(path, name string) (Profile, error) {
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && name == "":
+		return Profile{}, nil
+	case err != nil:
+		return Profile{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if name == "" {
+		name = cfg.Current
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	return profile, nil
+}
+
+// override replaces settings of the profile with environment variables
+// and then with flags that were set
+func (p Profile) override(flags Profile) Profile {
+	for _, setting := range []struct {
+		value *string
+		env   string
+		flag  string
+	}{
+		{&p.URL, "SUBCTL_URL", flags.URL},
+		{&p.APIKey, "SUBCTL_API_KEY", flags.APIKey},
+		{&p.Token, "SUBCTL_TOKEN", flags.Token},
+		{&p.Tenant, "SUBCTL_TENANT", flags.Tenant},
+	} {
+		if value := os.Getenv(setting.env); value != "" {
+			*setting.value = value
+		}
+		if setting.flag != "" {
+			*setting.value = setting.flag
+		}
+	}
+
+	if p.URL == "" {
+		p.URL = "http://localhost:8080"
+	}
+	return p
+}
+
+func (p Profile) tenantID() (uuid.UUID, error) {
+	if p.Tenant == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(p.Tenant)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid tenant ID %q", p.Tenant)
+	}
+	return id, nil
+}
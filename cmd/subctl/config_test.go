@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const testConfig = `current: prod
+profiles:
+  prod:
+    url: https://subscriptions.example.com
+    api_key: sa_prod
+    tenant: 00000000-0000-0000-0000-000000000001
+  local:
+    url: http://localhost:9090
+`
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("profiles: ["), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.yaml")
+
+	tests := []struct {
+		name    string
+		path    string
+		profile string
+		want    Profile
+		wantErr string
+	}{
+		{name: "current", path: path, want: Profile{URL: "https://subscriptions.example.com", APIKey: "sa_prod", Tenant: "00000000-0000-0000-0000-000000000001"}},
+		{name: "named", path: path, profile: "local", want: Profile{URL: "http://localhost:9090"}},
+		{name: "unknown", path: path, profile: "staging", wantErr: `profile "staging" not found`},
+		{name: "missing file", path: missing},
+		{name: "missing file and named", path: missing, profile: "local", wantErr: "failed to read config"},
+		{name: "invalid file", path: invalid, wantErr: "failed to parse config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadProfile(tt.path, tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadProfile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadProfile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("loadProfile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileOverride(t *testing.T) {
+	base := Profile{URL: "https://subscriptions.example.com", APIKey: "sa_file"}
+
+	tests := []struct {
+		name    string
+		profile Profile
+		env     map[string]string
+		flags   Profile
+		want    Profile
+	}{
+		{name: "file", profile: base, want: base},
+		{name: "default URL", want: Profile{URL: "http://localhost:8080"}},
+		{name: "environment", profile: base, env: map[string]string{"SUBCTL_API_KEY": "sa_env", "SUBCTL_TENANT": "t"},
+			want: Profile{URL: base.URL, APIKey: "sa_env", Tenant: "t"}},
+		{name: "flags over environment", profile: base, env: map[string]string{"SUBCTL_API_KEY": "sa_env", "SUBCTL_URL": "http://env"},
+			flags: Profile{APIKey: "sa_flag", Token: "token"}, want: Profile{URL: "http://env", APIKey: "sa_flag", Token: "token"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"SUBCTL_URL", "SUBCTL_API_KEY", "SUBCTL_TOKEN", "SUBCTL_TENANT"} {
+				t.Setenv(name, tt.env[name])
+			}
+			if got := tt.profile.override(tt.flags); got != tt.want {
+				t.Fatalf("override() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileTenantID(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		tenant  string
+		want    uuid.UUID
+		wantErr bool
+	}{
+		{"unset", "", uuid.Nil, false},
+		{"valid", id.String(), id, false},
+		{"invalid", "nope", uuid.Nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Profile{Tenant: tt.tenant}.tenantID()
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("tenantID() = %v, %v; want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
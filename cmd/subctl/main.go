@@ -0,0 +1,180 @@
+// subctl is a command-line client of the subscription aggregator HTTP API
+// for operators. Connection settings come from profiles of a config file,
+// SUBCTL_* environment variables and flags, in increasing precedence.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"subscription-aggregator/pkg/client"
+)
+
+type command struct {
+	synopsis string
+	run      func(ctx context.Context, args []string) error
+}
+
+// commands maps subcommand names to their implementations; it is filled in
+// init since the commands refer to it for their usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"list":      {"List subscriptions", runList},
+		"get":       {"Show subscriptions by ID", runGet},
+		"create":    {"Create a subscription", runCreate},
+		"update":    {"Change fields of a subscription", runUpdate},
+		"delete":    {"Delete subscriptions by ID", runDelete},
+		"aggregate": {"Total cost of subscriptions over a period", runAggregate},
+		"import":    {"Create subscriptions from a CSV or JSON file", runImport},
+		"export":    {"Write all subscriptions matching filters as CSV or JSON", runExport},
+	}
+}
+
+// usageError is a malformed command line
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		printUsage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "subctl: unknown command %q\n\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := cmd.run(ctx, os.Args[2:])
+	var usageErr usageError
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, "subctl:", err)
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "subctl:", err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: subctl <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].synopsis)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "subctl <command> -h" for the flags of a command.`)
+}
+
+// globalFlags are the connection and output flags every command takes
+type globalFlags struct {
+	config  string
+	profile string
+	conn    Profile
+	output  string
+}
+
+func newFlagSet(name, arguments string, global *globalFlags, defaultOutput string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: subctl %s [flags] %s\n\n%s\n\nFlags:\n", name, arguments, commands[name].synopsis)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&global.config, "config", defaultConfigPath(), "config file with profiles (SUBCTL_CONFIG)")
+	fs.StringVar(&global.profile, "profile", os.Getenv("SUBCTL_PROFILE"), "profile of the config file, the current one by default (SUBCTL_PROFILE)")
+	fs.StringVar(&global.conn.URL, "url", "", "base URL of the API (SUBCTL_URL)")
+	fs.StringVar(&global.conn.APIKey, "api-key", "", "API key (SUBCTL_API_KEY)")
+	fs.StringVar(&global.conn.Token, "token", "", "bearer token (SUBCTL_TOKEN)")
+	fs.StringVar(&global.conn.Tenant, "tenant", "", "tenant ID (SUBCTL_TENANT)")
+	fs.StringVar(&global.output, "o", defaultOutput, "output format: table, json or csv")
+	return fs
+}
+
+// parse parses flags that may follow the arguments, like
+// "subctl get <id> -o json", and returns the arguments
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func (g *globalFlags) client() (*client.Client, error) {
+	profile, err := loadProfile(g.config, g.profile)
+	if err != nil {
+		return nil, err
+	}
+	profile = profile.override(g.conn)
+
+	tenantID, err := profile.tenantID()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.New(profile.URL, client.Options{
+		APIKey:    profile.APIKey,
+		Token:     profile.Token,
+		TenantID:  tenantID,
+		UserAgent: "subctl",
+	})
+}
+
+func (g *globalFlags) printer() (*printer, error) {
+	switch g.output {
+	case formatTable, formatJSON, formatCSV:
+		return &printer{format: g.output, w: os.Stdout}, nil
+	}
+	return nil, usageError{fmt.Sprintf("unknown output format %q, use table, json or csv", g.output)}
+}
+
+// stringList is a flag that may be repeated or hold comma-separated values
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantOut string
+		wantErr bool
+	}{
+		{name: "flags first", args: []string{"-o", "json", "a", "b"}, want: []string{"a", "b"}, wantOut: "json"},
+		{name: "flags after arguments", args: []string{"a", "-o", "csv", "b"}, want: []string{"a", "b"}, wantOut: "csv"},
+		{name: "no arguments", args: []string{"-o", "json"}, wantOut: "json"},
+		{name: "unknown flag", args: []string{"a", "-x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var global globalFlags
+			fs := newFlagSet("list", "", &global, formatTable)
+			fs.SetOutput(io.Discard)
+
+			got, err := parse(fs, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) || global.output != tt.wantOut {
+				t.Fatalf("parse() = %q with -o %q, want %q with %q", got, global.output, tt.want, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestStringList(t *testing.T) {
+	var l stringList
+	for _, value := range []string{"family, video", "", " music ,,"} {
+		if err := l.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := (stringList{"family", "video", "music"}); !reflect.DeepEqual(l, want) {
+		t.Fatalf("stringList = %q, want %q", l, want)
+	}
+	if got := l.String(); got != "family,video,music" {
+		t.Fatalf("String() = %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// dateLayout is how dates are printed; the API accepts it as input
+const dateLayout = "2006-01-02"
+
+// subscriptionColumns are the CSV columns of subscriptions. The import
+// command reads the same columns, so exports can be imported again.
+var subscriptionColumns = []string{
+	"id", "service_name", "service_id", "price", "user_id", "start_date", "end_date",
+	"category", "tags", "split_type", "created_at",
+}
+
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func (p *printer) subscriptions(list []models.Subscription) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(list)
+	case formatCSV:
+		cw := csv.NewWriter(p.w)
+		cw.Write(subscriptionColumns)
+		for _, s := range list {
+			cw.Write([]string{
+				s.ID.String(), s.ServiceName, s.ServiceID.String(), strconv.Itoa(s.Price), s.UserID.String(),
+				s.StartDate.Format(dateLayout), optionalDate(s.EndDate), optional(s.Category),
+				strings.Join(s.Tags, ";"), s.SplitType, s.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSERVICE\tPRICE\tUSER\tSTART\tEND\tCATEGORY\tTAGS")
+	for _, s := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			s.ID, s.ServiceName, s.Price, s.UserID, s.StartDate.Format(dateLayout),
+			orDash(optionalDate(s.EndDate)), orDash(optional(s.Category)), orDash(strings.Join(s.Tags, ",")))
+	}
+	return tw.Flush()
+}
+
+func (p *printer) aggregation(result *models.AggregationResponse) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(result)
+	case formatCSV:
+		cw := csv.NewWriter(p.w)
+		cw.Write([]string{"key", "total_cost"})
+		for _, group := range result.Groups {
+			cw.Write([]string{group.Key, strconv.FormatInt(group.TotalCost, 10)})
+		}
+		cw.Write([]string{"total", strconv.FormatInt(result.TotalCost, 10)})
+		cw.Flush()
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "PERIOD\t%s\n", result.Period)
+	if result.UserID != nil {
+		fmt.Fprintf(tw, "USER\t%s\n", result.UserID)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\n", result.TotalCost)
+	if len(result.Groups) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "%s\tTOTAL\n", strings.ToUpper(result.GroupBy))
+		for _, group := range result.Groups {
+			fmt.Fprintf(tw, "%s\t%d\n", group.Key, group.TotalCost)
+		}
+	}
+	return tw.Flush()
+}
+
+func (p *printer) json(v interface{}) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func optional(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func optionalDate(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(dateLayout)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"subscription-aggregator/pkg/client"
+	"subscription-aggregator/pkg/models"
+)
+
+func runImport(ctx context.Context, args []string) error {
+	var global globalFlags
+	var format string
+
+	fs := newFlagSet("import", "<file>", &global, formatTable)
+	fs.StringVar(&format, "format", "", "format of the file, csv or json; by extension by default")
+	positional, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{"import takes one file, - for standard input"}
+	}
+	path := positional[0]
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	if format != formatCSV && format != formatJSON {
+		return usageError{"cannot tell the format of " + path + ", set -format csv or json"}
+	}
+
+	c, p, err := setup(&global)
+	if err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if path != "-" {
+		if in, err = os.Open(path); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+
+	var requests []models.CreateSubscriptionRequest
+	if format == formatCSV {
+		requests, err = readCSV(in)
+	} else {
+		err = json.NewDecoder(in).Decode(&requests)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	for i := range requests {
+		normalize(&requests[i])
+	}
+
+	created := make([]models.Subscription, 0, len(requests))
+	for i, req := range requests {
+		// The same record gets the same key, so running an interrupted
+		// import again skips the records created already
+		subscription, err := c.CreateSubscription(client.WithIdempotencyKey(ctx, importKey(req)), req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Created %d of %d subscriptions; the import can be run again\n", len(created), len(requests))
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+		created = append(created, *subscription)
+	}
+
+	fmt.Fprintf(os.Stderr, "Created %d subscriptions\n", len(created))
+	return p.subscriptions(created)
+}
+
+func runExport(ctx context.Context, args []string) error {
+	var global globalFlags
+	var filter filterFlags
+	var file string
+
+	fs := newFlagSet("export", "", &global, formatCSV)
+	filter.register(fs)
+	fs.StringVar(&file, "file", "", "file to write, standard output by default")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if global.output == formatTable {
+		return usageError{"export writes csv or json"}
+	}
+
+	c, p, err := setup(&global)
+	if err != nil {
+		return err
+	}
+	f, err := filter.filter()
+	if err != nil {
+		return err
+	}
+
+	list, err := collect(c.Subscriptions(ctx, f))
+	if err != nil {
+		return err
+	}
+
+	if file != "" {
+		out, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		p.w = out
+	}
+
+	if err := p.subscriptions(list); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d subscriptions\n", len(list))
+	return nil
+}
+
+// readCSV reads subscriptions from a CSV file with a header row naming the
+// columns of subscriptionColumns. Columns set by the server, like id, are
+// ignored, so exported files can be imported.
+func readCSV(r io.Reader) ([]models.CreateSubscriptionRequest, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing header row: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	if _, ok := columns["price"]; !ok {
+		return nil, errors.New("missing price column")
+	}
+
+	var requests []models.CreateSubscriptionRequest
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return requests, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		req := models.CreateSubscriptionRequest{
+			ServiceName: value("service_name"),
+			ServiceID:   value("service_id"),
+			UserID:      value("user_id"),
+			StartDate:   value("start_date"),
+			EndDate:     value("end_date"),
+			Category:    value("category"),
+		}
+		if req.Price, err = strconv.Atoi(value("price")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid price %q", line, value("price"))
+		}
+		for _, tag := range strings.Split(value("tags"), ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				req.Tags = append(req.Tags, tag)
+			}
+		}
+
+		requests = append(requests, req)
+	}
+}
+
+// normalize adapts exported records for creation: service IDs differ
+// between tenants and deployments while names resolve everywhere, and JSON
+// exports carry RFC 3339 timestamps instead of dates
+func normalize(req *models.CreateSubscriptionRequest) {
+	if req.ServiceName != "" {
+		req.ServiceID = ""
+	}
+	for _, date := range []*string{&req.StartDate, &req.EndDate} {
+		if t, err := time.Parse(time.RFC3339, *date); err == nil {
+			*date = t.Format(dateLayout)
+		}
+	}
+}
+
+func importKey(req models.CreateSubscriptionRequest) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return "subctl-import-" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestReadCSV(t *testing.T) {
+	userID := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []models.CreateSubscriptionRequest
+		wantErr string
+	}{
+		{
+			name:  "columns in any order and case",
+			input: "Price, user_id,SERVICE_NAME,start_date,tags\n599," + userID + ",Netflix,2025-01-01, family ; video ;\n",
+			want: []models.CreateSubscriptionRequest{
+				{ServiceName: "Netflix", Price: 599, UserID: userID, StartDate: "2025-01-01", Tags: []string{"family", "video"}},
+			},
+		},
+		{
+			name:  "short rows and unknown columns",
+			input: "price,user_id,note,end_date\n0," + userID + "\n100,,x,12-2025\n",
+			want: []models.CreateSubscriptionRequest{
+				{Price: 0, UserID: userID},
+				{Price: 100, EndDate: "12-2025"},
+			},
+		},
+		{name: "header only", input: "price,user_id\n"},
+		{name: "empty", input: "", wantErr: "missing header row"},
+		{name: "no price column", input: "user_id\n" + userID + "\n", wantErr: "missing price column"},
+		{name: "invalid price", input: "price\n599\nfree\n", wantErr: `line 3: invalid price "free"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCSV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readCSV() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readCSV() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("readCSV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExportImport checks that CSV exports read back as the subscriptions
+// they were made of
+func TestExportImport(t *testing.T) {
+	category := "video"
+	end := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	list := []models.Subscription{
+		{
+			ID: uuid.New(), ServiceName: "Netflix", ServiceID: uuid.New(), Price: 599, UserID: uuid.New(),
+			StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: &end, Category: &category,
+			Tags: []string{"family", "video"}, SplitType: models.SplitNone, CreatedAt: time.Now(),
+		},
+		{
+			ID: uuid.New(), ServiceName: "Okko", ServiceID: uuid.New(), Price: 0, UserID: uuid.New(),
+			StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), SplitType: models.SplitNone, CreatedAt: time.Now(),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&printer{format: formatCSV, w: &buf}).subscriptions(list); err != nil {
+		t.Fatal(err)
+	}
+	requests, err := readCSV(&buf)
+	if err != nil {
+		t.Fatalf("readCSV() error = %v", err)
+	}
+
+	want := []models.CreateSubscriptionRequest{
+		{ServiceName: "Netflix", Price: 599, UserID: list[0].UserID.String(), StartDate: "2025-01-01", EndDate: "2025-06-30", Category: "video", Tags: []string{"family", "video"}},
+		{ServiceName: "Okko", Price: 0, UserID: list[1].UserID.String(), StartDate: "2025-03-01"},
+	}
+	for i := range requests {
+		normalize(&requests[i])
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("imported %+v, want %+v", requests, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	serviceID := uuid.New().String()
+
+	tests := []struct {
+		name string
+		req  models.CreateSubscriptionRequest
+		want models.CreateSubscriptionRequest
+	}{
+		{"name wins over ID", models.CreateSubscriptionRequest{ServiceName: "Netflix", ServiceID: serviceID, StartDate: "2025-01-01"},
+			models.CreateSubscriptionRequest{ServiceName: "Netflix", StartDate: "2025-01-01"}},
+		{"ID alone kept", models.CreateSubscriptionRequest{ServiceID: serviceID, StartDate: "01-2025"},
+			models.CreateSubscriptionRequest{ServiceID: serviceID, StartDate: "01-2025"}},
+		{"timestamps become dates", models.CreateSubscriptionRequest{StartDate: "2025-01-01T00:00:00Z", EndDate: "2025-06-30T00:00:00+03:00"},
+			models.CreateSubscriptionRequest{StartDate: "2025-01-01", EndDate: "2025-06-30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			normalize(&req)
+			if !reflect.DeepEqual(req, tt.want) {
+				t.Fatalf("normalize() = %+v, want %+v", req, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportKey(t *testing.T) {
+	req := models.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 599, UserID: uuid.New().String(), StartDate: "2025-01-01"}
+	key := importKey(req)
+
+	if !strings.HasPrefix(key, "subctl-import-") || len(key) != len("subctl-import-")+32 {
+		t.Fatalf("importKey() = %q, want subctl-import- and 32 hex digits", key)
+	}
+	if again := importKey(req); again != key {
+		t.Fatalf("importKey() = %q then %q, want the same key for the same record", key, again)
+	}
+	req.Price = 699
+	if other := importKey(req); other == key {
+		t.Fatalf("importKey() = %q for different records", key)
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const apiKeyPrefix = "sa_"
+
+// GenerateAPIKey returns a new random API key and the short prefix used to
+// identify it in listings. Only the hash of the key is ever stored.
+func GenerateAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	key = apiKeyPrefix + hex.EncodeToString(buf)
+	return key, KeyPrefix(key), nil
+}
+
+// KeyPrefix returns the non-secret leading part of an API key
+func KeyPrefix(key string) string {
+	const length = len(apiKeyPrefix) + 8
+	if len(key) < length {
+		return key
+	}
+	return key[:length]
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 of the key. Keys carry 256 bits
+// of randomness, so a fast hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNoCredentials  = errors.New("no credentials")
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrJWTUnsupported = errors.New("JWT authentication is not configured")
+)
+
+// APIKeyHeader carries API keys; they are also accepted as bearer tokens
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator resolves the principal of a request from an API key or a
+// JWT bearer token
+type Authenticator struct {
+	db  *sqlx.DB
+	jwt *JWTVerifier
+}
+
+// NewAuthenticator returns an authenticator; jwt may be nil to accept API
+// keys only
+func NewAuthenticator(db *sqlx.DB, jwt *JWTVerifier) *Authenticator {
+	return &Authenticator{db: db, jwt: jwt}
+}
+
+// Authenticate returns the principal of the request credentials
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	return a.AuthenticateCredentials(r.Header.Get(APIKeyHeader), r.Header.Get("Authorization"))
+}
+
+// AuthenticateCredentials returns the principal of an API key or, when the
+// key is empty, of an Authorization header value
+func (a *Authenticator) AuthenticateCredentials(key, header string) (*Principal, error) {
+	if key != "" {
+		return a.authenticateAPIKey(key)
+	}
+
+	if header == "" {
+		return nil, ErrNoCredentials
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrNoCredentials
+	}
+	token = strings.TrimSpace(token)
+
+	if strings.HasPrefix(token, apiKeyPrefix) {
+		return a.authenticateAPIKey(token)
+	}
+
+	if a.jwt == nil {
+		return nil, ErrJWTUnsupported
+	}
+	return a.jwt.Verify(token)
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (*Principal, error) {
+	var row struct {
+		ID       uuid.UUID      `db:"id"`
+		TenantID uuid.UUID      `db:"tenant_id"`
+		UserID   *uuid.UUID     `db:"user_id"`
+		Scopes   pq.StringArray `db:"scopes"`
+		Roles    pq.StringArray `db:"roles"`
+	}
+
+	query := `
+		SELECT id, tenant_id, user_id, scopes, roles FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())`
+
+	if err := a.db.Get(&row, query, HashAPIKey(key)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if _, err := a.db.Exec(`UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, row.ID); err != nil {
+		return nil, err
+	}
+
+	return &Principal{
+		Subject:  "api_key:" + row.ID.String(),
+		Method:   MethodAPIKey,
+		TenantID: row.TenantID,
+		UserID:   row.UserID,
+		Scopes:   row.Scopes,
+		Roles:    row.Roles,
+	}, nil
+}
+
+// EnsureAPIKey stores the key for the tenant with all scopes and the admin
+// role unless it is already known. Used to bootstrap the first key.
+func EnsureAPIKey(db *sqlx.DB, tenantID uuid.UUID, name, key string) error {
+	_, err := db.Exec(`
+		INSERT INTO api_keys (tenant_id, name, key_prefix, key_hash, scopes, roles)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key_hash) DO NOTHING`,
+		tenantID, name, KeyPrefix(key), HashAPIKey(key), pq.StringArray{ScopeAll}, pq.StringArray{RoleAdmin})
+	return err
+}
@@ -0,0 +1,346 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// clockSkew is tolerated when checking exp and nbf
+const clockSkew = time.Minute
+
+// jwksRefreshInterval limits how often tokens naming unknown keys reload
+// the JWKS files
+const jwksRefreshInterval = time.Minute
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// JWTConfig configures bearer token verification
+type JWTConfig struct {
+	Issuer      string
+	Audience    string
+	HMACSecrets []string
+	JWKSFiles   []string
+	TenantClaim string
+}
+
+// JWTVerifier verifies signed JWTs and turns their claims into principals
+type JWTVerifier struct {
+	issuer      string
+	audience    string
+	secrets     [][]byte
+	tenantClaim string
+	now         func() time.Time
+
+	mu        sync.Mutex
+	jwksFiles []string
+	keys      []publicKey
+	loadedAt  time.Time
+}
+
+// NewJWTVerifier loads the configured keys. It returns nil when neither
+// HMAC secrets nor JWKS files are configured.
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		issuer:      cfg.Issuer,
+		audience:    cfg.Audience,
+		tenantClaim: cfg.TenantClaim,
+		now:         time.Now,
+		jwksFiles:   cfg.JWKSFiles,
+	}
+	if v.tenantClaim == "" {
+		v.tenantClaim = "tenant_id"
+	}
+
+	for _, secret := range cfg.HMACSecrets {
+		if secret != "" {
+			v.secrets = append(v.secrets, []byte(secret))
+		}
+	}
+
+	keys, err := loadJWKSFiles(cfg.JWKSFiles)
+	if err != nil {
+		return nil, err
+	}
+	v.keys = keys
+	v.loadedAt = v.now()
+
+	if len(v.secrets) == 0 && len(v.keys) == 0 {
+		return nil, nil
+	}
+
+	return v, nil
+}
+
+// Verify checks the token signature and registered claims and returns the
+// principal it describes
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := v.checkClaims(claims, v.now()); err != nil {
+		return nil, err
+	}
+
+	return v.principal(claims)
+}
+
+func (v *JWTVerifier) verifySignature(alg, kid, signed string, signature []byte) error {
+	switch alg {
+	case "HS256", "HS384", "HS512":
+		newHash := hmacHash(alg)
+		for _, secret := range v.secrets {
+			mac := hmac.New(newHash, secret)
+			mac.Write([]byte(signed))
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return nil
+			}
+		}
+		return ErrInvalidToken
+
+	case "RS256", "RS384", "RS512", "ES256", "ES384", "ES512":
+		hashType := signatureHash(alg)
+		h := hashType.New()
+		h.Write([]byte(signed))
+		digest := h.Sum(nil)
+
+		for _, key := range v.keySet(kid) {
+			if kid != "" && key.kid != "" && key.kid != kid {
+				continue
+			}
+			if key.alg != "" && key.alg != alg {
+				continue
+			}
+
+			switch pub := key.key.(type) {
+			case *rsa.PublicKey:
+				if alg[0] == 'R' && rsa.VerifyPKCS1v15(pub, hashType, digest, signature) == nil {
+					return nil
+				}
+			case *ecdsa.PublicKey:
+				// Each ES algorithm is defined for a single curve
+				if alg[0] == 'E' && pub.Curve == ecdsaCurve(alg) && verifyECDSA(pub, digest, signature) {
+					return nil
+				}
+			}
+		}
+		return ErrInvalidToken
+
+	default:
+		// Includes "none"
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+}
+
+// keySet returns the JWKS keys. A token naming a key ID none of them has
+// reloads the files first, at most once per jwksRefreshInterval, so rotated
+// keys are picked up without a restart; keys that fail to load keep the
+// previous set.
+func (v *JWTVerifier) keySet(kid string) []publicKey {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if kid == "" || len(v.jwksFiles) == 0 || hasKeyID(v.keys, kid) || v.now().Sub(v.loadedAt) < jwksRefreshInterval {
+		return v.keys
+	}
+
+	v.loadedAt = v.now()
+	if keys, err := loadJWKSFiles(v.jwksFiles); err == nil {
+		v.keys = keys
+	}
+	return v.keys
+}
+
+func hasKeyID(keys []publicKey, kid string) bool {
+	for _, key := range keys {
+		if key.kid == kid {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]interface{}, now time.Time) error {
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(time.Unix(exp, 0).Add(clockSkew)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(clockSkew).Before(time.Unix(nbf, 0)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	if v.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+		}
+	}
+
+	if v.audience != "" && !containsString(stringsClaim(claims["aud"]), v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// principal maps claims to a principal. sub becomes the user ID when it is
+// a UUID; scopes come from "scope" (space separated) or "scp", roles from
+// "roles".
+func (v *JWTVerifier) principal(claims map[string]interface{}) (*Principal, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+
+	p := &Principal{
+		Subject: subject,
+		Method:  MethodJWT,
+		Roles:   stringsClaim(claims["roles"]),
+	}
+
+	if userID, err := uuid.Parse(subject); err == nil {
+		p.UserID = &userID
+	}
+
+	if scope, ok := claims["scope"].(string); ok {
+		p.Scopes = strings.Fields(scope)
+	} else {
+		p.Scopes = stringsClaim(claims["scp"])
+	}
+
+	if value, ok := claims[v.tenantClaim].(string); ok && value != "" {
+		tenantID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s claim", ErrInvalidToken, v.tenantClaim)
+		}
+		p.TenantID = tenantID
+	}
+
+	return p, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func hmacHash(alg string) func() hash.Hash {
+	switch alg {
+	case "HS384":
+		return sha512.New384
+	case "HS512":
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+func signatureHash(alg string) crypto.Hash {
+	switch alg[2:] {
+	case "384":
+		return crypto.SHA384
+	case "512":
+		return crypto.SHA512
+	default:
+		return crypto.SHA256
+	}
+}
+
+func ecdsaCurve(alg string) elliptic.Curve {
+	switch alg {
+	case "ES384":
+		return elliptic.P384()
+	case "ES512":
+		return elliptic.P521()
+	default:
+		return elliptic.P256()
+	}
+}
+
+// verifyECDSA checks a JOSE signature, which is r and s concatenated
+func verifyECDSA(pub *ecdsa.PublicKey, digest, signature []byte) bool {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	return ecdsa.Verify(pub, digest, r, s)
+}
+
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	value, ok := claims[name].(float64)
+	return int64(value), ok
+}
+
+// stringsClaim accepts a single string or an array of strings
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func containsString(slice []string, value string) bool {
+	for _, item := range slice {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
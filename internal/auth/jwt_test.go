@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestJWTVerifierVerify(t *testing.T) {
+	rsaKey := generateRSAKey(t)
+	p256Key := generateECKey(t, elliptic.P256())
+	p384Key := generateECKey(t, elliptic.P384())
+
+	jwks := writeJWKS(t, t.TempDir(), []jsonWebKey{
+		rsaJWK("rsa-1", rsaKey),
+		ecJWK("ec-256", "P-256", p256Key),
+		ecJWK("ec-384", "P-384", p384Key),
+	})
+
+	v := newTestVerifier(t, JWTConfig{
+		Issuer:      "https://issuer.example",
+		Audience:    "subscriptions",
+		HMACSecrets: []string{"secret"},
+		JWKSFiles:   []string{jwks},
+	})
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e",
+			"iss":   "https://issuer.example",
+			"aud":   []string{"other", "subscriptions"},
+			"exp":   testNow.Add(time.Hour).Unix(),
+			"scope": "read write",
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(c, name)
+				continue
+			}
+			c[name] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "HS256", token: signHMAC(t, "HS256", "", "secret", claims(nil))},
+		{name: "HS512", token: signHMAC(t, "HS512", "", "secret", claims(nil))},
+		{name: "RS256", token: signRSA(t, "RS256", "rsa-1", rsaKey, claims(nil))},
+		{name: "ES256", token: signEC(t, "ES256", "ec-256", p256Key, claims(nil))},
+		{name: "expired within skew", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()}))},
+		{name: "expired", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()})), wantErr: true},
+		{name: "missing exp", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"exp": nil})), wantErr: true},
+		{name: "not valid yet", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"nbf": testNow.Add(2 * time.Minute).Unix()})), wantErr: true},
+		{name: "valid since", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"nbf": testNow.Add(-time.Minute).Unix()}))},
+		{name: "wrong issuer", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"iss": "https://evil.example"})), wantErr: true},
+		{name: "wrong audience", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"aud": "other"})), wantErr: true},
+		{name: "missing sub", token: signHMAC(t, "HS256", "", "secret", claims(map[string]interface{}{"sub": nil})), wantErr: true},
+		{name: "wrong secret", token: signHMAC(t, "HS256", "", "guess", claims(nil)), wantErr: true},
+		{name: "tampered claims", token: tamper(t, signHMAC(t, "HS256", "", "secret", claims(nil)), claims(map[string]interface{}{"scope": "admin"})), wantErr: true},
+		{name: "alg none", token: unsigned(t, claims(nil)), wantErr: true},
+		{name: "HMAC signed with the RSA public key", token: signHMAC(t, "HS256", "rsa-1", string(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)), claims(nil)), wantErr: true},
+		{name: "RS256 header on an EC key", token: signEC(t, "RS256", "ec-256", p256Key, claims(nil)), wantErr: true},
+		{name: "ES256 with a P-384 key", token: signEC(t, "ES256", "ec-384", p384Key, claims(nil)), wantErr: true},
+		{name: "ES384 with a P-256 key", token: signEC(t, "ES384", "ec-256", p256Key, claims(nil)), wantErr: true},
+		{name: "unknown kid", token: signRSA(t, "RS256", "rsa-2", generateRSAKey(t), claims(nil)), wantErr: true},
+		{name: "malformed", token: "not.a.token.at.all", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierPrincipal(t *testing.T) {
+	v := newTestVerifier(t, JWTConfig{HMACSecrets: []string{"secret"}})
+
+	p, err := v.Verify(signHMAC(t, "HS256", "", "secret", map[string]interface{}{
+		"sub":       "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e",
+		"exp":       testNow.Add(time.Hour).Unix(),
+		"scp":       []string{"read"},
+		"roles":     "admin",
+		"tenant_id": "00000000-0000-0000-0000-000000000002",
+	}))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if p.Method != MethodJWT || p.UserID == nil || p.UserID.String() != "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e" {
+		t.Errorf("principal = %+v, want the subject as user ID", p)
+	}
+	if !p.HasScope(ScopeRead) || p.HasScope(ScopeWrite) || !p.HasRole(RoleAdmin) {
+		t.Errorf("principal scopes %q and roles %q, want read and admin", p.Scopes, p.Roles)
+	}
+	if p.TenantID.String() != "00000000-0000-0000-0000-000000000002" {
+		t.Errorf("principal tenant = %s, want the tenant_id claim", p.TenantID)
+	}
+
+	_, err = v.Verify(signHMAC(t, "HS256", "", "secret", map[string]interface{}{
+		"sub":       "service",
+		"exp":       testNow.Add(time.Hour).Unix(),
+		"tenant_id": "nope",
+	}))
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() with an invalid tenant claim error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestJWTVerifierRefreshesJWKS(t *testing.T) {
+	oldKey := generateRSAKey(t)
+	newKey := generateRSAKey(t)
+
+	dir := t.TempDir()
+	path := writeJWKS(t, dir, []jsonWebKey{rsaJWK("old", oldKey)})
+
+	now := testNow
+	v := newTestVerifier(t, JWTConfig{JWKSFiles: []string{path}})
+	v.now = func() time.Time { return now }
+
+	claims := map[string]interface{}{"sub": "service", "exp": testNow.Add(time.Hour).Unix()}
+	rotated := signRSA(t, "RS256", "new", newKey, claims)
+
+	if _, err := v.Verify(signRSA(t, "RS256", "old", oldKey, claims)); err != nil {
+		t.Fatalf("Verify() with the loaded key error = %v", err)
+	}
+
+	writeJWKS(t, dir, []jsonWebKey{rsaJWK("old", oldKey), rsaJWK("new", newKey)})
+
+	// Files were just loaded
+	if _, err := v.Verify(rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() before the refresh interval error = %v, want ErrInvalidToken", err)
+	}
+
+	now = now.Add(jwksRefreshInterval)
+	if _, err := v.Verify(rotated); err != nil {
+		t.Fatalf("Verify() with the rotated key error = %v", err)
+	}
+
+	// A broken file keeps the loaded keys
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(jwksRefreshInterval)
+	if _, err := v.Verify(signRSA(t, "RS256", "unknown", generateRSAKey(t), claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() with an unknown key error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := v.Verify(rotated); err != nil {
+		t.Fatalf("Verify() after a failed refresh error = %v", err)
+	}
+}
+
+func newTestVerifier(t *testing.T, cfg JWTConfig) *JWTVerifier {
+	t.Helper()
+
+	v, err := NewJWTVerifier(cfg)
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() error = %v", err)
+	}
+	v.now = func() time.Time { return testNow }
+	v.loadedAt = testNow
+	return v
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func generateECKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: "RS256",
+		N:   b64(key.PublicKey.N.Bytes()),
+		E:   b64([]byte{1, 0, 1}),
+	}
+}
+
+func ecJWK(kid, crv string, key *ecdsa.PrivateKey) jsonWebKey {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: crv,
+		X:   b64(key.PublicKey.X.FillBytes(make([]byte, size))),
+		Y:   b64(key.PublicKey.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func writeJWKS(t *testing.T, dir string, keys []jsonWebKey) string {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b64(data)
+}
+
+func signingInput(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	return encodeSegment(t, header) + "." + encodeSegment(t, claims)
+}
+
+func signHMAC(t *testing.T, alg, kid, secret string, claims map[string]interface{}) string {
+	t.Helper()
+
+	signed := signingInput(t, alg, kid, claims)
+	mac := hmac.New(hmacHash(alg), []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + b64(mac.Sum(nil))
+}
+
+func signRSA(t *testing.T, alg, kid string, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+
+	signed := signingInput(t, alg, kid, claims)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, signatureHash(alg), digest(signatureHash(alg), signed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(signature)
+}
+
+// signEC signs with the hash the algorithm names, whatever the curve
+func signEC(t *testing.T, alg, kid string, key *ecdsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+
+	signed := signingInput(t, alg, kid, claims)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest(signatureHash(alg), signed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := (key.Curve.Params().BitSize + 7) / 8
+	signature := append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	return signed + "." + b64(signature)
+}
+
+func unsigned(t *testing.T, claims map[string]interface{}) string {
+	return signingInput(t, "none", "", claims) + "."
+}
+
+// tamper replaces the claims of a signed token, keeping its signature
+func tamper(t *testing.T, token string, claims map[string]interface{}) string {
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + encodeSegment(t, claims) + "." + parts[2]
+}
+
+func digest(h crypto.Hash, signed string) []byte {
+	hasher := h.New()
+	hasher.Write([]byte(signed))
+	return hasher.Sum(nil)
+}
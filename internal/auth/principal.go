@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Authentication methods
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Scopes granted to credentials
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin" // Managing API keys
+	ScopeAll   = "*"
+)
+
+// Roles checked by the authorization policy
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleFinance = "finance" // Read-only access to all subscriptions and aggregates
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject  string
+	Method   string
+	TenantID uuid.UUID // uuid.Nil when the credential is not bound to a tenant
+	UserID   *uuid.UUID
+	Scopes   []string
+	Roles    []string
+}
+
+// HasScope reports whether the principal was granted the scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the principal has the role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil
+}
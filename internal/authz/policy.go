@@ -0,0 +1,181 @@
+package authz
+
+import (
+	"fmt"
+	"subscription-aggregator/internal/auth"
+
+	"github.com/google/uuid"
+)
+
+// Action is an operation on a resource
+type Action string
+
+const (
+	ActionList      Action = "list"
+	ActionRead      Action = "read"
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionDelete    Action = "delete"
+	ActionAggregate Action = "aggregate"
+)
+
+// DeniedError explains why the policy rejected an action
+type DeniedError struct {
+	Action Action
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("%s denied: %s", e.Action, e.Reason)
+}
+
+// Policy decides which subscriptions, households and catalog entries a
+// principal may access.
+//
+// Admins may do anything. The finance role reads every subscription and
+// runs aggregates but cannot modify anything. Regular users only see and
+// modify subscriptions they own, aggregate their own costs, change
+// households they belong to and read their own user. Only admins change the
+// service catalog and users. Without a principal, i.e. with authentication disabled,
+// everything is allowed.
+type Policy struct{}
+
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+// Authorize checks the action against a subscription owned by owner. For
+// list the owner is ignored, and for aggregate it is the user whose costs
+// are aggregated (nil for all users).
+func (p *Policy) Authorize(principal *auth.Principal, action Action, owner *uuid.UUID) error {
+	if principal == nil {
+		return nil
+	}
+
+	switch roleOf(principal) {
+	case auth.RoleAdmin:
+		return nil
+
+	case auth.RoleFinance:
+		switch action {
+		case ActionList, ActionRead, ActionAggregate:
+			return nil
+		}
+		return &DeniedError{Action: action, Reason: "finance role is read-only"}
+
+	case auth.RoleUser:
+		if principal.UserID == nil {
+			return &DeniedError{Action: action, Reason: "principal has no user identity"}
+		}
+		if action == ActionList {
+			return nil
+		}
+		if owner == nil || *owner != *principal.UserID {
+			return &DeniedError{Action: action, Reason: "subscription belongs to another user"}
+		}
+		return nil
+	}
+
+	return &DeniedError{Action: action, Reason: "principal has no role"}
+}
+
+// AuthorizeAdmin checks an action only admins may take, such as changing
+// the service catalog or users
+func (p *Policy) AuthorizeAdmin(principal *auth.Principal, action Action) error {
+	if principal == nil || roleOf(principal) == auth.RoleAdmin {
+		return nil
+	}
+	return &DeniedError{Action: action, Reason: "admin role required"}
+}
+
+// AuthorizeUser checks the action against the user with the ID. Admins and
+// the finance role read every user, regular users list and read only
+// themselves; listing is narrowed by OwnerScope. Other actions need an
+// admin.
+func (p *Policy) AuthorizeUser(principal *auth.Principal, action Action, userID *uuid.UUID) error {
+	if action != ActionList && action != ActionRead {
+		return p.AuthorizeAdmin(principal, action)
+	}
+	if principal == nil {
+		return nil
+	}
+
+	switch roleOf(principal) {
+	case auth.RoleAdmin, auth.RoleFinance:
+		return nil
+
+	case auth.RoleUser:
+		if principal.UserID == nil {
+			return &DeniedError{Action: action, Reason: "principal has no user identity"}
+		}
+		if action == ActionList {
+			return nil
+		}
+		if userID == nil || *userID != *principal.UserID {
+			return &DeniedError{Action: action, Reason: "user is another user"}
+		}
+		return nil
+	}
+
+	return &DeniedError{Action: action, Reason: "principal has no role"}
+}
+
+// AuthorizeHousehold checks the action against a household with the
+// members; for create they are the members it is created with
+func (p *Policy) AuthorizeHousehold(principal *auth.Principal, action Action, members []uuid.UUID) error {
+	if principal == nil {
+		return nil
+	}
+
+	switch roleOf(principal) {
+	case auth.RoleAdmin:
+		return nil
+
+	case auth.RoleFinance:
+		switch action {
+		case ActionList, ActionRead:
+			return nil
+		}
+		return &DeniedError{Action: action, Reason: "finance role is read-only"}
+
+	case auth.RoleUser:
+		if principal.UserID == nil {
+			return &DeniedError{Action: action, Reason: "principal has no user identity"}
+		}
+		switch action {
+		case ActionList, ActionRead:
+			return nil
+		}
+		for _, member := range members {
+			if member == *principal.UserID {
+				return nil
+			}
+		}
+		return &DeniedError{Action: action, Reason: "principal is not a household member"}
+	}
+
+	return &DeniedError{Action: action, Reason: "principal has no role"}
+}
+
+// OwnerScope returns the user whose subscriptions the principal is limited
+// to, or nil when it may see subscriptions of every user
+func (p *Policy) OwnerScope(principal *auth.Principal) *uuid.UUID {
+	if principal == nil || roleOf(principal) != auth.RoleUser {
+		return nil
+	}
+	return principal.UserID
+}
+
+// roleOf returns the most privileged role of the principal. Principals
+// without roles that act as a user are regular users.
+func roleOf(principal *auth.Principal) string {
+	switch {
+	case principal.HasRole(auth.RoleAdmin):
+		return auth.RoleAdmin
+	case principal.HasRole(auth.RoleFinance):
+		return auth.RoleFinance
+	case principal.HasRole(auth.RoleUser) || (len(principal.Roles) == 0 && principal.UserID != nil):
+		return auth.RoleUser
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+	"subscription-aggregator/internal/auth"
+
+	"github.com/google/uuid"
+)
+
+var (
+	alice = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	bob   = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+)
+
+func principal(userID *uuid.UUID, roles ...string) *auth.Principal {
+	return &auth.Principal{Subject: "test", UserID: userID, Roles: roles}
+}
+
+func TestPolicyAuthorize(t *testing.T) {
+	p := NewPolicy()
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		action    Action
+		owner     *uuid.UUID
+		allowed   bool
+	}{
+		{name: "anonymous", principal: nil, action: ActionDelete, owner: &bob, allowed: true},
+		{name: "admin updates any", principal: principal(&alice, auth.RoleAdmin), action: ActionUpdate, owner: &bob, allowed: true},
+		{name: "finance reads any", principal: principal(nil, auth.RoleFinance), action: ActionRead, owner: &bob, allowed: true},
+		{name: "finance lists", principal: principal(nil, auth.RoleFinance), action: ActionList, allowed: true},
+		{name: "finance aggregates all users", principal: principal(nil, auth.RoleFinance), action: ActionAggregate, allowed: true},
+		{name: "finance cannot create", principal: principal(nil, auth.RoleFinance), action: ActionCreate, owner: &bob},
+		{name: "finance cannot delete", principal: principal(nil, auth.RoleFinance), action: ActionDelete, owner: &bob},
+		{name: "user reads own", principal: principal(&alice, auth.RoleUser), action: ActionRead, owner: &alice, allowed: true},
+		{name: "user updates own", principal: principal(&alice, auth.RoleUser), action: ActionUpdate, owner: &alice, allowed: true},
+		{name: "user reads foreign", principal: principal(&alice, auth.RoleUser), action: ActionRead, owner: &bob},
+		{name: "user creates for another", principal: principal(&alice, auth.RoleUser), action: ActionCreate, owner: &bob},
+		{name: "user aggregates all users", principal: principal(&alice, auth.RoleUser), action: ActionAggregate},
+		{name: "user lists", principal: principal(&alice, auth.RoleUser), action: ActionList, allowed: true},
+		{name: "user without identity", principal: principal(nil, auth.RoleUser), action: ActionRead, owner: &alice},
+		{name: "no roles with identity acts as user", principal: principal(&alice), action: ActionDelete, owner: &alice, allowed: true},
+		{name: "no roles without identity", principal: principal(nil), action: ActionList},
+		{name: "unknown role", principal: principal(&alice, "auditor"), action: ActionRead, owner: &alice},
+		{name: "most privileged role wins", principal: principal(&alice, auth.RoleUser, auth.RoleFinance), action: ActionRead, owner: &bob, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkDecision(t, p.Authorize(tt.principal, tt.action, tt.owner), tt.action, tt.allowed)
+		})
+	}
+}
+
+func TestPolicyAuthorizeAdmin(t *testing.T) {
+	p := NewPolicy()
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		allowed   bool
+	}{
+		{name: "anonymous", principal: nil, allowed: true},
+		{name: "admin", principal: principal(&alice, auth.RoleAdmin), allowed: true},
+		{name: "finance", principal: principal(nil, auth.RoleFinance)},
+		{name: "user", principal: principal(&alice, auth.RoleUser)},
+		{name: "no roles", principal: principal(&alice)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkDecision(t, p.AuthorizeAdmin(tt.principal, ActionCreate), ActionCreate, tt.allowed)
+		})
+	}
+}
+
+func TestPolicyAuthorizeUser(t *testing.T) {
+	p := NewPolicy()
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		action    Action
+		userID    *uuid.UUID
+		allowed   bool
+	}{
+		{name: "anonymous", principal: nil, action: ActionRead, userID: &bob, allowed: true},
+		{name: "admin reads any", principal: principal(&alice, auth.RoleAdmin), action: ActionRead, userID: &bob, allowed: true},
+		{name: "admin updates", principal: principal(&alice, auth.RoleAdmin), action: ActionUpdate, userID: &bob, allowed: true},
+		{name: "finance reads any", principal: principal(nil, auth.RoleFinance), action: ActionRead, userID: &bob, allowed: true},
+		{name: "finance lists", principal: principal(nil, auth.RoleFinance), action: ActionList, allowed: true},
+		{name: "finance cannot update", principal: principal(nil, auth.RoleFinance), action: ActionUpdate, userID: &bob},
+		{name: "user reads self", principal: principal(&alice, auth.RoleUser), action: ActionRead, userID: &alice, allowed: true},
+		{name: "user reads another", principal: principal(&alice, auth.RoleUser), action: ActionRead, userID: &bob},
+		{name: "user lists", principal: principal(&alice), action: ActionList, allowed: true},
+		{name: "user updates self", principal: principal(&alice, auth.RoleUser), action: ActionUpdate, userID: &alice},
+		{name: "user without identity", principal: principal(nil, auth.RoleUser), action: ActionList},
+		{name: "unknown role", principal: principal(&alice, "auditor"), action: ActionRead, userID: &alice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkDecision(t, p.AuthorizeUser(tt.principal, tt.action, tt.userID), tt.action, tt.allowed)
+		})
+	}
+}
+
+func TestPolicyAuthorizeHousehold(t *testing.T) {
+	p := NewPolicy()
+	members := []uuid.UUID{bob, alice}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		action    Action
+		members   []uuid.UUID
+		allowed   bool
+	}{
+		{name: "anonymous", principal: nil, action: ActionDelete, allowed: true},
+		{name: "admin changes any", principal: principal(nil, auth.RoleAdmin), action: ActionDelete, members: []uuid.UUID{bob}, allowed: true},
+		{name: "finance reads", principal: principal(nil, auth.RoleFinance), action: ActionRead, members: []uuid.UUID{bob}, allowed: true},
+		{name: "finance cannot change", principal: principal(nil, auth.RoleFinance), action: ActionUpdate, members: []uuid.UUID{bob}},
+		{name: "member updates", principal: principal(&alice, auth.RoleUser), action: ActionUpdate, members: members, allowed: true},
+		{name: "member deletes", principal: principal(&alice), action: ActionDelete, members: members, allowed: true},
+		{name: "user creates with self", principal: principal(&alice, auth.RoleUser), action: ActionCreate, members: members, allowed: true},
+		{name: "user creates without self", principal: principal(&alice, auth.RoleUser), action: ActionCreate, members: []uuid.UUID{bob}},
+		{name: "non-member updates", principal: principal(&alice, auth.RoleUser), action: ActionUpdate, members: []uuid.UUID{bob}},
+		{name: "non-member deletes empty household", principal: principal(&alice, auth.RoleUser), action: ActionDelete},
+		{name: "non-member reads", principal: principal(&alice, auth.RoleUser), action: ActionRead, members: []uuid.UUID{bob}, allowed: true},
+		{name: "user without identity", principal: principal(nil, auth.RoleUser), action: ActionUpdate, members: members},
+		{name: "no roles without identity", principal: principal(nil), action: ActionRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkDecision(t, p.AuthorizeHousehold(tt.principal, tt.action, tt.members), tt.action, tt.allowed)
+		})
+	}
+}
+
+func TestPolicyOwnerScope(t *testing.T) {
+	p := NewPolicy()
+
+	if got := p.OwnerScope(nil); got != nil {
+		t.Errorf("OwnerScope(nil) = %s, want nil", got)
+	}
+	if got := p.OwnerScope(principal(&alice, auth.RoleAdmin)); got != nil {
+		t.Errorf("OwnerScope(admin) = %s, want nil", got)
+	}
+	if got := p.OwnerScope(principal(nil, auth.RoleFinance)); got != nil {
+		t.Errorf("OwnerScope(finance) = %s, want nil", got)
+	}
+	if got := p.OwnerScope(principal(&alice)); got == nil || *got != alice {
+		t.Errorf("OwnerScope(user) = %v, want %s", got, alice)
+	}
+}
+
+func checkDecision(t *testing.T, err error, action Action, allowed bool) {
+	t.Helper()
+
+	if allowed {
+		if err != nil {
+			t.Fatalf("denied: %v", err)
+		}
+		return
+	}
+
+	var denied *DeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("error = %v, want *DeniedError", err)
+	}
+	if denied.Action != action {
+		t.Errorf("denied action = %s, want %s", denied.Action, action)
+	}
+}
@@ -0,0 +1,37 @@
+package billing
+
+import (
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+// PriceOn returns the price of a subscription on the day: the latest of the
+// scheduled changes taking effect by then, or the current price
+func PriceOn(price int, changes []models.PriceChange, day time.Time) int {
+	day = truncateDay(day)
+	var effective time.Time
+	for _, change := range changes {
+		date := truncateDay(change.EffectiveDate)
+		if !date.After(day) && !date.Before(effective) {
+			price = change.Price
+			effective = date
+		}
+	}
+	return price
+}
+
+// PastPriceOn returns the price a subscription had on the day given the
+// prices it had before applied changes: the earliest one still in effect
+// on the day, or the current price
+func PastPriceOn(price int, history []models.PricePeriod, day time.Time) int {
+	day = truncateDay(day)
+	var until time.Time
+	for _, period := range history {
+		date := truncateDay(period.ValidUntil)
+		if date.After(day) && (until.IsZero() || date.Before(until)) {
+			price = period.Price
+			until = date
+		}
+	}
+	return price
+}
@@ -0,0 +1,73 @@
+package billing
+
+import (
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+func TestPriceOn(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	changes := []models.PriceChange{
+		{EffectiveDate: day(2025, 3, 1), Price: 699},
+		{EffectiveDate: day(2025, 6, 15), Price: 799},
+		{EffectiveDate: day(2025, 9, 1), Price: 0},
+	}
+
+	tests := []struct {
+		name    string
+		changes []models.PriceChange
+		day     time.Time
+		want    int
+	}{
+		{"no changes", nil, day(2025, 6, 1), 599},
+		{"before the first change", changes, day(2025, 2, 28), 599},
+		{"on the effective date", changes, day(2025, 3, 1), 699},
+		{"time of day ignored", changes, time.Date(2025, 6, 15, 0, 0, 0, 1, time.UTC), 799},
+		{"between changes", changes, day(2025, 8, 31), 799},
+		{"made free", changes, day(2026, 1, 1), 0},
+		{"out of order", []models.PriceChange{changes[1], changes[0]}, day(2025, 7, 1), 799},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PriceOn(599, tt.changes, tt.day); got != tt.want {
+				t.Fatalf("PriceOn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPastPriceOn(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	history := []models.PricePeriod{
+		{ValidUntil: day(2025, 6, 15), Price: 699},
+		{ValidUntil: day(2025, 3, 1), Price: 599},
+	}
+
+	tests := []struct {
+		name    string
+		history []models.PricePeriod
+		day     time.Time
+		want    int
+	}{
+		{"no history", nil, day(2025, 1, 1), 799},
+		{"before the first change", history, day(2025, 2, 28), 599},
+		{"on the effective date", history, day(2025, 3, 1), 699},
+		{"day before the last change", history, day(2025, 6, 14), 699},
+		{"after every change", history, day(2025, 6, 15), 799},
+		{"time of day ignored", history, time.Date(2025, 6, 14, 23, 0, 0, 0, time.UTC), 699},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PastPriceOn(799, tt.history, tt.day); got != tt.want {
+				t.Fatalf("PastPriceOn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package billing
+
+import (
+	"math"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+// ProratedCost returns the cost of a monthly subscription within the period
+// [periodStart, periodEnd]. Every calendar month is charged by the share of
+// its days the subscription was active. All dates are inclusive days;
+// a nil end means the subscription is open-ended.
+func ProratedCost(price int, start time.Time, end *time.Time, periodStart, periodEnd time.Time) float64 {
+	from := maxDate(truncateDay(start), truncateDay(periodStart))
+	to := truncateDay(periodEnd)
+	if end != nil {
+		to = minDate(to, truncateDay(*end))
+	}
+
+	var cost float64
+	for day := from; !day.After(to); {
+		monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		monthEnd := monthStart.AddDate(0, 1, -1)
+		last := minDate(monthEnd, to)
+
+		activeDays := daysBetween(day, last) + 1
+		monthDays := monthEnd.Day()
+		cost += float64(price) * float64(activeDays) / float64(monthDays)
+
+		day = monthEnd.AddDate(0, 0, 1)
+	}
+
+	return cost
+}
+
+// MonthlyCost returns the cost of a monthly subscription within the period
+// [periodStart, periodEnd], charging the full price for every calendar month
+// in which the subscription was active on at least one day
+func MonthlyCost(price int, start time.Time, end *time.Time, periodStart, periodEnd time.Time) float64 {
+	from := maxDate(truncateDay(start), truncateDay(periodStart))
+	to := truncateDay(periodEnd)
+	if end != nil {
+		to = minDate(to, truncateDay(*end))
+	}
+	if to.Before(from) {
+		return 0
+	}
+
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()) + 1
+	return float64(price) * float64(months)
+}
+
+// PriceSpan is a part of a period charged at one price
+type PriceSpan struct {
+	From  time.Time
+	To    time.Time
+	Price int
+}
+
+// PriceSpans splits the period [periodStart, periodEnd] by the price of a
+// subscription with past prices. Every month is charged at the price in
+// effect on the first day of the month the subscription is active, so
+// spans are whole months except at the ends of the period. Without past
+// prices the period is one span at the current price.
+func PriceSpans(price int, history []models.PricePeriod, start time.Time, periodStart, periodEnd time.Time) []PriceSpan {
+	from, to := truncateDay(periodStart), truncateDay(periodEnd)
+	if len(history) == 0 {
+		return []PriceSpan{{From: from, To: to, Price: price}}
+	}
+
+	var spans []PriceSpan
+	for day := from; !day.After(to); {
+		monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		last := minDate(monthStart.AddDate(0, 1, -1), to)
+		monthPrice := PastPriceOn(price, history, maxDate(monthStart, truncateDay(start)))
+
+		if n := len(spans); n > 0 && spans[n-1].Price == monthPrice {
+			spans[n-1].To = last
+		} else {
+			spans = append(spans, PriceSpan{From: day, To: last, Price: monthPrice})
+		}
+
+		day = last.AddDate(0, 0, 1)
+	}
+	return spans
+}
+
+// RoundCost rounds an accumulated cost to whole currency units
+func RoundCost(cost float64) int64 {
+	return int64(math.Round(cost))
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func minDate(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxDate(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,211 @@
+package billing
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func dayPtr(s string) *time.Time {
+	t := day(s)
+	return &t
+}
+
+func TestProratedCost(t *testing.T) {
+	tests := []struct {
+		name        string
+		price       int
+		start       time.Time
+		end         *time.Time
+		periodStart time.Time
+		periodEnd   time.Time
+		want        float64
+	}{
+		{
+			name:  "whole month",
+			price: 300, start: day("2025-01-01"), end: dayPtr("2025-01-31"),
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			want: 300,
+		},
+		{
+			name:  "trial from the 20th to mid next month",
+			price: 310, start: day("2025-01-20"), end: dayPtr("2025-02-14"),
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			// 12 of 31 days in January, 14 of 28 in February
+			want: 310*12.0/31 + 310*14.0/28,
+		},
+		{
+			name:  "open-ended subscription clipped to the period",
+			price: 100, start: day("2024-06-01"), end: nil,
+			periodStart: day("2025-01-01"), periodEnd: day("2025-03-31"),
+			want: 300,
+		},
+		{
+			name:  "period starting mid-month",
+			price: 300, start: day("2025-01-01"), end: nil,
+			periodStart: day("2025-04-16"), periodEnd: day("2025-04-30"),
+			want: 150,
+		},
+		{
+			name:  "leap February",
+			price: 290, start: day("2024-02-01"), end: dayPtr("2024-02-10"),
+			periodStart: day("2024-01-01"), periodEnd: day("2024-12-31"),
+			want: 100,
+		},
+		{
+			name:  "ended before the period",
+			price: 500, start: day("2024-01-01"), end: dayPtr("2024-12-31"),
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			want: 0,
+		},
+		{
+			name:  "single day",
+			price: 310, start: day("2025-03-05"), end: dayPtr("2025-03-05"),
+			periodStart: day("2025-03-01"), periodEnd: day("2025-03-31"),
+			want: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProratedCost(tt.price, tt.start, tt.end, tt.periodStart, tt.periodEnd)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ProratedCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthlyCost(t *testing.T) {
+	tests := []struct {
+		name        string
+		price       int
+		start       time.Time
+		end         *time.Time
+		periodStart time.Time
+		periodEnd   time.Time
+		want        float64
+	}{
+		{
+			name:  "active one day of a month is charged the month",
+			price: 400, start: day("2025-01-31"), end: dayPtr("2025-02-01"),
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			want: 800,
+		},
+		{
+			name:  "open-ended over a year",
+			price: 100, start: day("2020-05-10"), end: nil,
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			want: 1200,
+		},
+		{
+			name:  "crossing a year boundary",
+			price: 50, start: day("2024-11-15"), end: dayPtr("2025-02-10"),
+			periodStart: day("2024-01-01"), periodEnd: day("2025-12-31"),
+			want: 200,
+		},
+		{
+			name:  "outside the period",
+			price: 50, start: day("2026-01-01"), end: nil,
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			want: 0,
+		},
+		{
+			name:  "ended the day before the period",
+			price: 50, start: day("2025-01-01"), end: dayPtr("2025-03-15"),
+			periodStart: day("2025-03-16"), periodEnd: day("2025-06-30"),
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MonthlyCost(tt.price, tt.start, tt.end, tt.periodStart, tt.periodEnd)
+			if got != tt.want {
+				t.Errorf("MonthlyCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceSpans(t *testing.T) {
+	history := []models.PricePeriod{
+		{ValidUntil: day("2025-03-01"), Price: 100},
+		{ValidUntil: day("2025-05-15"), Price: 200},
+	}
+
+	tests := []struct {
+		name        string
+		history     []models.PricePeriod
+		start       time.Time
+		periodStart time.Time
+		periodEnd   time.Time
+		want        []PriceSpan
+	}{
+		{
+			name:        "no history",
+			start:       day("2025-01-10"),
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			want: []PriceSpan{{day("2025-01-01"), day("2025-12-31"), 300}},
+		},
+		{
+			name:    "month of a change keeps the old price",
+			history: history, start: day("2025-01-10"),
+			periodStart: day("2025-01-01"), periodEnd: day("2025-12-31"),
+			want: []PriceSpan{
+				{day("2025-01-01"), day("2025-02-28"), 100},
+				{day("2025-03-01"), day("2025-05-31"), 200},
+				{day("2025-06-01"), day("2025-12-31"), 300},
+			},
+		},
+		{
+			name:    "period inside a month",
+			history: history, start: day("2025-01-10"),
+			periodStart: day("2025-05-20"), periodEnd: day("2025-06-10"),
+			want: []PriceSpan{
+				{day("2025-05-20"), day("2025-05-31"), 200},
+				{day("2025-06-01"), day("2025-06-10"), 300},
+			},
+		},
+		{
+			name:    "started after the month began",
+			history: []models.PricePeriod{{ValidUntil: day("2025-03-15"), Price: 100}}, start: day("2025-03-20"),
+			periodStart: day("2025-03-01"), periodEnd: day("2025-04-30"),
+			want: []PriceSpan{{day("2025-03-01"), day("2025-04-30"), 300}},
+		},
+		{
+			name:    "after every change",
+			history: history, start: day("2025-01-10"),
+			periodStart: day("2026-01-01"), periodEnd: day("2026-03-31"),
+			want: []PriceSpan{{day("2026-01-01"), day("2026-03-31"), 300}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PriceSpans(300, tt.history, tt.start, tt.periodStart, tt.periodEnd)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PriceSpans() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundCost(t *testing.T) {
+	tests := map[float64]int64{0: 0, 99.4: 99, 99.5: 100, 1234.5678: 1235}
+	for in, want := range tests {
+		if got := RoundCost(in); got != want {
+			t.Errorf("RoundCost(%v) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package billing
+
+import "time"
+
+// NextRenewal returns the first charge of a monthly subscription on or after
+// the day, not counting the first charge on the start date. Charges repeat
+// on the day of the month of the start, or on the last day of shorter
+// months.
+func NextRenewal(start, day time.Time) time.Time {
+	start = truncateDay(start)
+	day = truncateDay(day)
+
+	months := max((day.Year()-start.Year())*12+int(day.Month())-int(start.Month()), 1)
+	for {
+		renewal := AddMonths(start, months)
+		if !renewal.Before(day) {
+			return renewal
+		}
+		months++
+	}
+}
+
+// AddMonths shifts the date by whole months, clamping the day to the end of
+// shorter months
+func AddMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,71 @@
+package billing
+
+import (
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Split returns the monthly amount every participant pays for a subscription.
+// Equal splits without explicit shares are divided among household members.
+// For percent and fixed splits the owner pays whatever the shares leave.
+func Split(price int, owner uuid.UUID, splitType string, shares []models.SubscriptionShare, householdMembers []uuid.UUID) map[uuid.UUID]float64 {
+	costs := make(map[uuid.UUID]float64)
+	total := float64(price)
+
+	switch splitType {
+	case models.SplitEqual:
+		participants := householdMembers
+		if len(shares) > 0 {
+			participants = make([]uuid.UUID, 0, len(shares))
+			for _, share := range shares {
+				participants = append(participants, share.UserID)
+			}
+		}
+		if len(participants) == 0 {
+			costs[owner] = total
+			return costs
+		}
+		for _, userID := range participants {
+			costs[userID] += total / float64(len(participants))
+		}
+
+	case models.SplitPercent, models.SplitFixed:
+		var assigned float64
+		for _, share := range shares {
+			var cost float64
+			if share.Percent != nil {
+				cost = total * *share.Percent / 100
+			}
+			if share.Amount != nil {
+				cost = float64(*share.Amount)
+			}
+			if cost > total-assigned {
+				cost = total - assigned
+			}
+			costs[share.UserID] += cost
+			assigned += cost
+		}
+		if rest := total - assigned; rest > 0 {
+			costs[owner] += rest
+		}
+
+	default:
+		costs[owner] = total
+	}
+
+	return costs
+}
+
+// ShareFraction returns the part of the subscription price paid by userID
+func ShareFraction(price int, owner uuid.UUID, splitType string, shares []models.SubscriptionShare, householdMembers []uuid.UUID, userID uuid.UUID) float64 {
+	if price == 0 {
+		if userID == owner {
+			return 1
+		}
+		return 0
+	}
+
+	costs := Split(price, owner, splitType, shares, householdMembers)
+	return costs[userID] / float64(price)
+}
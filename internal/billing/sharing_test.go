@@ -0,0 +1,103 @@
+package billing
+
+import (
+	"math"
+	"testing"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func percent(p float64) *float64 { return &p }
+
+func amount(a int) *int { return &a }
+
+func TestSplit(t *testing.T) {
+	owner, alice, bob := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		price     int
+		splitType string
+		shares    []models.SubscriptionShare
+		members   []uuid.UUID
+		want      map[uuid.UUID]float64
+	}{
+		{
+			name: "no split", price: 900, splitType: models.SplitNone,
+			want: map[uuid.UUID]float64{owner: 900},
+		},
+		{
+			name: "equal among household members", price: 900, splitType: models.SplitEqual,
+			members: []uuid.UUID{owner, alice, bob},
+			want:    map[uuid.UUID]float64{owner: 300, alice: 300, bob: 300},
+		},
+		{
+			name: "equal among explicit participants", price: 900, splitType: models.SplitEqual,
+			shares:  []models.SubscriptionShare{{UserID: alice}, {UserID: bob}},
+			members: []uuid.UUID{owner, alice, bob},
+			want:    map[uuid.UUID]float64{alice: 450, bob: 450},
+		},
+		{
+			name: "equal without participants falls back to the owner", price: 900, splitType: models.SplitEqual,
+			want: map[uuid.UUID]float64{owner: 900},
+		},
+		{
+			name: "percent shares, the owner pays the rest", price: 1000, splitType: models.SplitPercent,
+			shares: []models.SubscriptionShare{{UserID: alice, Percent: percent(25)}, {UserID: bob, Percent: percent(15)}},
+			want:   map[uuid.UUID]float64{alice: 250, bob: 150, owner: 600},
+		},
+		{
+			name: "fixed shares, the owner pays the rest", price: 1000, splitType: models.SplitFixed,
+			shares: []models.SubscriptionShare{{UserID: alice, Amount: amount(300)}},
+			want:   map[uuid.UUID]float64{alice: 300, owner: 700},
+		},
+		{
+			name: "fixed shares are capped at the price", price: 500, splitType: models.SplitFixed,
+			shares: []models.SubscriptionShare{{UserID: alice, Amount: amount(400)}, {UserID: bob, Amount: amount(400)}},
+			want:   map[uuid.UUID]float64{alice: 400, bob: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.price, owner, tt.splitType, tt.shares, tt.members)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Split() = %v, want %v", got, tt.want)
+			}
+			var total float64
+			for userID, want := range tt.want {
+				if math.Abs(got[userID]-want) > 1e-9 {
+					t.Errorf("Split()[%s] = %v, want %v", userID, got[userID], want)
+				}
+				total += got[userID]
+			}
+			if math.Abs(total-float64(tt.price)) > 1e-9 {
+				t.Errorf("Split() sums to %v, want the price %d", total, tt.price)
+			}
+		})
+	}
+}
+
+func TestShareFraction(t *testing.T) {
+	owner, alice := uuid.New(), uuid.New()
+	shares := []models.SubscriptionShare{{UserID: alice, Percent: percent(30)}}
+
+	if got := ShareFraction(1000, owner, models.SplitPercent, shares, nil, alice); math.Abs(got-0.3) > 1e-9 {
+		t.Errorf("ShareFraction(alice) = %v, want 0.3", got)
+	}
+	if got := ShareFraction(1000, owner, models.SplitPercent, shares, nil, owner); math.Abs(got-0.7) > 1e-9 {
+		t.Errorf("ShareFraction(owner) = %v, want 0.7", got)
+	}
+	if got := ShareFraction(1000, owner, models.SplitPercent, shares, nil, uuid.New()); got != 0 {
+		t.Errorf("ShareFraction(stranger) = %v, want 0", got)
+	}
+
+	// Free subscriptions belong to the owner
+	if got := ShareFraction(0, owner, models.SplitPercent, shares, nil, owner); got != 1 {
+		t.Errorf("ShareFraction(free, owner) = %v, want 1", got)
+	}
+	if got := ShareFraction(0, owner, models.SplitPercent, shares, nil, alice); got != 0 {
+		t.Errorf("ShareFraction(free, alice) = %v, want 0", got)
+	}
+}
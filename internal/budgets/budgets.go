@@ -0,0 +1,93 @@
+package budgets
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/pkg/models"
+)
+
+// DefaultThresholds are the percentages alerted when a budget sets none
+var DefaultThresholds = []int64{80, 100}
+
+// Period returns the first and the last day of the budget period
+// containing the date
+func Period(period string, date time.Time) (time.Time, time.Time) {
+	if period == models.BudgetYearly {
+		start := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+	}
+
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
+
+// Status evaluates the budget in the period containing the date. Every
+// month of the period up to the month of the date is charged the way the
+// monthly aggregation charges it, so a yearly budget is not spent by
+// renewals that are still ahead. The principal of the context must be
+// allowed to aggregate the costs the budget covers.
+func Status(ctx context.Context, service *subscriptions.Service, budget models.Budget, date time.Time) (*models.BudgetStatus, error) {
+	start, end := Period(budget.Period, date)
+	_, monthEnd := Period(models.BudgetMonthly, date)
+
+	req := models.AggregationRequest{
+		UserID:    budget.UserID,
+		ServiceID: budget.ServiceID,
+		Category:  budget.Category,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   monthEnd.Format("2006-01-02"),
+	}
+
+	aggregation, err := service.Aggregate(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluate(budget, start, end, aggregation.TotalCost), nil
+}
+
+// evaluate compares the spending with the amount and thresholds of the budget
+func evaluate(budget models.Budget, start, end time.Time, spent int64) *models.BudgetStatus {
+	status := &models.BudgetStatus{
+		BudgetID:          budget.ID,
+		PeriodStart:       start,
+		PeriodEnd:         end,
+		Amount:            budget.Amount,
+		Spent:             spent,
+		Remaining:         budget.Amount - spent,
+		Percent:           math.Round(float64(spent)*1000/float64(budget.Amount)) / 10,
+		CrossedThresholds: []int64{},
+		Exceeded:          spent > budget.Amount,
+	}
+
+	for _, threshold := range Thresholds(budget.Thresholds) {
+		if spent*100 >= threshold*budget.Amount {
+			status.CrossedThresholds = append(status.CrossedThresholds, threshold)
+		}
+	}
+
+	return status
+}
+
+// Thresholds returns the thresholds sorted without duplicates, or the
+// defaults when there are none
+func Thresholds(thresholds []int64) []int64 {
+	if len(thresholds) == 0 {
+		return DefaultThresholds
+	}
+
+	result := append([]int64{}, thresholds...)
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	unique := result[:1]
+	for _, threshold := range result[1:] {
+		if threshold != unique[len(unique)-1] {
+			unique = append(unique, threshold)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,100 @@
+package budgets
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		period    string
+		date      time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"monthly", models.BudgetMonthly, date(2025, 3, 17), date(2025, 3, 1), date(2025, 3, 31)},
+		{"monthly in February", models.BudgetMonthly, date(2024, 2, 29), date(2024, 2, 1), date(2024, 2, 29)},
+		{"monthly in December", models.BudgetMonthly, date(2025, 12, 31), date(2025, 12, 1), date(2025, 12, 31)},
+		{"yearly", models.BudgetYearly, date(2025, 7, 4), date(2025, 1, 1), date(2025, 12, 31)},
+		{"time of day ignored", models.BudgetMonthly, time.Date(2025, 3, 31, 23, 59, 0, 0, time.UTC), date(2025, 3, 1), date(2025, 3, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := Period(tt.period, tt.date)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Fatalf("Period() = %v..%v, want %v..%v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	start, end := date(2025, 3, 1), date(2025, 3, 31)
+
+	tests := []struct {
+		name       string
+		thresholds []int64
+		spent      int64
+		want       models.BudgetStatus
+	}{
+		{name: "nothing spent", spent: 0,
+			want: models.BudgetStatus{Remaining: 1000, CrossedThresholds: []int64{}}},
+		{name: "below the first threshold", spent: 799,
+			want: models.BudgetStatus{Remaining: 201, Percent: 79.9, CrossedThresholds: []int64{}}},
+		{name: "at the first threshold", spent: 800,
+			want: models.BudgetStatus{Remaining: 200, Percent: 80, CrossedThresholds: []int64{80}}},
+		{name: "spent exactly", spent: 1000,
+			want: models.BudgetStatus{Remaining: 0, Percent: 100, CrossedThresholds: []int64{80, 100}}},
+		{name: "exceeded", spent: 1234,
+			want: models.BudgetStatus{Remaining: -234, Percent: 123.4, CrossedThresholds: []int64{80, 100}, Exceeded: true}},
+		{name: "own thresholds", thresholds: []int64{150, 50, 50}, spent: 1500,
+			want: models.BudgetStatus{Remaining: -500, Percent: 150, CrossedThresholds: []int64{50, 150}, Exceeded: true}},
+		{name: "percent rounded", spent: 1,
+			want: models.BudgetStatus{Remaining: 999, Percent: 0.1, CrossedThresholds: []int64{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := models.Budget{Amount: 1000, Thresholds: tt.thresholds}
+			want := tt.want
+			want.PeriodStart, want.PeriodEnd, want.Amount, want.Spent = start, end, 1000, tt.spent
+
+			if got := evaluate(budget, start, end, tt.spent); !reflect.DeepEqual(*got, want) {
+				t.Fatalf("evaluate() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds []int64
+		want       []int64
+	}{
+		{"defaults", nil, []int64{80, 100}},
+		{"sorted", []int64{100, 50, 75}, []int64{50, 75, 100}},
+		{"duplicates dropped", []int64{90, 90, 120, 90}, []int64{90, 120}},
+		{"single", []int64{100}, []int64{100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append([]int64{}, tt.thresholds...)
+			if got := Thresholds(tt.thresholds); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Thresholds() = %v, want %v", got, tt.want)
+			}
+			if len(tt.thresholds) > 0 && !reflect.DeepEqual(tt.thresholds, before) {
+				t.Fatalf("Thresholds() changed its argument to %v", tt.thresholds)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package budgets
+
+import (
+	"context"
+	"time"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/outbox"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/tenant"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Options configures the evaluator; zero values take the defaults
+type Options struct {
+	Interval time.Duration // 15m
+}
+
+// Evaluator checks every budget of every tenant and records a
+// budget.threshold_crossed event in the outbox for each threshold crossed
+// in the current period. budget_alerts remembers the crossings, so each
+// is announced once per period even with several replicas.
+type Evaluator struct {
+	db      *database.DB
+	service *subscriptions.Service
+	opts    Options
+	logger  *logrus.Logger
+}
+
+func NewEvaluator(db *database.DB, service *subscriptions.Service, opts Options, logger *logrus.Logger) *Evaluator {
+	if opts.Interval <= 0 {
+		opts.Interval = 15 * time.Minute
+	}
+
+	return &Evaluator{
+		db:      db,
+		service: service,
+		opts:    opts,
+		logger:  logger,
+	}
+}
+
+// Run evaluates budgets until the context is done
+func (e *Evaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.RunOnce(ctx, time.Now()); err != nil {
+			e.logger.WithError(err).Error("Failed to evaluate budgets")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce evaluates the budgets in the periods containing the time
+func (e *Evaluator) RunOnce(ctx context.Context, now time.Time) error {
+	var list []records.Budget
+	if err := e.db.System().Select(&list, `SELECT * FROM budgets ORDER BY tenant_id, id`); err != nil {
+		return err
+	}
+
+	alerted := 0
+	for _, row := range list {
+		budget := row.Model()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Without a principal the service aggregates on behalf of the system
+		status, err := Status(tenant.WithID(ctx, budget.TenantID), e.service, budget, now)
+		if err != nil {
+			e.logger.WithError(err).WithField("budget_id", budget.ID).Error("Failed to evaluate budget")
+			continue
+		}
+
+		for _, threshold := range status.CrossedThresholds {
+			ok, err := e.alert(budget, *status, threshold)
+			if err != nil {
+				e.logger.WithError(err).WithField("budget_id", budget.ID).Error("Failed to record budget alert")
+				break
+			}
+			if ok {
+				alerted++
+			}
+		}
+	}
+
+	if alerted > 0 {
+		e.logger.WithField("count", alerted).Info("Budget alerts recorded")
+	}
+
+	return nil
+}
+
+// alert records the crossing of the threshold with its event, returning
+// false when the crossing was already recorded in the period
+func (e *Evaluator) alert(budget models.Budget, status models.BudgetStatus, threshold int64) (bool, error) {
+	recorded := false
+	err := e.db.Tenant(budget.TenantID).Transact(func(tx *sqlx.Tx) error {
+		result, err := tx.Exec(`
+			INSERT INTO budget_alerts (tenant_id, budget_id, period_start, threshold, spent)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING`,
+			budget.TenantID, budget.ID, status.PeriodStart, threshold, status.Spent)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil || rows == 0 {
+			return err
+		}
+
+		recorded = true
+		return outbox.WriteBudgetAlert(tx, models.BudgetAlert{
+			Threshold: threshold,
+			Budget:    budget,
+			Status:    status,
+		})
+	})
+
+	return recorded && err == nil, err
+}
@@ -11,6 +11,10 @@ type Config struct {
 	Server struct {
 		Port int    `yaml:"port"`
 		Host string `yaml:"host"`
+
+		// Request body limits in bytes; routes are keyed by path template
+		MaxBodyBytes      int64            `yaml:"max_body_bytes"`
+		RouteMaxBodyBytes map[string]int64 `yaml:"route_max_body_bytes"`
 	} `yaml:"server"`
 
 	Database struct {
@@ -20,14 +24,166 @@ type Config struct {
 		Password string `yaml:"password"`
 		DBName   string `yaml:"dbname"`
 		SSLMode  string `yaml:"sslmode"`
+
+		// Set app.tenant_id on every statement so row-level security policies apply
+		RowLevelSecurity bool `yaml:"row_level_security"`
+
+		// Role with BYPASSRLS for background jobs and lookups made before the
+		// tenant is known; required with row-level security
+		SystemUser     string `yaml:"system_user"`
+		SystemPassword string `yaml:"system_password"`
 	} `yaml:"database"`
 
+	Tenancy struct {
+		Header          string `yaml:"header"`
+		Required        bool   `yaml:"required"`
+		DefaultTenantID string `yaml:"default_tenant_id"` // Used when the tenant is not required and not sent
+	} `yaml:"tenancy"`
+
+	Auth struct {
+		Enabled bool `yaml:"enabled"`
+
+		// Plain API key ensured at startup with admin scopes for the default tenant
+		BootstrapAPIKey string `yaml:"bootstrap_api_key"`
+
+		JWT struct {
+			Issuer      string   `yaml:"issuer"`
+			Audience    string   `yaml:"audience"`
+			HMACSecret  string   `yaml:"hmac_secret"`
+			HMACSecrets []string `yaml:"hmac_secrets"` // Additional secrets for key rotation
+			JWKSFiles   []string `yaml:"jwks_files"`
+			TenantClaim string   `yaml:"tenant_claim"`
+		} `yaml:"jwt"`
+	} `yaml:"auth"`
+
+	RateLimit struct {
+		Enabled           bool `yaml:"enabled"`
+		RequestsPerMinute int  `yaml:"requests_per_minute"`
+		Burst             int  `yaml:"burst"`
+
+		// Keep buckets in Postgres so limits hold across replicas
+		Shared bool `yaml:"shared"`
+
+		// Use the first X-Forwarded-For address as the client IP
+		TrustForwardedFor bool `yaml:"trust_forwarded_for"`
+
+		// Limits of individual routes keyed by path template
+		Routes map[string]RouteRateLimit `yaml:"routes"`
+	} `yaml:"rate_limit"`
+
+	GRPC struct {
+		Enabled bool `yaml:"enabled"`
+		Port    int  `yaml:"port"` // Listens on server.host
+
+		// Let clients such as grpcurl discover the services
+		Reflection bool `yaml:"reflection"`
+	} `yaml:"grpc"`
+
+	Outbox struct {
+		// Run the relay publishing recorded events; events are recorded
+		// either way and published once a replica runs it
+		Enabled bool `yaml:"enabled"`
+
+		// Comma-separated sinks: webhook, kafka, nats, file
+		Sinks string `yaml:"sinks"`
+		File  string `yaml:"file"` // Path of the file sink
+
+		Kafka struct {
+			Brokers string `yaml:"brokers"` // Comma-separated host:port
+			Topic   string `yaml:"topic"`
+		} `yaml:"kafka"`
+
+		NATS struct {
+			URL       string `yaml:"url"`
+			Subject   string `yaml:"subject"` // Events go to <subject>.<event type>
+			JetStream bool   `yaml:"jetstream"`
+		} `yaml:"nats"`
+	} `yaml:"outbox"`
+
+	Webhooks struct {
+		// Run the dispatcher sending deliveries queued by the webhook sink
+		// of the outbox
+		Enabled bool `yaml:"enabled"`
+
+		MaxAttempts    int `yaml:"max_attempts"`    // Before a delivery goes to the dead letters
+		TimeoutSeconds int `yaml:"timeout_seconds"` // Per request
+	} `yaml:"webhooks"`
+
+	Reminders struct {
+		Enabled    bool `yaml:"enabled"`
+		WindowDays int  `yaml:"window_days"` // Remind about renewals and end dates this many days ahead
+
+		// Comma-separated notifiers: log, file, smtp, telegram, webhook
+		Notifiers string `yaml:"notifiers"`
+		File      string `yaml:"file"` // Path of the file notifier
+
+		SMTP struct {
+			Host     string `yaml:"host"`
+			Port     int    `yaml:"port"`
+			Username string `yaml:"username"`
+			Password string `yaml:"password"`
+			From     string `yaml:"from"`
+		} `yaml:"smtp"`
+
+		Telegram struct {
+			BotToken string `yaml:"bot_token"`
+			ChatID   string `yaml:"chat_id"`
+		} `yaml:"telegram"`
+
+		Webhook struct {
+			URL    string `yaml:"url"`
+			Secret string `yaml:"secret"`
+		} `yaml:"webhook"`
+	} `yaml:"reminders"`
+
+	PriceChanges struct {
+		// Switch subscriptions to scheduled prices when they take effect
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"price_changes"`
+
+	Budgets struct {
+		// Evaluate budgets and record budget.threshold_crossed events
+		Enabled         bool `yaml:"enabled"`
+		IntervalMinutes int  `yaml:"interval_minutes"`
+	} `yaml:"budgets"`
+
+	Rollups struct {
+		// Rebuild monthly spend rollups, counting open-ended subscriptions
+		// through Months months ahead
+		Enabled         bool `yaml:"enabled"`
+		IntervalMinutes int  `yaml:"interval_minutes"`
+		Months          int  `yaml:"months"`
+	} `yaml:"rollups"`
+
+	API struct {
+		// Unversioned and /v1 routes announce these dates (YYYY-MM-DD) in
+		// the Deprecation and Sunset headers
+		V1 struct {
+			DeprecatedAt string `yaml:"deprecated_at"`
+			Sunset       string `yaml:"sunset"`
+		} `yaml:"v1"`
+	} `yaml:"api"`
+
+	OpenAPI struct {
+		// Reject requests that do not match the OpenAPI document
+		ValidateRequests bool `yaml:"validate_requests"`
+
+		// Answer 500 instead of responses that do not match the document;
+		// for tests and staging
+		ValidateResponses bool `yaml:"validate_responses"`
+	} `yaml:"openapi"`
+
 	Logging struct {
 		Level  string `yaml:"level"`
 		Format string `yaml:"format"`
 	} `yaml:"logging"`
 }
 
+type RouteRateLimit struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	Burst             int `yaml:"burst"`
+}
+
 func Load() (*Config, error) {
 	config := &Config{}
 
@@ -63,7 +219,7 @@ func Load() (*Config, error) {
 // replaceEnvVars replaces ${VAR:-default} patterns with environment variables
 func replaceEnvVars(content string) string {
 	// Pattern to match ${VAR:-default} or ${VAR}
-	re := regexp.MustCompile(`\$\{([^:}]+)(?::([^}]*))?\}`)
+	re := regexp.MustCompile(`\$\{([^:}]+)(:-([^}]*))?\}`)
 	return re.ReplaceAllStringFunc(content, func(match string) string {
 		groups := re.FindStringSubmatch(match)
 		if len(groups) < 2 {
@@ -71,18 +227,16 @@ func replaceEnvVars(content string) string {
 		}
 
 		varName := groups[1]
-		defaultValue := ""
-		if len(groups) > 2 {
-			defaultValue = groups[2]
-		}
+		hasDefault := groups[2] != ""
+		defaultValue := groups[3]
 
 		// Check if environment variable exists
 		if envValue := os.Getenv(varName); envValue != "" {
 			return envValue
 		}
 
-		// Return default value if provided
-		if defaultValue != "" {
+		// Return default value if provided, even an empty one
+		if hasDefault {
 			return defaultValue
 		}
 
@@ -92,7 +246,16 @@ func replaceEnvVars(content string) string {
 }
 
 func (c *Config) GetDatabaseURL() string {
+	return c.databaseURL(c.Database.User, c.Database.Password)
+}
+
+// GetSystemDatabaseURL returns the connection string of the system role
+func (c *Config) GetSystemDatabaseURL() string {
+	return c.databaseURL(c.Database.SystemUser, c.Database.SystemPassword)
+}
+
+func (c *Config) databaseURL(user, password string) string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Database.Host, c.Database.Port, c.Database.User, c.Database.Password,
+		c.Database.Host, c.Database.Port, user, password,
 		c.Database.DBName, c.Database.SSLMode)
 }
@@ -9,8 +9,42 @@ import (
 	_ "github.com/lib/pq"
 )
 
-func New(cfg *config.Config) (*sqlx.DB, error) {
-	db, err := sqlx.Open("postgres", cfg.GetDatabaseURL())
+// DB wraps the connection pool with database-wide settings
+type DB struct {
+	*sqlx.DB
+	rowLevelSecurity bool
+
+	// Pool of the role bypassing row-level security, nil without it
+	system *sqlx.DB
+}
+
+func New(cfg *config.Config) (*DB, error) {
+	db, err := open(cfg.GetDatabaseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DB{DB: db, rowLevelSecurity: cfg.Database.RowLevelSecurity}
+
+	// Policies hide every row without app.tenant_id, so statements that
+	// are not bound to a tenant need a role they do not apply to
+	switch {
+	case cfg.Database.SystemUser != "":
+		d.system, err = open(cfg.GetSystemDatabaseURL())
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("system role: %w", err)
+		}
+	case d.rowLevelSecurity:
+		db.Close()
+		return nil, fmt.Errorf("row-level security requires database.system_user")
+	}
+
+	return d, nil
+}
+
+func open(url string) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -22,6 +56,7 @@ func New(cfg *config.Config) (*sqlx.DB, error) {
 	for i := 0; i < maxRetries; i++ {
 		if err := db.Ping(); err != nil {
 			if i == maxRetries-1 {
+				db.Close()
 				return nil, fmt.Errorf("failed to ping database after %d retries: %w", maxRetries, err)
 			}
 			time.Sleep(retryInterval)
@@ -32,3 +67,21 @@ func New(cfg *config.Config) (*sqlx.DB, error) {
 
 	return db, nil
 }
+
+// System returns the pool for background jobs and lookups made before the
+// tenant of a request is known. It connects as the system role when one is
+// configured, which row-level security policies do not apply to.
+func (d *DB) System() *sqlx.DB {
+	if d.system != nil {
+		return d.system
+	}
+	return d.DB
+}
+
+// Close closes both pools
+func (d *DB) Close() error {
+	if d.system != nil {
+		d.system.Close()
+	}
+	return d.DB.Close()
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Querier is the subset of sqlx shared by databases and transactions
+type Querier interface {
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// TenantDB is a database handle bound to a single tenant. Queries still
+// filter by tenant_id themselves; with row-level security enabled every
+// statement additionally runs in a transaction that sets app.tenant_id,
+// so the Postgres policies reject rows of other tenants.
+type TenantDB struct {
+	db       *sqlx.DB
+	tenantID uuid.UUID
+	rls      bool
+}
+
+// Tenant returns a handle scoped to the tenant
+func (d *DB) Tenant(id uuid.UUID) *TenantDB {
+	return &TenantDB{db: d.DB, tenantID: id, rls: d.rowLevelSecurity}
+}
+
+// TenantID returns the tenant the handle is bound to
+func (t *TenantDB) TenantID() uuid.UUID {
+	return t.tenantID
+}
+
+func (t *TenantDB) Get(dest interface{}, query string, args ...interface{}) error {
+	if !t.rls {
+		return t.db.Get(dest, query, args...)
+	}
+	return t.Transact(func(tx *sqlx.Tx) error {
+		return tx.Get(dest, query, args...)
+	})
+}
+
+func (t *TenantDB) Select(dest interface{}, query string, args ...interface{}) error {
+	if !t.rls {
+		return t.db.Select(dest, query, args...)
+	}
+	return t.Transact(func(tx *sqlx.Tx) error {
+		return tx.Select(dest, query, args...)
+	})
+}
+
+func (t *TenantDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if !t.rls {
+		return t.db.Exec(query, args...)
+	}
+	var result sql.Result
+	err := t.Transact(func(tx *sqlx.Tx) error {
+		var err error
+		result, err = tx.Exec(query, args...)
+		return err
+	})
+	return result, err
+}
+
+// Transact runs fn in a transaction bound to the tenant and commits it
+// when fn succeeds
+func (t *TenantDB) Transact(fn func(tx *sqlx.Tx) error) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if t.rls {
+		if _, err := tx.Exec(`SELECT set_config('app.tenant_id', $1, true)`, t.tenantID.String()); err != nil {
+			return err
+		}
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
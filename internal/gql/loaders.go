@@ -0,0 +1,90 @@
+package gql
+
+import (
+	"context"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/lib/pq"
+)
+
+// loaders batch the lookups of nested fields, so a list of subscriptions
+// with their users and services costs one query per level instead of one
+// per row. They cache for the duration of a single request.
+type loaders struct {
+	users                  *dataloader.Loader[uuid.UUID, *models.User]
+	services               *dataloader.Loader[uuid.UUID, *models.Service]
+	subscriptionsByUser    *dataloader.Loader[uuid.UUID, []models.Subscription]
+	subscriptionsByService *dataloader.Loader[uuid.UUID, []models.Subscription]
+}
+
+type loadersKey struct{}
+
+func newLoaders(service *subscriptions.Service) *loaders {
+	return &loaders{
+		users: dataloader.NewBatchedLoader(batch(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.User, error) {
+			return loadUsers(service.Tenant(ctx), ids)
+		})),
+		services: dataloader.NewBatchedLoader(batch(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Service, error) {
+			return loadServices(service.Tenant(ctx), ids)
+		})),
+		subscriptionsByUser:    dataloader.NewBatchedLoader(batch(service.ByUsers)),
+		subscriptionsByService: dataloader.NewBatchedLoader(batch(service.ByServices)),
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// batch adapts a query of many IDs to a batch function answering the keys
+// in order; keys missing from the result get the zero value
+func batch[V any](load func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]V, error)) dataloader.BatchFunc[uuid.UUID, V] {
+	return func(ctx context.Context, keys []uuid.UUID) []*dataloader.Result[V] {
+		values, err := load(ctx, keys)
+
+		results := make([]*dataloader.Result[V], len(keys))
+		for i, key := range keys {
+			if err != nil {
+				results[i] = &dataloader.Result[V]{Error: err}
+				continue
+			}
+			results[i] = &dataloader.Result[V]{Data: values[key]}
+		}
+		return results
+	}
+}
+
+func loadUsers(db *database.TenantDB, ids []uuid.UUID) (map[uuid.UUID]*models.User, error) {
+	var rows []records.User
+	query := `SELECT * FROM users WHERE tenant_id = $1 AND id = ANY($2)`
+	if err := db.Select(&rows, query, db.TenantID(), pq.Array(ids)); err != nil {
+		return nil, err
+	}
+
+	users := make(map[uuid.UUID]*models.User, len(rows))
+	for _, row := range rows {
+		model := row.Model()
+		users[row.ID] = &model
+	}
+	return users, nil
+}
+
+func loadServices(db *database.TenantDB, ids []uuid.UUID) (map[uuid.UUID]*models.Service, error) {
+	var rows []records.Service
+	query := `SELECT * FROM services WHERE tenant_id = $1 AND id = ANY($2)`
+	if err := db.Select(&rows, query, db.TenantID(), pq.Array(ids)); err != nil {
+		return nil, err
+	}
+
+	services := make(map[uuid.UUID]*models.Service, len(rows))
+	for _, row := range rows {
+		model := row.Model()
+		services[row.ID] = &model
+	}
+	return services, nil
+}
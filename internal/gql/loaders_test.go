@@ -0,0 +1,48 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBatch(t *testing.T) {
+	found, missing, other := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		values  map[uuid.UUID]string
+		err     error
+		want    []string
+		wantErr bool
+	}{
+		{"keys in order, missing ones empty", map[uuid.UUID]string{other: "other", found: "found"}, nil, []string{"found", "", "other"}, false},
+		{"error for every key", nil, errors.New("connection refused"), []string{"", "", ""}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loaded []uuid.UUID
+			load := batch(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+				loaded = ids
+				return tt.values, tt.err
+			})
+
+			keys := []uuid.UUID{found, missing, other}
+			results := load(context.Background(), keys)
+			if len(loaded) != len(keys) {
+				t.Fatalf("loaded %d IDs at once, want %d", len(loaded), len(keys))
+			}
+			if len(results) != len(keys) {
+				t.Fatalf("got %d results, want %d", len(results), len(keys))
+			}
+			for i, result := range results {
+				if result.Data != tt.want[i] || (result.Error != nil) != tt.wantErr {
+					t.Errorf("result %d = %q, %v; want %q, error %v", i, result.Data, result.Error, tt.want[i], tt.wantErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,507 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sirupsen/logrus"
+)
+
+type rootResolver struct {
+	service *subscriptions.Service
+	logger  *logrus.Logger
+}
+
+type filterInput struct {
+	UserID      *graphql.ID
+	ServiceID   *graphql.ID
+	ServiceName *string
+	Category    *string
+	Tags        *[]string
+}
+
+type periodInput struct {
+	Start string
+	End   string
+}
+
+func (r *rootResolver) Subscription(ctx context.Context, args struct{ ID graphql.ID }) (*subscriptionResolver, error) {
+	id, err := parseID(args.ID, "Invalid subscription ID")
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := r.service.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.serviceError(err, "Failed to load subscription")
+	}
+
+	return &subscriptionResolver{root: r, subscription: *subscription}, nil
+}
+
+func (r *rootResolver) Subscriptions(ctx context.Context, args struct {
+	Filter *filterInput
+	Limit  *int32
+	Offset *int32
+}) ([]*subscriptionResolver, error) {
+	filter, err := args.Filter.subscriptionFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	var limit, offset int
+	if args.Limit != nil {
+		limit = int(*args.Limit)
+	}
+	if args.Offset != nil {
+		offset = int(*args.Offset)
+	}
+
+	list, err := r.service.List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, r.serviceError(err, "Failed to list subscriptions")
+	}
+
+	return r.subscriptionResolvers(list), nil
+}
+
+func (r *rootResolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseID(args.ID, "Invalid user ID")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.service.AuthorizeUser(ctx, authz.ActionRead, &id); err != nil {
+		return nil, r.serviceError(err, "Failed to load user")
+	}
+
+	user, err := loadersFrom(ctx).users.Load(ctx, id)()
+	if err != nil {
+		return nil, r.serviceError(err, "Failed to load user")
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	return &userResolver{root: r, user: *user}, nil
+}
+
+func (r *rootResolver) Users(ctx context.Context) ([]*userResolver, error) {
+	if err := r.service.AuthorizeUser(ctx, authz.ActionList, nil); err != nil {
+		return nil, r.serviceError(err, "Failed to list users")
+	}
+
+	db := r.service.Tenant(ctx)
+
+	// Regular users only see themselves
+	query := `SELECT * FROM users WHERE tenant_id = $1`
+	args := []interface{}{db.TenantID()}
+	if scope := r.service.OwnerScope(ctx); scope != nil {
+		query += ` AND id = $2`
+		args = append(args, *scope)
+	}
+
+	var users []records.User
+	err := db.Select(&users, query+` ORDER BY created_at DESC`, args...)
+	if err != nil {
+		return nil, r.serviceError(err, "Failed to list users")
+	}
+
+	resolvers := make([]*userResolver, 0, len(users))
+	for _, user := range users {
+		resolvers = append(resolvers, &userResolver{root: r, user: user.Model()})
+	}
+	return resolvers, nil
+}
+
+func (r *rootResolver) Service(ctx context.Context, args struct{ ID graphql.ID }) (*serviceResolver, error) {
+	id, err := parseID(args.ID, "Invalid service ID")
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := loadersFrom(ctx).services.Load(ctx, id)()
+	if err != nil {
+		return nil, r.serviceError(err, "Failed to load service")
+	}
+	if service == nil {
+		return nil, nil
+	}
+
+	return &serviceResolver{root: r, service: *service}, nil
+}
+
+func (r *rootResolver) Services(ctx context.Context, args struct {
+	Name     *string
+	Category *string
+}) ([]*serviceResolver, error) {
+	db := r.service.Tenant(ctx)
+
+	query := "SELECT * FROM services"
+	conditions := []string{"tenant_id = $1"}
+	queryArgs := []interface{}{db.TenantID()}
+
+	if args.Name != nil && *args.Name != "" {
+		queryArgs = append(queryArgs, validation.NormalizeServiceName(*args.Name))
+		conditions = append(conditions, fmt.Sprintf(
+			"(lower(name) = lower($%d) OR EXISTS (SELECT 1 FROM unnest(aliases) a WHERE lower(a) = lower($%d)))",
+			len(queryArgs), len(queryArgs)))
+	}
+
+	if args.Category != nil && *args.Category != "" {
+		queryArgs = append(queryArgs, *args.Category)
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(queryArgs)))
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += " ORDER BY name"
+
+	var services []records.Service
+	if err := db.Select(&services, query, queryArgs...); err != nil {
+		return nil, r.serviceError(err, "Failed to list services")
+	}
+
+	resolvers := make([]*serviceResolver, 0, len(services))
+	for _, service := range services {
+		resolvers = append(resolvers, &serviceResolver{root: r, service: service.Model()})
+	}
+	return resolvers, nil
+}
+
+func (r *rootResolver) Aggregate(ctx context.Context, args struct {
+	Period  periodInput
+	Filter  *filterInput
+	GroupBy *string
+	Prorate *bool
+	Monthly *bool
+}) (*aggregateResolver, error) {
+	filter, err := args.Filter.subscriptionFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	req := models.AggregationRequest{
+		UserID:      filter.UserID,
+		ServiceID:   filter.ServiceID,
+		ServiceName: filter.ServiceName,
+		Category:    filter.Category,
+		Tags:        filter.Tags,
+		StartDate:   args.Period.Start,
+		EndDate:     args.Period.End,
+		Prorate:     args.Prorate != nil && *args.Prorate,
+	}
+	if args.GroupBy != nil {
+		req.GroupBy = strings.ToLower(*args.GroupBy)
+	}
+
+	result, err := r.service.Aggregate(ctx, req, args.Monthly != nil && *args.Monthly)
+	if err != nil {
+		return nil, r.serviceError(err, "Failed to aggregate subscriptions")
+	}
+
+	return &aggregateResolver{result: *result}, nil
+}
+
+func (r *rootResolver) subscriptionResolvers(list []models.Subscription) []*subscriptionResolver {
+	resolvers := make([]*subscriptionResolver, 0, len(list))
+	for _, subscription := range list {
+		resolvers = append(resolvers, &subscriptionResolver{root: r, subscription: subscription})
+	}
+	return resolvers
+}
+
+// serviceError turns errors of the subscription service into messages fit
+// for clients, like the status codes of the HTTP and gRPC APIs
+func (r *rootResolver) serviceError(err error, message string) error {
+	var denied *authz.DeniedError
+	var validationErrs validation.ValidationErrors
+	var inputErr *subscriptions.InputError
+
+	switch {
+	case errors.As(err, &denied):
+		return errors.New("Forbidden")
+	case errors.As(err, &validationErrs):
+		messages := make([]string, 0, len(validationErrs))
+		for _, validationErr := range validationErrs {
+			messages = append(messages, validationErr.Message)
+		}
+		return errors.New(strings.Join(messages, "; "))
+	case errors.As(err, &inputErr):
+		return errors.New(inputErr.Message)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	}
+
+	r.logger.WithError(err).Error(message)
+	return errors.New(message)
+}
+
+func (f *filterInput) subscriptionFilter() (models.SubscriptionFilter, error) {
+	var filter models.SubscriptionFilter
+	if f == nil {
+		return filter, nil
+	}
+
+	filter.ServiceName = nonEmpty(f.ServiceName)
+	filter.Category = nonEmpty(f.Category)
+	if f.Tags != nil {
+		filter.Tags = *f.Tags
+	}
+
+	var err error
+	if filter.UserID, err = optionalID(f.UserID, "Invalid user ID format"); err != nil {
+		return filter, err
+	}
+	if filter.ServiceID, err = optionalID(f.ServiceID, "Invalid service ID format"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+type subscriptionResolver struct {
+	root         *rootResolver
+	subscription models.Subscription
+}
+
+func (r *subscriptionResolver) ID() graphql.ID {
+	return graphql.ID(r.subscription.ID.String())
+}
+
+func (r *subscriptionResolver) ServiceName() string {
+	return r.subscription.ServiceName
+}
+
+func (r *subscriptionResolver) Service(ctx context.Context) (*serviceResolver, error) {
+	service, err := loadersFrom(ctx).services.Load(ctx, r.subscription.ServiceID)()
+	if err != nil {
+		return nil, r.root.serviceError(err, "Failed to load service")
+	}
+	if service == nil {
+		return nil, nil
+	}
+	return &serviceResolver{root: r.root, service: *service}, nil
+}
+
+func (r *subscriptionResolver) Price() int32 {
+	return int32(r.subscription.Price)
+}
+
+func (r *subscriptionResolver) UserID() graphql.ID {
+	return graphql.ID(r.subscription.UserID.String())
+}
+
+func (r *subscriptionResolver) User(ctx context.Context) (*userResolver, error) {
+	// Subscriptions shared with a regular user may belong to another one
+	if err := r.root.service.AuthorizeUser(ctx, authz.ActionRead, &r.subscription.UserID); err != nil {
+		return nil, r.root.serviceError(err, "Failed to load user")
+	}
+
+	user, err := loadersFrom(ctx).users.Load(ctx, r.subscription.UserID)()
+	if err != nil {
+		return nil, r.root.serviceError(err, "Failed to load user")
+	}
+	if user == nil {
+		return nil, nil
+	}
+	return &userResolver{root: r.root, user: *user}, nil
+}
+
+func (r *subscriptionResolver) StartDate() string {
+	return r.subscription.StartDate.Format(time.RFC3339)
+}
+
+func (r *subscriptionResolver) EndDate() *string {
+	if r.subscription.EndDate == nil {
+		return nil
+	}
+	endDate := r.subscription.EndDate.Format(time.RFC3339)
+	return &endDate
+}
+
+func (r *subscriptionResolver) Category() *string {
+	return r.subscription.Category
+}
+
+func (r *subscriptionResolver) Tags() []string {
+	if r.subscription.Tags == nil {
+		return []string{}
+	}
+	return r.subscription.Tags
+}
+
+func (r *subscriptionResolver) SplitType() string {
+	return r.subscription.SplitType
+}
+
+func (r *subscriptionResolver) CreatedAt() string {
+	return r.subscription.CreatedAt.Format(time.RFC3339)
+}
+
+func (r *subscriptionResolver) UpdatedAt() string {
+	return r.subscription.UpdatedAt.Format(time.RFC3339)
+}
+
+type userResolver struct {
+	root *rootResolver
+	user models.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(r.user.ID.String())
+}
+
+func (r *userResolver) Name() *string {
+	return r.user.Name
+}
+
+func (r *userResolver) Email() *string {
+	return r.user.Email
+}
+
+func (r *userResolver) CreatedAt() string {
+	return r.user.CreatedAt.Format(time.RFC3339)
+}
+
+func (r *userResolver) Subscriptions(ctx context.Context) ([]*subscriptionResolver, error) {
+	list, err := loadersFrom(ctx).subscriptionsByUser.Load(ctx, r.user.ID)()
+	if err != nil {
+		return nil, r.root.serviceError(err, "Failed to list subscriptions")
+	}
+	return r.root.subscriptionResolvers(list), nil
+}
+
+type serviceResolver struct {
+	root    *rootResolver
+	service models.Service
+}
+
+func (r *serviceResolver) ID() graphql.ID {
+	return graphql.ID(r.service.ID.String())
+}
+
+func (r *serviceResolver) Name() string {
+	return r.service.Name
+}
+
+func (r *serviceResolver) Aliases() []string {
+	if r.service.Aliases == nil {
+		return []string{}
+	}
+	return r.service.Aliases
+}
+
+func (r *serviceResolver) Category() *string {
+	return r.service.Category
+}
+
+func (r *serviceResolver) DefaultPrice() *int32 {
+	if r.service.DefaultPrice == nil {
+		return nil
+	}
+	price := int32(*r.service.DefaultPrice)
+	return &price
+}
+
+func (r *serviceResolver) Website() *string {
+	return r.service.Website
+}
+
+func (r *serviceResolver) Subscriptions(ctx context.Context) ([]*subscriptionResolver, error) {
+	list, err := loadersFrom(ctx).subscriptionsByService.Load(ctx, r.service.ID)()
+	if err != nil {
+		return nil, r.root.serviceError(err, "Failed to list subscriptions")
+	}
+	return r.root.subscriptionResolvers(list), nil
+}
+
+type aggregateResolver struct {
+	result models.AggregationResponse
+}
+
+func (r *aggregateResolver) TotalCost() float64 {
+	return float64(r.result.TotalCost)
+}
+
+func (r *aggregateResolver) Period() string {
+	return r.result.Period
+}
+
+func (r *aggregateResolver) UserID() *graphql.ID {
+	if r.result.UserID == nil {
+		return nil
+	}
+	id := graphql.ID(r.result.UserID.String())
+	return &id
+}
+
+func (r *aggregateResolver) Prorated() bool {
+	return r.result.Prorated
+}
+
+func (r *aggregateResolver) GroupBy() *string {
+	if r.result.GroupBy == "" {
+		return nil
+	}
+	groupBy := strings.ToUpper(r.result.GroupBy)
+	return &groupBy
+}
+
+func (r *aggregateResolver) Groups() []*aggregationGroupResolver {
+	groups := make([]*aggregationGroupResolver, 0, len(r.result.Groups))
+	for _, group := range r.result.Groups {
+		groups = append(groups, &aggregationGroupResolver{group: group})
+	}
+	return groups
+}
+
+type aggregationGroupResolver struct {
+	group models.AggregationGroup
+}
+
+func (r *aggregationGroupResolver) Key() string {
+	return r.group.Key
+}
+
+func (r *aggregationGroupResolver) TotalCost() float64 {
+	return float64(r.group.TotalCost)
+}
+
+func parseID(id graphql.ID, message string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(string(id))
+	if err != nil {
+		return uuid.Nil, errors.New(message)
+	}
+	return parsed, nil
+}
+
+func optionalID(id *graphql.ID, message string) (*uuid.UUID, error) {
+	if id == nil || *id == "" {
+		return nil, nil
+	}
+	parsed, err := parseID(*id, message)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func nonEmpty(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
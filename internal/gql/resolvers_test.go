@@ -0,0 +1,82 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+)
+
+func TestServiceError(t *testing.T) {
+	r := &rootResolver{logger: testLogger()}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"denied", &authz.DeniedError{Reason: "not the owner"}, "Forbidden"},
+		{"validation", validation.ValidationErrors{{Field: "price", Message: "price is required"}, {Field: "end", Message: "end is invalid"}},
+			"price is required; end is invalid"},
+		{"input", &subscriptions.InputError{Message: "Invalid user ID"}, "Invalid user ID"},
+		{"canceled", context.Canceled, context.Canceled.Error()},
+		{"internal", errors.New("connection refused"), "Failed to load subscriptions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.serviceError(tt.err, "Failed to load subscriptions").Error(); got != tt.want {
+				t.Fatalf("serviceError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionFilter(t *testing.T) {
+	userID := uuid.New()
+	str := func(s string) *string { return &s }
+	id := func(s string) *graphql.ID { v := graphql.ID(s); return &v }
+	tags := []string{"family"}
+
+	tests := []struct {
+		name    string
+		input   *filterInput
+		want    models.SubscriptionFilter
+		wantErr string
+	}{
+		{name: "no filter"},
+		{name: "empty values are unset", input: &filterInput{UserID: id(""), ServiceName: str(""), Category: str("")}},
+		{
+			name:  "filters",
+			input: &filterInput{UserID: id(userID.String()), ServiceName: str("Netflix"), Category: str("video"), Tags: &tags},
+			want:  models.SubscriptionFilter{UserID: &userID, ServiceName: str("Netflix"), Category: str("video"), Tags: tags},
+		},
+		{name: "invalid user ID", input: &filterInput{UserID: id("nope")}, wantErr: "Invalid user ID format"},
+		{name: "invalid service ID", input: &filterInput{ServiceID: id("nope")}, wantErr: "Invalid service ID format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.input.subscriptionFilter()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("subscriptionFilter() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("subscriptionFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("subscriptionFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
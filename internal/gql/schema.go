@@ -0,0 +1,44 @@
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// maxDepth bounds the nesting of queries, since every level of
+// user { subscriptions { user ... } } costs another batch of queries
+const maxDepth = 8
+
+// Schema executes GraphQL queries against the subscription service. Nested
+// users, services and subscriptions are loaded in batches per request.
+type Schema struct {
+	schema  *graphql.Schema
+	service *subscriptions.Service
+}
+
+func NewSchema(service *subscriptions.Service, logger *logrus.Logger) *Schema {
+	root := &rootResolver{service: service, logger: logger}
+
+	return &Schema{
+		schema: graphql.MustParseSchema(schemaSDL, root,
+			graphql.UseStringDescriptions(),
+			graphql.MaxDepth(maxDepth),
+		),
+		service: service,
+	}
+}
+
+// Exec runs the query of the request with the tenant and principal of the
+// context. Errors of the query end up in the response.
+func (s *Schema) Exec(ctx context.Context, req models.GraphQLRequest) *graphql.Response {
+	ctx = context.WithValue(ctx, loadersKey{}, newLoaders(s.service))
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
@@ -0,0 +1,51 @@
+package gql
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// TestSchemaExec covers queries rejected before any resolver reaches the
+// database; NewSchema itself checks the resolvers against the schema
+func TestSchemaExec(t *testing.T) {
+	schema := NewSchema(nil, testLogger())
+
+	tests := []struct {
+		name      string
+		query     string
+		wantError string
+	}{
+		{"typename", `{ __typename }`, ""},
+		{"unknown field", `{ subscriptions { price cost } }`, `Cannot query field "cost"`},
+		{"invalid ID", `{ subscription(id: "nope") { id } }`, "Invalid subscription ID"},
+		{"invalid user ID", `{ user(id: "nope") { id } }`, "Invalid user ID"},
+		{"unknown group", `{ aggregate(period: {start: "01-2025", end: "12-2025"}, groupBy: owner) { totalCost } }`, "owner"},
+		{"too deep", `{ users { subscriptions { user { subscriptions { user { subscriptions { user { subscriptions { id } } } } } } } } }`, "exceeds max depth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := schema.Exec(context.Background(), models.GraphQLRequest{Query: tt.query})
+			if tt.wantError == "" {
+				if len(resp.Errors) > 0 {
+					t.Fatalf("Exec() errors = %v, want none", resp.Errors)
+				}
+				return
+			}
+			if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.wantError) {
+				t.Fatalf("Exec() errors = %v, want %q", resp.Errors, tt.wantError)
+			}
+		})
+	}
+}
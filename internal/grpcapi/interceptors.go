@@ -0,0 +1,207 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/tenant"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys; gRPC lowercases them
+const (
+	apiKeyMetadata        = "x-api-key"
+	authorizationMetadata = "authorization"
+	tenantMetadata        = "x-tenant-id"
+	forwardedForMetadata  = "x-forwarded-for"
+)
+
+// methodRoutes maps methods to the HTTP routes they mirror, so both APIs
+// share rate limits
+var methodRoutes = map[string]string{
+	"/subscription.v1.SubscriptionService/GetSubscription":        "/subscriptions/{id}",
+	"/subscription.v1.SubscriptionService/ListSubscriptions":      "/subscriptions",
+	"/subscription.v1.SubscriptionService/StreamSubscriptions":    "/subscriptions",
+	"/subscription.v1.SubscriptionService/AggregateSubscriptions": "/subscriptions/aggregate",
+}
+
+// guard authenticates calls, applies rate limits, resolves the tenant and
+// checks scopes, like the middlewares of the HTTP API. Health and
+// reflection calls pass through.
+type guard struct {
+	opts   Options
+	logger *logrus.Logger
+}
+
+func (g *guard) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := g.check(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *guard) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := g.check(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func (g *guard) check(ctx context.Context, method string) (context.Context, error) {
+	route, ok := methodRoutes[method]
+	if !ok {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var principal *auth.Principal
+	if g.opts.Authenticator != nil {
+		var err error
+		principal, err = g.opts.Authenticator.AuthenticateCredentials(first(md, apiKeyMetadata), first(md, authorizationMetadata))
+		if err != nil {
+			if errors.Is(err, auth.ErrNoCredentials) {
+				return nil, status.Error(codes.Unauthenticated, "Authentication required")
+			}
+			g.logger.WithError(err).Warn("Authentication failed")
+			return nil, status.Error(codes.Unauthenticated, "Invalid credentials")
+		}
+		ctx = auth.WithPrincipal(ctx, principal)
+	}
+
+	if err := g.rateLimit(ctx, md, principal, route); err != nil {
+		return nil, err
+	}
+
+	credentialTenant := uuid.Nil
+	if principal != nil {
+		credentialTenant = principal.TenantID
+	}
+	tenantID, err := tenant.Resolve(principal != nil, credentialTenant, first(md, tenantMetadata), g.opts.TenantRequired, g.opts.DefaultTenant)
+	switch {
+	case errors.Is(err, tenant.ErrForeignTenant):
+		return nil, status.Error(codes.PermissionDenied, "Credentials do not belong to this tenant")
+	case errors.Is(err, tenant.ErrUnboundCredentials):
+		return nil, status.Error(codes.PermissionDenied, "Credentials are not bound to a tenant")
+	case errors.Is(err, tenant.ErrInvalidID):
+		return nil, status.Error(codes.InvalidArgument, "Invalid tenant ID")
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, "Tenant ID is required")
+	}
+	ctx = tenant.WithID(ctx, tenantID)
+
+	// Every method only reads data
+	if principal != nil && !principal.HasScope(auth.ScopeRead) {
+		return nil, status.Error(codes.PermissionDenied, "Insufficient scope: "+auth.ScopeRead+" required")
+	}
+
+	return ctx, nil
+}
+
+// rateLimit takes a token from the bucket the caller has for the route.
+// Limiter failures are logged and let the call through.
+func (g *guard) rateLimit(ctx context.Context, md metadata.MD, principal *auth.Principal, route string) error {
+	if g.opts.Limiter == nil {
+		return nil
+	}
+
+	limit, ok := g.opts.RouteLimits[route]
+	if !ok {
+		limit = g.opts.DefaultLimit
+	}
+
+	res, err := g.opts.Limiter.Allow(g.clientKey(ctx, md, principal)+"|"+route, limit)
+	if err != nil {
+		g.logger.WithError(err).Error("Rate limiter failed")
+		return nil
+	}
+
+	if !res.Allowed {
+		retryAfter := strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds())))
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+		return status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+	}
+
+	return nil
+}
+
+// clientKey identifies the caller the same way as the HTTP API
+func (g *guard) clientKey(ctx context.Context, md metadata.MD, principal *auth.Principal) string {
+	if principal != nil {
+		return "principal:" + principal.TenantID.String() + ":" + principal.Subject
+	}
+
+	if g.opts.TrustForwardedFor {
+		if forwarded := first(md, forwardedForMetadata); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			return "ip:" + strings.TrimSpace(ip)
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+func loggingUnary(logger *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(logger, info.FullMethod, err, start)
+		return resp, err
+	}
+}
+
+func loggingStream(logger *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(logger, info.FullMethod, err, start)
+		return err
+	}
+}
+
+func logCall(logger *logrus.Logger, method string, err error, start time.Time) {
+	logger.WithFields(logrus.Fields{
+		"method":   method,
+		"code":     status.Code(err).String(),
+		"duration": time.Since(start).String(),
+	}).Info("gRPC call")
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
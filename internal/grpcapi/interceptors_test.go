@@ -0,0 +1,209 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/ratelimit"
+	"subscription-aggregator/internal/tenant"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testSecret       = "secret"
+	getSubscription  = "/subscription.v1.SubscriptionService/GetSubscription"
+	aggregateMethod  = "/subscription.v1.SubscriptionService/AggregateSubscriptions"
+	healthCheckRoute = "/grpc.health.v1.Health/Check"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("database is down")
+}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// testToken signs an HS256 token with the test secret
+func testToken(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func testAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{HMACSecrets: []string{testSecret}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewAuthenticator(nil, verifier)
+}
+
+func incoming(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestGuardCheck(t *testing.T) {
+	bound := uuid.New()
+	other := uuid.New()
+	fallback := uuid.New()
+
+	reader := testToken(t, map[string]interface{}{"sub": "reader", "scope": "read", "tenant_id": bound.String()})
+	writer := testToken(t, map[string]interface{}{"sub": "writer", "scope": "write", "tenant_id": bound.String()})
+	unbound := testToken(t, map[string]interface{}{"sub": "unbound", "scope": "read"})
+
+	tests := []struct {
+		name          string
+		authenticate  bool
+		required      bool
+		method        string
+		ctx           context.Context
+		wantCode      codes.Code
+		wantTenant    uuid.UUID
+		wantPrincipal string
+	}{
+		{name: "health passes through", authenticate: true, method: healthCheckRoute, ctx: incoming(), wantCode: codes.OK},
+		{name: "anonymous with tenant", method: getSubscription, ctx: incoming(tenantMetadata, other.String()), wantCode: codes.OK, wantTenant: other},
+		{name: "anonymous without tenant", method: getSubscription, ctx: incoming(), wantCode: codes.OK, wantTenant: fallback},
+		{name: "anonymous without required tenant", required: true, method: getSubscription, ctx: incoming(), wantCode: codes.InvalidArgument},
+		{name: "anonymous with invalid tenant", method: getSubscription, ctx: incoming(tenantMetadata, "nope"), wantCode: codes.InvalidArgument},
+		{name: "no credentials", authenticate: true, method: getSubscription, ctx: incoming(), wantCode: codes.Unauthenticated},
+		{name: "invalid token", authenticate: true, method: getSubscription, ctx: incoming(authorizationMetadata, "Bearer a.b.c"), wantCode: codes.Unauthenticated},
+		{name: "token", authenticate: true, method: getSubscription, ctx: incoming(authorizationMetadata, "Bearer "+reader),
+			wantCode: codes.OK, wantTenant: bound, wantPrincipal: "reader"},
+		{name: "token and its tenant", authenticate: true, method: aggregateMethod,
+			ctx: incoming(authorizationMetadata, "Bearer "+reader, tenantMetadata, bound.String()), wantCode: codes.OK, wantTenant: bound, wantPrincipal: "reader"},
+		{name: "token and foreign tenant", authenticate: true, method: getSubscription,
+			ctx: incoming(authorizationMetadata, "Bearer "+reader, tenantMetadata, other.String()), wantCode: codes.PermissionDenied},
+		{name: "unbound token and required tenant", authenticate: true, required: true, method: getSubscription,
+			ctx: incoming(authorizationMetadata, "Bearer "+unbound, tenantMetadata, other.String()), wantCode: codes.PermissionDenied},
+		{name: "token without read scope", authenticate: true, method: getSubscription, ctx: incoming(authorizationMetadata, "Bearer "+writer), wantCode: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{TenantRequired: tt.required, DefaultTenant: fallback}
+			if tt.authenticate {
+				opts.Authenticator = testAuthenticator(t)
+			}
+			g := &guard{opts: opts, logger: testLogger()}
+
+			ctx, err := g.check(tt.ctx, tt.method)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("check() code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if err != nil {
+				return
+			}
+
+			tenantID, _ := tenant.FromContext(ctx)
+			if tenantID != tt.wantTenant {
+				t.Errorf("tenant = %v, want %v", tenantID, tt.wantTenant)
+			}
+			subject := ""
+			if principal, ok := auth.FromContext(ctx); ok {
+				subject = principal.Subject
+			}
+			if subject != tt.wantPrincipal {
+				t.Errorf("principal = %q, want %q", subject, tt.wantPrincipal)
+			}
+		})
+	}
+}
+
+func TestGuardRateLimit(t *testing.T) {
+	g := &guard{
+		opts: Options{
+			Limiter:      ratelimit.NewMemoryLimiter(),
+			DefaultLimit: ratelimit.Limit{RequestsPerMinute: 60, Burst: 1},
+			RouteLimits: map[string]ratelimit.Limit{
+				"/subscriptions/aggregate": {RequestsPerMinute: 60, Burst: 2},
+			},
+			TrustForwardedFor: true,
+			DefaultTenant:     uuid.New(),
+		},
+		logger: testLogger(),
+	}
+
+	call := func(method string, pairs ...string) codes.Code {
+		ctx := peer.NewContext(incoming(pairs...), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+		_, err := g.check(ctx, method)
+		return status.Code(err)
+	}
+
+	if code := call(getSubscription); code != codes.OK {
+		t.Fatalf("first call = %v, want OK", code)
+	}
+	if code := call(getSubscription); code != codes.ResourceExhausted {
+		t.Fatalf("second call = %v, want ResourceExhausted", code)
+	}
+
+	// Other clients and routes have their own buckets and limits
+	if code := call(getSubscription, forwardedForMetadata, "10.0.0.2, 10.0.0.1"); code != codes.OK {
+		t.Errorf("call of another client = %v, want OK", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := call(aggregateMethod); code != codes.OK {
+			t.Errorf("call %d of another route = %v, want OK", i+1, code)
+		}
+	}
+
+	g.opts.Limiter = failingLimiter{}
+	if code := call(getSubscription); code != codes.OK {
+		t.Errorf("call with a failing limiter = %v, want OK", code)
+	}
+}
+
+func TestGuardClientKey(t *testing.T) {
+	tenantID := uuid.New()
+	addr := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}}
+
+	tests := []struct {
+		name      string
+		trust     bool
+		md        metadata.MD
+		principal *auth.Principal
+		want      string
+	}{
+		{name: "principal", md: metadata.MD{}, principal: &auth.Principal{Subject: "user", TenantID: tenantID}, want: "principal:" + tenantID.String() + ":user"},
+		{name: "peer", md: metadata.MD{}, want: "ip:10.0.0.1"},
+		{name: "forwarded for ignored", md: metadata.Pairs(forwardedForMetadata, "10.0.0.2"), want: "ip:10.0.0.1"},
+		{name: "forwarded for trusted", trust: true, md: metadata.Pairs(forwardedForMetadata, " 10.0.0.2 , 10.0.0.3"), want: "ip:10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &guard{opts: Options{TrustForwardedFor: tt.trust}, logger: testLogger()}
+			if got := g.clientKey(peer.NewContext(context.Background(), addr), tt.md, tt.principal); got != tt.want {
+				t.Fatalf("clientKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
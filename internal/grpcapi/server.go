@@ -0,0 +1,55 @@
+package grpcapi
+
+import (
+	subscriptionv1 "subscription-aggregator/api/subscription/v1"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/ratelimit"
+	"subscription-aggregator/internal/subscriptions"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Options configures the gRPC server
+type Options struct {
+	Authenticator *auth.Authenticator // nil disables authentication
+
+	TenantRequired bool
+	DefaultTenant  uuid.UUID
+
+	// Calls share the buckets of the equivalent HTTP routes; a nil limiter
+	// disables rate limiting
+	Limiter           ratelimit.Limiter
+	DefaultLimit      ratelimit.Limit
+	RouteLimits       map[string]ratelimit.Limit // Keyed by HTTP path template
+	TrustForwardedFor bool
+
+	Reflection bool
+}
+
+// NewServer returns a gRPC server with the subscription service, the
+// standard health service and, when enabled, server reflection
+func NewServer(service *subscriptions.Service, opts Options, logger *logrus.Logger) *grpc.Server {
+	guard := &guard{opts: opts, logger: logger}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(loggingUnary(logger), guard.unary),
+		grpc.ChainStreamInterceptor(loggingStream(logger), guard.stream),
+	)
+
+	subscriptionv1.RegisterSubscriptionServiceServer(server, &subscriptionServer{service: service, logger: logger})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(subscriptionv1.SubscriptionService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if opts.Reflection {
+		reflection.Register(server)
+	}
+
+	return server
+}
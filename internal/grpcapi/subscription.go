@@ -0,0 +1,214 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	subscriptionv1 "subscription-aggregator/api/subscription/v1"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// subscriptionServer implements SubscriptionService on top of the service
+// layer shared with the HTTP handlers
+type subscriptionServer struct {
+	subscriptionv1.UnimplementedSubscriptionServiceServer
+
+	service *subscriptions.Service
+	logger  *logrus.Logger
+}
+
+func (s *subscriptionServer) GetSubscription(ctx context.Context, req *subscriptionv1.GetSubscriptionRequest) (*subscriptionv1.Subscription, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid subscription ID")
+	}
+
+	subscription, err := s.service.Get(ctx, id)
+	if err != nil {
+		return nil, s.serviceError(err, "Failed to load subscription")
+	}
+
+	return toSubscription(*subscription), nil
+}
+
+func (s *subscriptionServer) ListSubscriptions(ctx context.Context, req *subscriptionv1.ListSubscriptionsRequest) (*subscriptionv1.ListSubscriptionsResponse, error) {
+	filter, err := listFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.service.List(ctx, filter, int(req.GetLimit()), int(req.GetOffset()))
+	if err != nil {
+		return nil, s.serviceError(err, "Failed to list subscriptions")
+	}
+
+	resp := &subscriptionv1.ListSubscriptionsResponse{
+		Subscriptions: make([]*subscriptionv1.Subscription, 0, len(list)),
+	}
+	for _, subscription := range list {
+		resp.Subscriptions = append(resp.Subscriptions, toSubscription(subscription))
+	}
+
+	return resp, nil
+}
+
+func (s *subscriptionServer) StreamSubscriptions(req *subscriptionv1.ListSubscriptionsRequest, stream subscriptionv1.SubscriptionService_StreamSubscriptionsServer) error {
+	filter, err := listFilter(req)
+	if err != nil {
+		return err
+	}
+
+	err = s.service.Stream(stream.Context(), filter, func(subscription models.Subscription) error {
+		return stream.Send(toSubscription(subscription))
+	})
+	if err != nil {
+		return s.serviceError(err, "Failed to stream subscriptions")
+	}
+
+	return nil
+}
+
+func (s *subscriptionServer) AggregateSubscriptions(ctx context.Context, req *subscriptionv1.AggregateSubscriptionsRequest) (*subscriptionv1.AggregateSubscriptionsResponse, error) {
+	aggregation := models.AggregationRequest{
+		ServiceName: nonEmpty(req.ServiceName),
+		Category:    nonEmpty(req.Category),
+		Tags:        req.GetTags(),
+		StartDate:   req.GetStartDate(),
+		EndDate:     req.GetEndDate(),
+		Prorate:     req.GetProrate(),
+		GroupBy:     req.GetGroupBy(),
+	}
+
+	var err error
+	if aggregation.UserID, err = optionalUUID(req.UserId, "Invalid user ID format"); err != nil {
+		return nil, err
+	}
+	if aggregation.ServiceID, err = optionalUUID(req.ServiceId, "Invalid service ID format"); err != nil {
+		return nil, err
+	}
+
+	result, err := s.service.Aggregate(ctx, aggregation, req.GetMonthly())
+	if err != nil {
+		return nil, s.serviceError(err, "Failed to aggregate subscriptions")
+	}
+
+	resp := &subscriptionv1.AggregateSubscriptionsResponse{
+		TotalCost: result.TotalCost,
+		Period:    result.Period,
+		Prorated:  result.Prorated,
+		GroupBy:   result.GroupBy,
+	}
+	if result.UserID != nil {
+		userID := result.UserID.String()
+		resp.UserId = &userID
+	}
+	for _, group := range result.Groups {
+		resp.Groups = append(resp.Groups, &subscriptionv1.AggregationGroup{Key: group.Key, TotalCost: group.TotalCost})
+	}
+
+	return resp, nil
+}
+
+// serviceError maps errors of the subscription service to gRPC statuses
+func (s *subscriptionServer) serviceError(err error, message string) error {
+	var denied *authz.DeniedError
+	var validationErrs validation.ValidationErrors
+	var inputErr *subscriptions.InputError
+
+	switch {
+	case errors.As(err, &denied):
+		return status.Error(codes.PermissionDenied, "Forbidden")
+	case errors.As(err, &validationErrs):
+		messages := make([]string, 0, len(validationErrs))
+		for _, validationErr := range validationErrs {
+			messages = append(messages, validationErr.Message)
+		}
+		return status.Error(codes.InvalidArgument, strings.Join(messages, "; "))
+	case errors.As(err, &inputErr):
+		return status.Error(codes.InvalidArgument, inputErr.Message)
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "Subscription not found")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+
+	// Errors of stream.Send already carry a status
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	s.logger.WithError(err).Error(message)
+	return status.Error(codes.Internal, message)
+}
+
+func listFilter(req *subscriptionv1.ListSubscriptionsRequest) (models.SubscriptionFilter, error) {
+	filter := models.SubscriptionFilter{
+		ServiceName: nonEmpty(req.ServiceName),
+		Category:    nonEmpty(req.Category),
+		Tags:        req.GetTags(),
+	}
+
+	var err error
+	if filter.UserID, err = optionalUUID(req.UserId, "Invalid user ID format"); err != nil {
+		return filter, err
+	}
+	if filter.ServiceID, err = optionalUUID(req.ServiceId, "Invalid service ID format"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func toSubscription(subscription models.Subscription) *subscriptionv1.Subscription {
+	msg := &subscriptionv1.Subscription{
+		Id:          subscription.ID.String(),
+		ServiceName: subscription.ServiceName,
+		ServiceId:   subscription.ServiceID.String(),
+		Price:       int32(subscription.Price),
+		UserId:      subscription.UserID.String(),
+		StartDate:   timestamppb.New(subscription.StartDate),
+		Category:    subscription.Category,
+		Tags:        subscription.Tags,
+		SplitType:   subscription.SplitType,
+		CreatedAt:   timestamppb.New(subscription.CreatedAt),
+		UpdatedAt:   timestamppb.New(subscription.UpdatedAt),
+	}
+	if subscription.EndDate != nil {
+		msg.EndDate = timestamppb.New(*subscription.EndDate)
+	}
+	if subscription.HouseholdID != nil {
+		householdID := subscription.HouseholdID.String()
+		msg.HouseholdId = &householdID
+	}
+	return msg
+}
+
+// optionalUUID parses an optional ID, answering InvalidArgument with the
+// message when it is malformed
+func optionalUUID(value *string, message string) (*uuid.UUID, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, message)
+	}
+	return &id, nil
+}
+
+func nonEmpty(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+	subscriptionv1 "subscription-aggregator/api/subscription/v1"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServiceError(t *testing.T) {
+	s := &subscriptionServer{logger: testLogger()}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{"denied", &authz.DeniedError{Reason: "not the owner"}, codes.PermissionDenied, "Forbidden"},
+		{"validation", validation.ValidationErrors{{Field: "price", Message: "price is required"}, {Field: "start_date", Message: "start_date is invalid"}},
+			codes.InvalidArgument, "price is required; start_date is invalid"},
+		{"input", &subscriptions.InputError{Message: "Invalid user ID"}, codes.InvalidArgument, "Invalid user ID"},
+		{"not found", fmt.Errorf("get: %w", sql.ErrNoRows), codes.NotFound, "Subscription not found"},
+		{"canceled", context.Canceled, codes.Canceled, context.Canceled.Error()},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded, context.DeadlineExceeded.Error()},
+		{"status", status.Error(codes.Unavailable, "stream closed"), codes.Unavailable, "stream closed"},
+		{"internal", errors.New("connection refused"), codes.Internal, "Failed to get subscription"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(s.serviceError(tt.err, "Failed to get subscription"))
+			if st.Code() != tt.wantCode || st.Message() != tt.wantMessage {
+				t.Fatalf("serviceError() = %v %q, want %v %q", st.Code(), st.Message(), tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	userID := uuid.New()
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		req      *subscriptionv1.ListSubscriptionsRequest
+		want     models.SubscriptionFilter
+		wantCode codes.Code
+	}{
+		{name: "empty", req: &subscriptionv1.ListSubscriptionsRequest{}},
+		{name: "empty strings are unset", req: &subscriptionv1.ListSubscriptionsRequest{UserId: str(""), ServiceName: str(""), Category: str("")}},
+		{
+			name: "filters",
+			req:  &subscriptionv1.ListSubscriptionsRequest{UserId: str(userID.String()), ServiceName: str("Netflix"), Category: str("video"), Tags: []string{"family"}},
+			want: models.SubscriptionFilter{UserID: &userID, ServiceName: str("Netflix"), Category: str("video"), Tags: []string{"family"}},
+		},
+		{name: "invalid user ID", req: &subscriptionv1.ListSubscriptionsRequest{UserId: str("nope")}, wantCode: codes.InvalidArgument},
+		{name: "invalid service ID", req: &subscriptionv1.ListSubscriptionsRequest{ServiceId: str("nope")}, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listFilter(tt.req)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("listFilter() code = %v, want %v", code, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("listFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToSubscription(t *testing.T) {
+	end := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	householdID := uuid.New()
+	subscription := models.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		ServiceID:   uuid.New(),
+		Price:       599,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		SplitType:   models.SplitEqual,
+		Tags:        []string{"family"},
+	}
+
+	msg := toSubscription(subscription)
+	if msg.EndDate != nil || msg.HouseholdId != nil {
+		t.Fatalf("open subscription without household = end %v, household %v, want unset", msg.EndDate, msg.HouseholdId)
+	}
+	if msg.Id != subscription.ID.String() || msg.Price != 599 || !msg.StartDate.AsTime().Equal(subscription.StartDate) || msg.SplitType != models.SplitEqual {
+		t.Fatalf("toSubscription() = %v", msg)
+	}
+
+	subscription.EndDate = &end
+	subscription.HouseholdID = &householdID
+	msg = toSubscription(subscription)
+	if msg.EndDate == nil || !msg.EndDate.AsTime().Equal(end) || msg.GetHouseholdId() != householdID.String() {
+		t.Fatalf("toSubscription() = end %v, household %q, want %v and %v", msg.EndDate, msg.GetHouseholdId(), end, householdID)
+	}
+}
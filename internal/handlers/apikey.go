@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type APIKeyHandler struct {
+	db     *database.DB
+	logger *logrus.Logger
+}
+
+func NewAPIKeyHandler(db *database.DB, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// POST /api-keys
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate request
+	if err := validation.ValidateCreateAPIKey(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	var userID *uuid.UUID
+	if req.UserID != "" {
+		parsed := uuid.MustParse(req.UserID)
+		userID = &parsed
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		parsed, _ := time.Parse(time.RFC3339, req.ExpiresAt)
+		expiresAt = &parsed
+	}
+
+	key, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate API key")
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	if userID != nil {
+		if err := ensureUser(db, db.TenantID(), *userID); err != nil {
+			h.apiKeyWriteError(w, err, "Failed to create API key")
+			return
+		}
+	}
+
+	var row records.APIKey
+	query := `
+		INSERT INTO api_keys (tenant_id, name, key_prefix, key_hash, user_id, scopes, roles, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING *`
+
+	roles := req.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+
+	err = db.Get(&row, query, db.TenantID(), strings.TrimSpace(req.Name), prefix,
+		auth.HashAPIKey(key), userID, pq.StringArray(req.Scopes), pq.StringArray(roles), expiresAt)
+	if err != nil {
+		h.apiKeyWriteError(w, err, "Failed to create API key")
+		return
+	}
+	created := models.CreatedAPIKey{APIKey: row.Model(), Key: key}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+
+	h.logger.WithFields(logrus.Fields{
+		"api_key_id": created.ID,
+		"key_prefix": created.KeyPrefix,
+	}).Info("API key created successfully")
+}
+
+// GET /api-keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	db := tenantDB(h.db, r)
+
+	keys := []records.APIKey{}
+	err := db.Select(&keys, `SELECT * FROM api_keys WHERE tenant_id = $1 ORDER BY created_at DESC`, db.TenantID())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list API keys")
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records.Models[models.APIKey](keys))
+}
+
+// DELETE /api-keys/{id}
+// Keys are revoked rather than deleted so their usage stays auditable
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid API key ID format")
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	result, err := db.Exec(`
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE tenant_id = $1 AND id = $2 AND revoked_at IS NULL`, db.TenantID(), id)
+	if err != nil {
+		h.apiKeyWriteError(w, err, "Failed to revoke API key")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithField("api_key_id", id).Info("API key revoked successfully")
+}
+
+// apiKeyWriteError maps API key errors to HTTP responses
+func (h *APIKeyHandler) apiKeyWriteError(w http.ResponseWriter, err error, message string) {
+	h.logger.WithError(err).Error(message)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqForeignKeyViolation {
+		http.Error(w, "User not found", http.StatusBadRequest)
+		return
+	}
+
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/subscriptions"
+
+	"github.com/google/uuid"
+)
+
+// authorizeAdmin consults the policy for an action only admins may take
+// and answers 403 when the principal may not
+func authorizeAdmin(w http.ResponseWriter, r *http.Request, service *subscriptions.Service, action authz.Action) bool {
+	if err := service.AuthorizeAdmin(r.Context(), action); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// authorizeUser consults the policy for an action on the user with the ID
+// and answers 403 when the principal may not
+func authorizeUser(w http.ResponseWriter, r *http.Request, service *subscriptions.Service, action authz.Action, userID *uuid.UUID) bool {
+	if err := service.AuthorizeUser(r.Context(), action, userID); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
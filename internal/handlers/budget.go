@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/budgets"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// BudgetHandler manages budgets. Access follows the subscription policy:
+// regular users manage the budgets of their own costs, tenant-wide budgets
+// are for admins and readable by the finance role.
+type BudgetHandler struct {
+	db      *database.DB
+	service *subscriptions.Service
+	logger  *logrus.Logger
+}
+
+func NewBudgetHandler(db *database.DB, service *subscriptions.Service, logger *logrus.Logger) *BudgetHandler {
+	return &BudgetHandler{
+		db:      db,
+		service: service,
+		logger:  logger,
+	}
+}
+
+// POST /budgets
+func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateBudgetRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate request
+	if err := validation.ValidateCreateBudget(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	// Regular users budget their own costs
+	userID := h.service.OwnerScope(r.Context())
+	if req.UserID != "" {
+		id := uuid.MustParse(req.UserID)
+		userID = &id
+	}
+
+	if err := h.service.Authorize(r.Context(), authz.ActionCreate, userID); err != nil {
+		h.budgetError(w, err, "Failed to create budget")
+		return
+	}
+
+	var category *string
+	if req.Category != "" {
+		category = &req.Category
+	}
+
+	var serviceID *uuid.UUID
+	if req.ServiceID != "" {
+		id := uuid.MustParse(req.ServiceID)
+		serviceID = &id
+	}
+
+	db := tenantDB(h.db, r)
+
+	var budget records.Budget
+	query := `
+		INSERT INTO budgets (tenant_id, user_id, name, period, amount, category, service_id, thresholds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING *`
+
+	err := db.Get(&budget, query, db.TenantID(), userID, strings.TrimSpace(req.Name), req.Period, req.Amount,
+		category, serviceID, pq.Int64Array(budgets.Thresholds(req.Thresholds)))
+	if err != nil {
+		h.budgetError(w, err, "Failed to create budget")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(budget.Model())
+
+	h.logger.WithFields(logrus.Fields{
+		"budget_id": budget.ID,
+		"period":    budget.Period,
+	}).Info("Budget created successfully")
+}
+
+// GET /budgets
+func (h *BudgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Authorize(r.Context(), authz.ActionList, nil); err != nil {
+		h.budgetError(w, err, "Failed to list budgets")
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	query := `SELECT * FROM budgets WHERE tenant_id = $1`
+	args := []interface{}{db.TenantID()}
+
+	// Regular users only list their own budgets
+	userID := h.service.OwnerScope(r.Context())
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		id, err := uuid.Parse(userIDStr)
+		if err != nil {
+			h.logger.WithError(err).Error("Invalid user ID format")
+			http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+			return
+		}
+		if userID != nil && *userID != id {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		userID = &id
+	}
+
+	if userID != nil {
+		query += " AND user_id = $2"
+		args = append(args, *userID)
+	}
+	query += " ORDER BY created_at DESC"
+
+	list := []records.Budget{}
+	if err := db.Select(&list, query, args...); err != nil {
+		h.budgetError(w, err, "Failed to list budgets")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records.Models[models.Budget](list))
+}
+
+// GET /budgets/{id}
+func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	budget, ok := h.loadBudget(w, r, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(budget.Model())
+}
+
+// PUT /budgets/{id}
+func (h *BudgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateBudgetRequest
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate
+	if err := validation.ValidateUpdateBudget(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	budget, ok := h.loadBudget(w, r, authz.ActionUpdate)
+	if !ok {
+		return
+	}
+
+	// Build update query
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Name != nil {
+		setParts = append(setParts, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, strings.TrimSpace(*req.Name))
+		argCount++
+	}
+
+	if req.Amount != nil {
+		setParts = append(setParts, fmt.Sprintf("amount = $%d", argCount))
+		args = append(args, *req.Amount)
+		argCount++
+	}
+
+	if req.Thresholds != nil {
+		setParts = append(setParts, fmt.Sprintf("thresholds = $%d", argCount))
+		args = append(args, pq.Int64Array(budgets.Thresholds(req.Thresholds)))
+		argCount++
+	}
+
+	if len(setParts) == 0 {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argCount))
+	args = append(args, time.Now())
+	argCount++
+
+	db := tenantDB(h.db, r)
+
+	args = append(args, db.TenantID(), budget.ID)
+	query := fmt.Sprintf("UPDATE budgets SET %s WHERE tenant_id = $%d AND id = $%d RETURNING *",
+		strings.Join(setParts, ", "), argCount, argCount+1)
+
+	if err := db.Get(budget, query, args...); err != nil {
+		h.budgetError(w, err, "Failed to update budget")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(budget.Model())
+
+	h.logger.WithField("budget_id", budget.ID).Info("Budget updated successfully")
+}
+
+// DELETE /budgets/{id}
+// Recorded alerts of the budget are deleted with it
+func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	budget, ok := h.loadBudget(w, r, authz.ActionDelete)
+	if !ok {
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	result, err := db.Exec("DELETE FROM budgets WHERE tenant_id = $1 AND id = $2", db.TenantID(), budget.ID)
+	if err != nil {
+		h.budgetError(w, err, "Failed to delete budget")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to delete budget", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Budget not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithField("budget_id", budget.ID).Info("Budget deleted successfully")
+}
+
+// GET /budgets/{id}/status
+// date (YYYY-MM-DD or MM-YYYY, today by default) selects the period and
+// the last month counted
+func (h *BudgetHandler) GetBudgetStatus(w http.ResponseWriter, r *http.Request) {
+	date := time.Now().UTC()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		var err error
+		if date, err = validation.ParseDate(dateStr); err != nil {
+			h.logger.WithError(err).Error("Invalid date format")
+			http.Error(w, "Invalid date format (use MM-YYYY or YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	budget, ok := h.loadBudget(w, r, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	status, err := budgets.Status(r.Context(), h.service, budget.Model(), date)
+	if err != nil {
+		h.budgetError(w, err, "Failed to evaluate budget")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// loadBudget loads the budget of the path and consults the policy for the
+// action on it, writing the error response when either fails
+func (h *BudgetHandler) loadBudget(w http.ResponseWriter, r *http.Request, action authz.Action) (*records.Budget, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid budget ID format")
+		http.Error(w, "Invalid budget ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	db := tenantDB(h.db, r)
+
+	var budget records.Budget
+	err = db.Get(&budget, `SELECT * FROM budgets WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+	if err != nil {
+		h.budgetError(w, err, "Failed to load budget")
+		return nil, false
+	}
+
+	if err := h.service.Authorize(r.Context(), action, budget.UserID); err != nil {
+		h.budgetError(w, err, "Failed to load budget")
+		return nil, false
+	}
+
+	return &budget, true
+}
+
+// budgetError maps budget errors to HTTP responses
+func (h *BudgetHandler) budgetError(w http.ResponseWriter, err error, message string) {
+	var denied *authz.DeniedError
+	var validationErrs validation.ValidationErrors
+	var inputErr *subscriptions.InputError
+	var pqErr *pq.Error
+
+	switch {
+	case errors.As(err, &denied):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.As(err, &validationErrs):
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+	case errors.As(err, &inputErr):
+		h.logger.WithError(err).Error(inputErr.Message)
+		http.Error(w, inputErr.Message, http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Budget not found", http.StatusNotFound)
+	case errors.As(err, &pqErr) && pqErr.Code == pqForeignKeyViolation:
+		h.logger.WithError(err).Warn(message)
+		http.Error(w, "Service not found", http.StatusBadRequest)
+	default:
+		h.logger.WithError(err).Error(message)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"subscription-aggregator/internal/validation"
+
+	"github.com/sirupsen/logrus"
+)
+
+// decodeJSON strictly decodes the request body into dst. It answers 413 for
+// bodies over the route limit and 400 for malformed JSON or unknown fields,
+// and reports whether the handler may go on. The OpenAPI middleware has
+// usually rejected such bodies already.
+func decodeJSON(w http.ResponseWriter, r *http.Request, logger *logrus.Logger, dst interface{}) bool {
+	err := validation.DecodeJSON(r.Body, dst)
+	if err == nil {
+		return true
+	}
+
+	logger.WithError(err).Error("Failed to decode request body")
+
+	var validationErrors validation.ValidationErrors
+	switch {
+	case errors.Is(err, validation.ErrBodyTooLarge):
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+	case errors.As(err, &validationErrors):
+		http.Error(w, "Validation failed: "+validationErrors.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"subscription-aggregator/internal/gql"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+type GraphQLHandler struct {
+	schema *gql.Schema
+	logger *logrus.Logger
+}
+
+func NewGraphQLHandler(schema *gql.Schema, logger *logrus.Logger) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: schema,
+		logger: logger,
+	}
+}
+
+// POST /graphql
+//
+// Errors of the query itself are reported in the errors field of a 200
+// response, as GraphQL clients expect
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var req models.GraphQLRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	if err := validation.ValidateGraphQLRequest(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	resp := h.schema.Exec(r.Context(), req)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type HouseholdHandler struct {
+	db      *database.DB
+	service *subscriptions.Service
+	logger  *logrus.Logger
+}
+
+func NewHouseholdHandler(db *database.DB, service *subscriptions.Service, logger *logrus.Logger) *HouseholdHandler {
+	return &HouseholdHandler{
+		db:      db,
+		service: service,
+		logger:  logger,
+	}
+}
+
+// POST /households
+func (h *HouseholdHandler) CreateHousehold(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateHouseholdRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate request
+	if err := validation.ValidateCreateHousehold(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	members := make([]uuid.UUID, 0, len(req.Members)+1)
+	for _, member := range req.Members {
+		members = append(members, uuid.MustParse(member))
+	}
+
+	// Regular users create households they belong to
+	if owner := h.service.OwnerScope(r.Context()); owner != nil && !containsUUID(members, *owner) {
+		members = append(members, *owner)
+	}
+
+	if err := h.service.AuthorizeHousehold(r.Context(), authz.ActionCreate, members); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var household models.Household
+	err := db.Transact(func(tx *sqlx.Tx) error {
+		query := `INSERT INTO households (tenant_id, name) VALUES ($1, $2) RETURNING *`
+		var row records.Household
+		if err := tx.Get(&row, query, db.TenantID(), strings.TrimSpace(req.Name)); err != nil {
+			return err
+		}
+		household = row.Model()
+
+		household.Members = []uuid.UUID{}
+		for _, userID := range members {
+			if err := addHouseholdMember(tx, db.TenantID(), household.ID, userID); err != nil {
+				return err
+			}
+			household.Members = append(household.Members, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		h.householdWriteError(w, err, "Failed to create household")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(household)
+
+	h.logger.WithFields(logrus.Fields{
+		"household_id": household.ID,
+		"members":      len(household.Members),
+	}).Info("Household created successfully")
+}
+
+// GET /households/{id}
+func (h *HouseholdHandler) GetHousehold(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid household ID format")
+		http.Error(w, "Invalid household ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var row records.Household
+	err = db.Get(&row, `SELECT * FROM households WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+	if err != nil {
+		h.logger.WithError(err).Error("Household not found")
+		http.Error(w, "Household not found", http.StatusNotFound)
+		return
+	}
+	household := row.Model()
+
+	members, err := subscriptions.HouseholdMembers(db, db.TenantID(), []uuid.UUID{id})
+	if err != nil {
+		h.householdWriteError(w, err, "Failed to get household")
+		return
+	}
+	household.Members = members[id]
+	if household.Members == nil {
+		household.Members = []uuid.UUID{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(household)
+}
+
+// GET /households
+func (h *HouseholdHandler) ListHouseholds(w http.ResponseWriter, r *http.Request) {
+	db := tenantDB(h.db, r)
+
+	query := `SELECT * FROM households WHERE tenant_id = $1 ORDER BY created_at DESC`
+	args := []interface{}{db.TenantID()}
+
+	// Only households the user belongs to
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			h.logger.WithError(err).Error("Invalid user ID format")
+			http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+			return
+		}
+		query = `
+			SELECT h.* FROM households h
+			JOIN household_members m ON m.tenant_id = h.tenant_id AND m.household_id = h.id
+			WHERE h.tenant_id = $1 AND m.user_id = $2
+			ORDER BY h.created_at DESC`
+		args = append(args, userID)
+	}
+
+	var rows []records.Household
+	if err := db.Select(&rows, query, args...); err != nil {
+		h.householdWriteError(w, err, "Failed to list households")
+		return
+	}
+	households := records.Models[models.Household](rows)
+
+	ids := make([]uuid.UUID, 0, len(households))
+	for _, household := range households {
+		ids = append(ids, household.ID)
+	}
+
+	members, err := subscriptions.HouseholdMembers(db, db.TenantID(), ids)
+	if err != nil {
+		h.householdWriteError(w, err, "Failed to list households")
+		return
+	}
+	for i := range households {
+		households[i].Members = members[households[i].ID]
+		if households[i].Members == nil {
+			households[i].Members = []uuid.UUID{}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(households)
+}
+
+// DELETE /households/{id}
+func (h *HouseholdHandler) DeleteHousehold(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid household ID format")
+		http.Error(w, "Invalid household ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	if !h.authorizeHousehold(w, r, db, authz.ActionDelete, id) {
+		return
+	}
+
+	result, err := db.Exec("DELETE FROM households WHERE tenant_id = $1 AND id = $2", db.TenantID(), id)
+	if err != nil {
+		h.householdWriteError(w, err, "Failed to delete household")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to delete household", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Household not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithField("household_id", id).Info("Household deleted successfully")
+}
+
+// POST /households/{id}/members
+func (h *HouseholdHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid household ID format")
+		http.Error(w, "Invalid household ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AddHouseholdMemberRequest
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate
+	if err := validation.ValidateAddHouseholdMember(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	userID := uuid.MustParse(req.UserID)
+
+	db := tenantDB(h.db, r)
+
+	if !h.authorizeHousehold(w, r, db, authz.ActionUpdate, id) {
+		return
+	}
+
+	if err := addHouseholdMember(db, db.TenantID(), id, userID); err != nil {
+		h.householdWriteError(w, err, "Failed to add household member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithFields(logrus.Fields{
+		"household_id": id,
+		"user_id":      userID,
+	}).Info("Household member added successfully")
+}
+
+// DELETE /households/{id}/members/{user_id}
+func (h *HouseholdHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid household ID format")
+		http.Error(w, "Invalid household ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := uuid.Parse(vars["user_id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID format")
+		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	if !h.authorizeHousehold(w, r, db, authz.ActionUpdate, id) {
+		return
+	}
+
+	result, err := db.Exec("DELETE FROM household_members WHERE tenant_id = $1 AND household_id = $2 AND user_id = $3",
+		db.TenantID(), id, userID)
+	if err != nil {
+		h.householdWriteError(w, err, "Failed to remove household member")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to remove household member", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Household member not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithFields(logrus.Fields{
+		"household_id": id,
+		"user_id":      userID,
+	}).Info("Household member removed successfully")
+}
+
+// authorizeHousehold loads the members of the household and consults the
+// policy, answering 404 for unknown households
+func (h *HouseholdHandler) authorizeHousehold(w http.ResponseWriter, r *http.Request, db *database.TenantDB, action authz.Action, id uuid.UUID) bool {
+	var exists bool
+	err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM households WHERE tenant_id = $1 AND id = $2)`, db.TenantID(), id)
+	if err != nil {
+		h.householdWriteError(w, err, "Failed to load household")
+		return false
+	}
+	if !exists {
+		http.Error(w, "Household not found", http.StatusNotFound)
+		return false
+	}
+
+	members, err := subscriptions.HouseholdMembers(db, db.TenantID(), []uuid.UUID{id})
+	if err != nil {
+		h.householdWriteError(w, err, "Failed to load household")
+		return false
+	}
+
+	if err := h.service.AuthorizeHousehold(r.Context(), action, members[id]); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// householdWriteError maps household errors to HTTP responses
+func (h *HouseholdHandler) householdWriteError(w http.ResponseWriter, err error, message string) {
+	h.logger.WithError(err).Error(message)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqForeignKeyViolation {
+		http.Error(w, "Household not found", http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// addHouseholdMember adds the user to the household, registering unknown users
+func addHouseholdMember(db database.Querier, tenantID, householdID, userID uuid.UUID) error {
+	if err := ensureUser(db, tenantID, userID); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO household_members (tenant_id, household_id, user_id) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`, tenantID, householdID, userID)
+	return err
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, item := range ids {
+		if item == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/rules"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// GET /subscriptions/{id}/price-changes
+func (h *SubscriptionHandler) ListPriceChanges(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid subscription ID format")
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	if !h.authorizeExisting(w, r, db, authz.ActionRead, id) {
+		return
+	}
+
+	changes, err := subscriptions.PriceChanges(db, db.TenantID(), []uuid.UUID{id})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list price changes")
+		http.Error(w, "Failed to list price changes", http.StatusInternalServerError)
+		return
+	}
+
+	list := changes[id]
+	if list == nil {
+		list = []models.PriceChange{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// POST /subscriptions/{id}/price-changes
+// A change on a date that already has one replaces it
+func (h *SubscriptionHandler) SchedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid subscription ID format")
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SchedulePriceChangeRequest
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate
+	if err := validation.ValidateSchedulePriceChange(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	effectiveDate, err := validation.ParseDate(req.EffectiveDate)
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid effective date format")
+		http.Error(w, "Invalid effective date format (use MM-YYYY or YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	if !h.authorizeExisting(w, r, db, authz.ActionUpdate, id) {
+		return
+	}
+
+	var change models.PriceChange
+	err = db.Transact(func(tx *sqlx.Tx) error {
+		var row records.Subscription
+		err := tx.Get(&row, `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2 FOR UPDATE`,
+			db.TenantID(), id)
+		if err != nil {
+			return err
+		}
+		subscription := row.Model()
+
+		shares, err := subscriptions.Shares(tx, db.TenantID(), []uuid.UUID{id})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if err := rules.CheckPriceChange(subscription, shares[id], effectiveDate, req.Price, today); err != nil {
+			return err
+		}
+
+		var scheduled records.PriceChange
+		err = tx.Get(&scheduled, `
+			INSERT INTO subscription_price_changes (tenant_id, subscription_id, effective_date, price)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (tenant_id, subscription_id, effective_date)
+			DO UPDATE SET price = EXCLUDED.price, created_at = NOW()
+			RETURNING *`, db.TenantID(), id, effectiveDate, req.Price)
+		if err != nil {
+			return err
+		}
+		change = scheduled.Model()
+		return nil
+	})
+	if err != nil {
+		h.subscriptionWriteError(w, err, "Failed to schedule price change")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(change)
+
+	h.logger.WithFields(logrus.Fields{
+		"subscription_id": id,
+		"effective_date":  change.EffectiveDate.Format("2006-01-02"),
+	}).Info("Price change scheduled successfully")
+}
+
+// DELETE /subscriptions/{id}/price-changes/{date}
+func (h *SubscriptionHandler) CancelPriceChange(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid subscription ID format")
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	effectiveDate, err := time.Parse("2006-01-02", mux.Vars(r)["date"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid effective date format")
+		http.Error(w, "Invalid effective date format (use YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	if !h.authorizeExisting(w, r, db, authz.ActionUpdate, id) {
+		return
+	}
+
+	result, err := db.Exec(`
+		DELETE FROM subscription_price_changes
+		WHERE tenant_id = $1 AND subscription_id = $2 AND effective_date = $3`, db.TenantID(), id, effectiveDate)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to cancel price change")
+		http.Error(w, "Failed to cancel price change", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to cancel price change", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Price change not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithField("subscription_id", id).Info("Price change cancelled successfully")
+}
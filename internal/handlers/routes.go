@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/gql"
+	"subscription-aggregator/internal/middleware"
+	"subscription-aggregator/internal/openapi"
+	"subscription-aggregator/internal/subscriptions"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Routes holds the handlers of the API and registers their routes
+type Routes struct {
+	subscriptions   *SubscriptionHandler
+	subscriptionsV2 *SubscriptionV2
+	services        *ServiceHandler
+	users           *UserHandler
+	households      *HouseholdHandler
+	apiKeys         *APIKeyHandler
+	webhooks        *WebhookHandler
+	budgets         *BudgetHandler
+	graphQL         *GraphQLHandler
+}
+
+// NewRoutes builds the handlers of every API route
+func NewRoutes(db *database.DB, service *subscriptions.Service, logger *logrus.Logger) *Routes {
+	subscriptionHandler := NewSubscriptionHandler(db, service, logger)
+
+	return &Routes{
+		subscriptions:   subscriptionHandler,
+		subscriptionsV2: NewSubscriptionV2(subscriptionHandler),
+		services:        NewServiceHandler(db, service, logger),
+		users:           NewUserHandler(db, service, logger),
+		households:      NewHouseholdHandler(db, service, logger),
+		apiKeys:         NewAPIKeyHandler(db, logger),
+		webhooks:        NewWebhookHandler(db, logger),
+		budgets:         NewBudgetHandler(db, service, logger),
+		graphQL:         NewGraphQLHandler(gql.NewSchema(service, logger), logger),
+	}
+}
+
+// RegisterSystem registers the routes served without authentication or a
+// tenant: the health check and the API description
+func RegisterSystem(r *mux.Router, spec *openapi.Document) {
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "healthy"}`))
+	}).Methods("GET")
+
+	r.HandleFunc("/openapi.json", openapi.Handler(spec)).Methods("GET")
+	r.HandleFunc("/docs", openapi.DocsHandler(spec)).Methods("GET")
+}
+
+// Register registers the routes of every API version on the authenticated
+// router
+func (a *Routes) Register(r *mux.Router) {
+	// Unversioned routes stay as deprecated aliases of v1
+	a.v1(r)
+	a.v1(r.PathPrefix("/v1").Subrouter())
+	a.v2(r.PathPrefix("/v2").Subrouter())
+
+	// GraphQL evolves its schema instead of versions
+	r.HandleFunc("/graphql", a.graphQL.Query).Methods("POST")
+}
+
+// v1 registers the original API
+func (a *Routes) v1(r *mux.Router) {
+	r.HandleFunc("/subscriptions", a.subscriptions.ListSubscriptions).Methods("GET")
+	r.HandleFunc("/subscriptions/aggregate", a.subscriptions.AggregateSubscriptions).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}", a.subscriptions.UpdateSubscription).Methods("PUT")
+	a.common(r)
+}
+
+// v2 registers the API with envelopes, merge patch updates and aggregation
+// charging every month of the period
+func (a *Routes) v2(r *mux.Router) {
+	r.HandleFunc("/subscriptions", a.subscriptionsV2.ListSubscriptions).Methods("GET")
+	r.HandleFunc("/subscriptions/aggregate", a.subscriptionsV2.AggregateSubscriptions).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}", a.subscriptionsV2.PatchSubscription).Methods("PATCH")
+	a.common(r)
+}
+
+// common registers the routes that behave the same in every version
+func (a *Routes) common(r *mux.Router) {
+	r.HandleFunc("/subscriptions", a.subscriptions.CreateSubscription).Methods("POST")
+	r.HandleFunc("/subscriptions/forecast", a.subscriptions.ForecastSubscriptions).Methods("POST")
+	r.HandleFunc("/subscriptions/anomalies", a.subscriptions.ListAnomalies).Methods("GET")
+	r.HandleFunc("/subscriptions/{id}", a.subscriptions.GetSubscription).Methods("GET")
+	r.HandleFunc("/subscriptions/{id}", a.subscriptions.DeleteSubscription).Methods("DELETE")
+
+	// Service catalog
+	r.HandleFunc("/services", a.services.ListServices).Methods("GET")
+	r.HandleFunc("/services", a.services.CreateService).Methods("POST")
+	r.HandleFunc("/services/{id}", a.services.GetService).Methods("GET")
+	r.HandleFunc("/services/{id}", a.services.UpdateService).Methods("PUT")
+	r.HandleFunc("/services/{id}", a.services.DeleteService).Methods("DELETE")
+
+	// Cost sharing
+	r.HandleFunc("/subscriptions/{id}/sharing", a.subscriptions.GetSharing).Methods("GET")
+	r.HandleFunc("/subscriptions/{id}/sharing", a.subscriptions.UpdateSharing).Methods("PUT")
+
+	// Scheduled price changes
+	r.HandleFunc("/subscriptions/{id}/price-changes", a.subscriptions.ListPriceChanges).Methods("GET")
+	r.HandleFunc("/subscriptions/{id}/price-changes", a.subscriptions.SchedulePriceChange).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}/price-changes/{date}", a.subscriptions.CancelPriceChange).Methods("DELETE")
+
+	// Users
+	r.HandleFunc("/users", a.users.ListUsers).Methods("GET")
+	r.HandleFunc("/users", a.users.CreateUser).Methods("POST")
+	r.HandleFunc("/users/{id}", a.users.GetUser).Methods("GET")
+	r.HandleFunc("/users/{id}", a.users.UpdateUser).Methods("PUT")
+	r.HandleFunc("/users/{id}", a.users.DeleteUser).Methods("DELETE")
+
+	// Households
+	r.HandleFunc("/households", a.households.ListHouseholds).Methods("GET")
+	r.HandleFunc("/households", a.households.CreateHousehold).Methods("POST")
+	r.HandleFunc("/households/{id}", a.households.GetHousehold).Methods("GET")
+	r.HandleFunc("/households/{id}", a.households.DeleteHousehold).Methods("DELETE")
+	r.HandleFunc("/households/{id}/members", a.households.AddMember).Methods("POST")
+	r.HandleFunc("/households/{id}/members/{user_id}", a.households.RemoveMember).Methods("DELETE")
+
+	// Budgets
+	r.HandleFunc("/budgets", a.budgets.ListBudgets).Methods("GET")
+	r.HandleFunc("/budgets", a.budgets.CreateBudget).Methods("POST")
+	r.HandleFunc("/budgets/{id}", a.budgets.GetBudget).Methods("GET")
+	r.HandleFunc("/budgets/{id}", a.budgets.UpdateBudget).Methods("PUT")
+	r.HandleFunc("/budgets/{id}", a.budgets.DeleteBudget).Methods("DELETE")
+	r.HandleFunc("/budgets/{id}/status", a.budgets.GetBudgetStatus).Methods("GET")
+
+	// API keys
+	apiKeyRouter := r.PathPrefix("/api-keys").Subrouter()
+	apiKeyRouter.Use(middleware.RequireScope(auth.ScopeAdmin))
+	apiKeyRouter.HandleFunc("", a.apiKeys.ListAPIKeys).Methods("GET")
+	apiKeyRouter.HandleFunc("", a.apiKeys.CreateAPIKey).Methods("POST")
+	apiKeyRouter.HandleFunc("/{id}", a.apiKeys.RevokeAPIKey).Methods("DELETE")
+
+	// Webhooks
+	webhookRouter := r.PathPrefix("/webhooks").Subrouter()
+	webhookRouter.Use(middleware.RequireScope(auth.ScopeAdmin))
+	webhookRouter.HandleFunc("", a.webhooks.ListWebhooks).Methods("GET")
+	webhookRouter.HandleFunc("", a.webhooks.CreateWebhook).Methods("POST")
+	webhookRouter.HandleFunc("/{id}", a.webhooks.GetWebhook).Methods("GET")
+	webhookRouter.HandleFunc("/{id}", a.webhooks.UpdateWebhook).Methods("PUT")
+	webhookRouter.HandleFunc("/{id}", a.webhooks.DeleteWebhook).Methods("DELETE")
+	webhookRouter.HandleFunc("/{id}/deliveries", a.webhooks.ListDeliveries).Methods("GET")
+	webhookRouter.HandleFunc("/{id}/deliveries/{delivery_id}/redeliver", a.webhooks.RedeliverDelivery).Methods("POST")
+}
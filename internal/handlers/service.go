@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/outbox"
+	"subscription-aggregator/internal/rollup"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Postgres error codes the handlers react to
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqCheckViolation      = "23514"
+)
+
+var errServiceNameTaken = errors.New("service name or alias already used by another service")
+
+type ServiceHandler struct {
+	db      *database.DB
+	service *subscriptions.Service
+	logger  *logrus.Logger
+}
+
+func NewServiceHandler(db *database.DB, service *subscriptions.Service, logger *logrus.Logger) *ServiceHandler {
+	return &ServiceHandler{
+		db:      db,
+		service: service,
+		logger:  logger,
+	}
+}
+
+// POST /services
+func (h *ServiceHandler) CreateService(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.service, authz.ActionCreate) {
+		return
+	}
+
+	var req models.CreateServiceRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate request
+	if err := validation.ValidateCreateService(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+	name := validation.NormalizeServiceName(req.Name)
+	aliases := normalizeAliases(req.Aliases)
+
+	var service records.Service
+	err := db.Transact(func(tx *sqlx.Tx) error {
+		absorbed, err := servicesNamed(tx, db.TenantID(), uuid.Nil, aliases)
+		if err != nil {
+			return err
+		}
+
+		err = ensureServiceNamesFree(tx, db.TenantID(), serviceIDs(absorbed), append([]string{name}, aliases...))
+		if err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO services (tenant_id, name, aliases, category, default_price, website)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING *`
+
+		err = tx.Get(&service, query, db.TenantID(), name, pq.StringArray(aliases), req.Category, req.DefaultPrice, req.Website)
+		if err != nil {
+			return err
+		}
+
+		return absorbServices(tx, &service, absorbed)
+	})
+	if err != nil {
+		h.serviceWriteError(w, err, "Failed to create service")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(service.Model())
+
+	h.logger.WithFields(logrus.Fields{
+		"service_id": service.ID,
+		"name":       service.Name,
+	}).Info("Service created successfully")
+}
+
+// GET /services/{id}
+func (h *ServiceHandler) GetService(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid service ID format")
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var service records.Service
+	err = db.Get(&service, `SELECT * FROM services WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+	if err != nil {
+		h.logger.WithError(err).Error("Service not found")
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.Model())
+}
+
+// GET /services
+func (h *ServiceHandler) ListServices(w http.ResponseWriter, r *http.Request) {
+	db := tenantDB(h.db, r)
+
+	query := "SELECT * FROM services"
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{db.TenantID()}
+	argCount := 1
+
+	if name := r.URL.Query().Get("name"); name != "" {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf(
+			"(lower(name) = lower($%d) OR EXISTS (SELECT 1 FROM unnest(aliases) a WHERE lower(a) = lower($%d)))",
+			argCount, argCount))
+		args = append(args, validation.NormalizeServiceName(name))
+	}
+
+	if category := r.URL.Query().Get("category"); category != "" {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf("category = $%d", argCount))
+		args = append(args, category)
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += " ORDER BY name"
+
+	services := []records.Service{}
+	err := db.Select(&services, query, args...)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list services")
+		http.Error(w, "Failed to list services", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records.Models[models.Service](services))
+}
+
+// PUT /services/{id}
+func (h *ServiceHandler) UpdateService(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.service, authz.ActionUpdate) {
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid service ID format")
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateServiceRequest
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate
+	if err := validation.ValidateUpdateService(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	// Build update query
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 1
+	names := []string{}
+	var aliases []string
+
+	name := validation.NormalizeServiceName(req.Name)
+	if name != "" {
+		setParts = append(setParts, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, name)
+		argCount++
+		names = append(names, name)
+	}
+
+	if req.Aliases != nil {
+		aliases = normalizeAliases(req.Aliases)
+		setParts = append(setParts, fmt.Sprintf("aliases = $%d", argCount))
+		args = append(args, pq.StringArray(aliases))
+		argCount++
+		names = append(names, aliases...)
+	}
+
+	if req.Category != nil {
+		setParts = append(setParts, fmt.Sprintf("category = $%d", argCount))
+		args = append(args, req.Category)
+		argCount++
+	}
+
+	if req.DefaultPrice != nil {
+		setParts = append(setParts, fmt.Sprintf("default_price = $%d", argCount))
+		args = append(args, req.DefaultPrice)
+		argCount++
+	}
+
+	if req.Website != nil {
+		setParts = append(setParts, fmt.Sprintf("website = $%d", argCount))
+		args = append(args, req.Website)
+		argCount++
+	}
+
+	if len(setParts) == 0 {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argCount))
+	args = append(args, time.Now())
+	argCount++
+
+	args = append(args, db.TenantID(), id)
+	query := fmt.Sprintf("UPDATE services SET %s WHERE tenant_id = $%d AND id = $%d RETURNING *",
+		strings.Join(setParts, ", "), argCount, argCount+1)
+
+	err = db.Transact(func(tx *sqlx.Tx) error {
+		absorbed, err := servicesNamed(tx, db.TenantID(), id, aliases)
+		if err != nil {
+			return err
+		}
+
+		if err := ensureServiceNamesFree(tx, db.TenantID(), append(serviceIDs(absorbed), id), names); err != nil {
+			return err
+		}
+
+		var service records.Service
+		if err := tx.Get(&service, query, args...); err != nil {
+			return err
+		}
+
+		// Keep the denormalized name on subscriptions in sync with the catalog
+		if name != "" {
+			_, err = tx.Exec("UPDATE subscriptions SET service_name = $1 WHERE tenant_id = $2 AND service_id = $3",
+				name, db.TenantID(), id)
+			if err != nil {
+				return err
+			}
+		}
+
+		return absorbServices(tx, &service, absorbed)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.serviceWriteError(w, err, "Failed to update service")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	h.logger.WithField("service_id", id).Info("Service updated successfully")
+}
+
+// DELETE /services/{id}
+func (h *ServiceHandler) DeleteService(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.service, authz.ActionDelete) {
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid service ID format")
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	result, err := db.Exec("DELETE FROM services WHERE tenant_id = $1 AND id = $2", db.TenantID(), id)
+	if err != nil {
+		h.serviceWriteError(w, err, "Failed to delete service")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to delete service", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithField("service_id", id).Info("Service deleted successfully")
+}
+
+// serviceWriteError maps catalog errors to HTTP responses
+func (h *ServiceHandler) serviceWriteError(w http.ResponseWriter, err error, message string) {
+	h.logger.WithError(err).Error(message)
+
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, errServiceNameTaken):
+		http.Error(w, "Service name or alias already exists", http.StatusConflict)
+	case errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation:
+		http.Error(w, "Service name or alias already exists", http.StatusConflict)
+	case errors.As(err, &pqErr) && pqErr.Code == pqForeignKeyViolation:
+		http.Error(w, "Service is used by subscriptions", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// findServiceByName looks a catalog service of the tenant up by its
+// canonical name or any alias, ignoring case and surrounding whitespace
+func findServiceByName(db database.Querier, tenantID uuid.UUID, name string) (*records.Service, error) {
+	var service records.Service
+	query := `
+		SELECT * FROM services
+		WHERE tenant_id = $1
+		  AND (lower(name) = lower($2)
+		       OR EXISTS (SELECT 1 FROM unnest(aliases) a WHERE lower(a) = lower($2)))
+		LIMIT 1`
+
+	if err := db.Get(&service, query, tenantID, validation.NormalizeServiceName(name)); err != nil {
+		return nil, err
+	}
+
+	return &service, nil
+}
+
+// resolveService returns the catalog service for the given name,
+// registering a new catalog entry when the name is unknown. Called with the
+// transaction of the subscription write, so the entry is not left behind
+// when the write fails.
+func resolveService(q database.Querier, tenantID uuid.UUID, name string) (*records.Service, error) {
+	service, err := findServiceByName(q, tenantID, name)
+	if err == nil {
+		return service, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO services (tenant_id, name) VALUES ($1, $2)
+		ON CONFLICT (tenant_id, (lower(name))) DO NOTHING`, tenantID, validation.NormalizeServiceName(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return findServiceByName(q, tenantID, name)
+}
+
+// servicesNamed locks the catalog services of the tenant other than exceptID
+// whose canonical name is one of the aliases
+func servicesNamed(q database.Querier, tenantID, exceptID uuid.UUID, aliases []string) ([]records.Service, error) {
+	services := []records.Service{}
+	if len(aliases) == 0 {
+		return services, nil
+	}
+
+	query := `
+		SELECT * FROM services
+		WHERE tenant_id = $1 AND id <> $2
+		  AND lower(name) IN (SELECT lower(n) FROM unnest($3::text[]) n)
+		ORDER BY name
+		FOR UPDATE`
+
+	if err := q.Select(&services, query, tenantID, exceptID, pq.StringArray(aliases)); err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// absorbServices merges services whose name became an alias of the service
+// into it: their subscriptions and budgets move over, their aliases join
+// the service's, and the services are deleted. Subscriptions registered as
+// "Яндекс Плюс" end up on "Yandex Plus" once it gets that alias.
+func absorbServices(tx *sqlx.Tx, service *records.Service, absorbed []records.Service) error {
+	if len(absorbed) == 0 {
+		return nil
+	}
+
+	aliases := append([]string{}, service.Aliases...)
+	for _, other := range absorbed {
+		var moved []records.Subscription
+		err := tx.Select(&moved, `SELECT * FROM subscriptions WHERE tenant_id = $1 AND service_id = $2 FOR UPDATE`,
+			service.TenantID, other.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range moved {
+			var updated records.Subscription
+			err := tx.Get(&updated, `
+				UPDATE subscriptions SET service_id = $1, service_name = $2, updated_at = NOW()
+				WHERE tenant_id = $3 AND id = $4
+				RETURNING *`, service.ID, service.Name, service.TenantID, row.ID)
+			if err != nil {
+				return err
+			}
+			before, subscription := row.Model(), updated.Model()
+
+			if err := rollup.Apply(tx, &before, &subscription); err != nil {
+				return err
+			}
+			if err := outbox.Write(tx, models.EventSubscriptionUpdated, subscription); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`UPDATE budgets SET service_id = $1, updated_at = NOW() WHERE tenant_id = $2 AND service_id = $3`,
+			service.ID, service.TenantID, other.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM services WHERE tenant_id = $1 AND id = $2`, service.TenantID, other.ID); err != nil {
+			return err
+		}
+
+		aliases = append(aliases, other.Aliases...)
+	}
+
+	// The canonical name is never its own alias
+	merged := []string{}
+	for _, alias := range normalizeAliases(aliases) {
+		if !strings.EqualFold(alias, service.Name) {
+			merged = append(merged, alias)
+		}
+	}
+
+	return tx.Get(service, `UPDATE services SET aliases = $1 WHERE tenant_id = $2 AND id = $3 RETURNING *`,
+		pq.StringArray(merged), service.TenantID, service.ID)
+}
+
+func serviceIDs(services []records.Service) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(services))
+	for _, service := range services {
+		ids = append(ids, service.ID)
+	}
+	return ids
+}
+
+// ensureServiceNamesFree checks that none of the names is already used as a
+// name or alias by a catalog service of the tenant other than exceptIDs
+func ensureServiceNamesFree(q database.Querier, tenantID uuid.UUID, exceptIDs []uuid.UUID, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	var count int
+	query := `
+		SELECT COUNT(*) FROM services
+		WHERE tenant_id = $1 AND NOT (id = ANY($2))
+		  AND (lower(name) IN (SELECT lower(n) FROM unnest($3::text[]) n)
+		       OR EXISTS (SELECT 1 FROM unnest(aliases) a
+		                  WHERE lower(a) IN (SELECT lower(n) FROM unnest($3::text[]) n)))`
+
+	if err := q.Get(&count, query, tenantID, pq.Array(exceptIDs), pq.StringArray(names)); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return errServiceNameTaken
+	}
+
+	return nil
+}
+
+// normalizeAliases normalizes aliases and drops empty entries and duplicates
+func normalizeAliases(aliases []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, alias := range aliases {
+		alias = validation.NormalizeServiceName(alias)
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, alias)
+	}
+	return result
+}
+
+// writeValidationError writes validation errors as a plain-text 400 response
+func writeValidationError(w http.ResponseWriter, err error) {
+	if validationErrors, ok := err.(validation.ValidationErrors); ok {
+		var errorMessages []string
+		for _, validationErr := range validationErrors {
+			errorMessages = append(errorMessages, validationErr.Message)
+		}
+		http.Error(w, strings.Join(errorMessages, "; "), http.StatusBadRequest)
+	} else {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAliases(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{name: "nil", in: nil, want: []string{}},
+		{name: "whitespace collapsed", in: []string{"  Яндекс   Плюс "}, want: []string{"Яндекс Плюс"}},
+		{name: "case-insensitive duplicates keep the first", in: []string{"YouTube", "youtube", "YOUTUBE "}, want: []string{"YouTube"}},
+		{name: "empty entries dropped", in: []string{"", "  ", "Kinopoisk"}, want: []string{"Kinopoisk"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeAliases(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeAliases(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
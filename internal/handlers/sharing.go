@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/billing"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/outbox"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GET /subscriptions/{id}/sharing
+func (h *SubscriptionHandler) GetSharing(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid subscription ID format")
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var row records.Subscription
+	query := `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2`
+	if err := db.Get(&row, query, db.TenantID(), id); err != nil {
+		h.logger.WithError(err).Error("Subscription not found")
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	subscription := row.Model()
+
+	if !h.authorize(w, r, authz.ActionRead, &subscription.UserID) {
+		return
+	}
+
+	sharing, err := loadSharing(db, subscription)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to load cost sharing")
+		http.Error(w, "Failed to load cost sharing", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sharing)
+}
+
+// PUT /subscriptions/{id}/sharing
+func (h *SubscriptionHandler) UpdateSharing(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid subscription ID format")
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateSharingRequest
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var row records.Subscription
+	query := `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2`
+	if err := db.Get(&row, query, db.TenantID(), id); err != nil {
+		h.logger.WithError(err).Error("Subscription not found")
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	subscription := row.Model()
+
+	if !h.authorize(w, r, authz.ActionUpdate, &subscription.UserID) {
+		return
+	}
+
+	// Validate
+	if err := validation.ValidateSharing(req, subscription.Price); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	var householdID *uuid.UUID
+	if req.HouseholdID != nil {
+		parsed := uuid.MustParse(*req.HouseholdID)
+		householdID = &parsed
+	}
+
+	err = db.Transact(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE subscriptions SET split_type = $1, household_id = $2, updated_at = NOW()
+			WHERE tenant_id = $3 AND id = $4`, req.SplitType, householdID, db.TenantID(), id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM subscription_shares WHERE tenant_id = $1 AND subscription_id = $2`, db.TenantID(), id)
+		if err != nil {
+			return err
+		}
+
+		for _, share := range req.Shares {
+			userID := uuid.MustParse(share.UserID)
+			if err := ensureUser(tx, db.TenantID(), userID); err != nil {
+				return err
+			}
+
+			_, err := tx.Exec(`
+				INSERT INTO subscription_shares (tenant_id, subscription_id, user_id, percent, amount)
+				VALUES ($1, $2, $3, $4, $5)`, db.TenantID(), id, userID, share.Percent, share.Amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Get(&row, `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+		if err != nil {
+			return err
+		}
+		subscription = row.Model()
+		return outbox.Write(tx, models.EventSubscriptionUpdated, subscription)
+	})
+	if err != nil {
+		h.sharingWriteError(w, err)
+		return
+	}
+
+	sharing, err := loadSharing(db, subscription)
+	if err != nil {
+		h.sharingWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sharing)
+
+	h.logger.WithField("subscription_id", id).Info("Subscription cost sharing updated successfully")
+}
+
+// sharingWriteError maps cost sharing errors to HTTP responses
+func (h *SubscriptionHandler) sharingWriteError(w http.ResponseWriter, err error) {
+	h.logger.WithError(err).Error("Failed to update cost sharing")
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqForeignKeyViolation {
+		http.Error(w, "Household not found", http.StatusBadRequest)
+		return
+	}
+
+	http.Error(w, "Failed to update cost sharing", http.StatusInternalServerError)
+}
+
+// loadSharing builds the cost sharing view of a subscription
+func loadSharing(db database.Querier, subscription models.Subscription) (*models.SubscriptionSharing, error) {
+	shares, err := subscriptions.Shares(db, subscription.TenantID, []uuid.UUID{subscription.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	var members []uuid.UUID
+	if subscription.HouseholdID != nil {
+		householdIDs := []uuid.UUID{*subscription.HouseholdID}
+		byHousehold, err := subscriptions.HouseholdMembers(db, subscription.TenantID, householdIDs)
+		if err != nil {
+			return nil, err
+		}
+		members = byHousehold[*subscription.HouseholdID]
+	}
+
+	sharing := &models.SubscriptionSharing{
+		SubscriptionID: subscription.ID,
+		OwnerID:        subscription.UserID,
+		SplitType:      subscription.SplitType,
+		HouseholdID:    subscription.HouseholdID,
+		Shares:         shares[subscription.ID],
+		Costs:          []models.UserCost{},
+	}
+	if sharing.Shares == nil {
+		sharing.Shares = []models.SubscriptionShare{}
+	}
+
+	costs := billing.Split(subscription.Price, subscription.UserID, subscription.SplitType, sharing.Shares, members)
+	for userID, cost := range costs {
+		sharing.Costs = append(sharing.Costs, models.UserCost{UserID: userID, Cost: cost})
+	}
+	sort.Slice(sharing.Costs, func(i, j int) bool {
+		return sharing.Costs[i].UserID.String() < sharing.Costs[j].UserID.String()
+	})
+
+	return sharing, nil
+}
@@ -1,54 +1,92 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
-	"subscription-aggregator/internal/models"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/outbox"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/rollup"
+	"subscription-aggregator/internal/rules"
+	"subscription-aggregator/internal/subscriptions"
 	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
 type SubscriptionHandler struct {
-	db     *sqlx.DB
-	logger *logrus.Logger
+	db      *database.DB
+	service *subscriptions.Service
+	logger  *logrus.Logger
 }
 
-func NewSubscriptionHandler(db *sqlx.DB, logger *logrus.Logger) *SubscriptionHandler {
+func NewSubscriptionHandler(db *database.DB, service *subscriptions.Service, logger *logrus.Logger) *SubscriptionHandler {
 	return &SubscriptionHandler{
-		db:     db,
-		logger: logger,
+		db:      db,
+		service: service,
+		logger:  logger,
 	}
 }
 
+// authorize consults the policy and answers 403 when the principal may not
+// perform the action on a subscription owned by owner
+func (h *SubscriptionHandler) authorize(w http.ResponseWriter, r *http.Request, action authz.Action, owner *uuid.UUID) bool {
+	if err := h.service.Authorize(r.Context(), action, owner); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// subscriptionOwner returns the user owning the subscription
+func subscriptionOwner(db *database.TenantDB, id uuid.UUID) (uuid.UUID, error) {
+	var owner uuid.UUID
+	err := db.Get(&owner, `SELECT user_id FROM subscriptions WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+	return owner, err
+}
+
+// authorizeExisting loads the owner of the subscription and consults the
+// policy, answering 404 for unknown subscriptions
+func (h *SubscriptionHandler) authorizeExisting(w http.ResponseWriter, r *http.Request, db *database.TenantDB, action authz.Action, id uuid.UUID) bool {
+	owner, err := subscriptionOwner(db, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return false
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to load subscription")
+		http.Error(w, "Failed to load subscription", http.StatusInternalServerError)
+		return false
+	}
+
+	return h.authorize(w, r, action, &owner)
+}
+
 // POST /subscriptions
 func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateSubscriptionRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.WithError(err).Error("Failed to decode request body")
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !decodeJSON(w, r, h.logger, &req) {
 		return
 	}
 
 	// Validate request
 	if err := validation.ValidateCreateSubscription(req); err != nil {
 		h.logger.WithError(err).Error("Validation failed")
-		if validationErrors, ok := err.(validation.ValidationErrors); ok {
-			var errorMessages []string
-			for _, validationErr := range validationErrors {
-				errorMessages = append(errorMessages, validationErr.Message)
-			}
-			http.Error(w, strings.Join(errorMessages, "; "), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
+		writeValidationError(w, err)
 		return
 	}
 
@@ -60,37 +98,92 @@ func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if !h.authorize(w, r, authz.ActionCreate, &userID) {
+		return
+	}
+
 	// Parse start date
-	startDate, err := validation.ParseMonthYear(req.StartDate)
+	startDate, err := validation.ParseDate(req.StartDate)
 	if err != nil {
 		h.logger.WithError(err).Error("Invalid start date format")
-		http.Error(w, "Invalid start date format (use MM-YYYY)", http.StatusBadRequest)
+		http.Error(w, "Invalid start date format (use MM-YYYY or YYYY-MM-DD)", http.StatusBadRequest)
 		return
 	}
 
 	// Parse end date
 	var endDate *time.Time
 	if req.EndDate != "" {
-		parsedEndDate, err := validation.ParseMonthYear(req.EndDate)
+		parsedEndDate, err := validation.ParseEndDate(req.EndDate)
 		if err != nil {
 			h.logger.WithError(err).Error("Invalid end date format")
-			http.Error(w, "Invalid end date format (use MM-YYYY)", http.StatusBadRequest)
+			http.Error(w, "Invalid end date format (use MM-YYYY or YYYY-MM-DD)", http.StatusBadRequest)
 			return
 		}
 		endDate = &parsedEndDate
 	}
 
-	// Insert subscription
+	db := tenantDB(h.db, r)
+	tags := validation.NormalizeTags(req.Tags)
+
 	var subscription models.Subscription
-	query := `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at`
+	err = db.Transact(func(tx *sqlx.Tx) error {
+		// Resolve catalog service
+		service, err := lookupService(tx, db.TenantID(), req.ServiceID, req.ServiceName)
+		if err != nil {
+			return &catalogError{err}
+		}
+
+		// Category defaults to the catalog service category
+		category := service.Category
+		if req.Category != "" {
+			category = &req.Category
+		}
 
-	err = h.db.QueryRowx(query, req.ServiceName, req.Price, userID, startDate, endDate).StructScan(&subscription)
+		subscription = models.Subscription{
+			TenantID:    db.TenantID(),
+			ServiceName: service.Name,
+			ServiceID:   service.ID,
+			Price:       req.Price,
+			UserID:      userID,
+			StartDate:   startDate,
+			EndDate:     endDate,
+			Category:    category,
+			Tags:        tags,
+			SplitType:   models.SplitNone,
+		}
+
+		if err := rules.CheckSubscription(subscription, nil); err != nil {
+			return err
+		}
+		if err := rules.CheckOverlap(tx, subscription); err != nil {
+			return err
+		}
+
+		// Back the user ID with a user row
+		if err := ensureUser(tx, db.TenantID(), userID); err != nil {
+			return err
+		}
+
+		// Insert subscription
+		query := `
+			INSERT INTO subscriptions (tenant_id, service_name, service_id, price, user_id, start_date, end_date, category, tags)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING *`
+
+		var row records.Subscription
+		err = tx.Get(&row, query, db.TenantID(), service.Name, service.ID, req.Price, userID, startDate, endDate, category, pq.StringArray(tags))
+		if err != nil {
+			return err
+		}
+		subscription = row.Model()
+
+		if err := rollup.Apply(tx, nil, &subscription); err != nil {
+			return err
+		}
+		return outbox.Write(tx, models.EventSubscriptionCreated, subscription)
+	})
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to create subscription")
-		http.Error(w, "Failed to create subscription", http.StatusInternalServerError)
+		h.subscriptionWriteError(w, err, "Failed to create subscription")
 		return
 	}
 
@@ -107,20 +200,16 @@ func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.
 
 // GET /subscriptions/{id}
 func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
-	id, err := uuid.Parse(idStr)
+	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
 		h.logger.WithError(err).Error("Invalid subscription ID format")
 		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
 		return
 	}
 
-	var subscription models.Subscription
-	query := `SELECT * FROM subscriptions WHERE id = $1`
-	err = h.db.Get(&subscription, query, id)
+	subscription, err := h.service.Get(r.Context(), id)
 	if err != nil {
-		h.logger.WithError(err).Error("Subscription not found")
-		http.Error(w, "Subscription not found", http.StatusNotFound)
+		h.serviceError(w, err, "Failed to load subscription")
 		return
 	}
 
@@ -130,8 +219,7 @@ func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Req
 
 // PUT /subscriptions/{id}
 func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
-	id, err := uuid.Parse(idStr)
+	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
 		h.logger.WithError(err).Error("Invalid subscription ID format")
 		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
@@ -139,136 +227,225 @@ func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.
 	}
 
 	var req models.UpdateSubscriptionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.WithError(err).Error("Failed to decode request body")
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	if !h.updateSubscription(w, r, id, req) {
 		return
 	}
 
-	// Validate
+	w.WriteHeader(http.StatusOK)
+	h.logger.WithField("subscription_id", id).Info("Subscription updated successfully")
+}
+
+// updateSubscription validates and applies the update, writing the error
+// response and returning false when it fails
+func (h *SubscriptionHandler) updateSubscription(w http.ResponseWriter, r *http.Request, id uuid.UUID, req models.UpdateSubscriptionRequest) bool {
 	if err := validation.ValidateUpdateSubscription(req); err != nil {
 		h.logger.WithError(err).Error("Validation failed")
-		if validationErrors, ok := err.(validation.ValidationErrors); ok {
-			var errorMessages []string
-			for _, validationErr := range validationErrors {
-				errorMessages = append(errorMessages, validationErr.Message)
-			}
-			http.Error(w, strings.Join(errorMessages, "; "), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
-		return
+		writeValidationError(w, err)
+		return false
+	}
+
+	db := tenantDB(h.db, r)
+
+	if !h.authorizeExisting(w, r, db, authz.ActionUpdate, id) {
+		return false
 	}
 
-	// Build update query
+	// Build update query, and the same changes applied to the stored row
+	// so business rules can check the subscription as it will be saved
 	setParts := []string{}
 	args := []interface{}{}
 	argCount := 1
+	var patches []func(*models.Subscription)
+	periodChanged := false
 
-	if req.ServiceName != "" {
+	// The service is resolved in the transaction; its arguments are filled in there
+	serviceArg := -1
+	if req.ServiceID != "" || req.ServiceName != "" {
+		serviceArg = len(args)
 		setParts = append(setParts, fmt.Sprintf("service_name = $%d", argCount))
-		args = append(args, req.ServiceName)
 		argCount++
+		setParts = append(setParts, fmt.Sprintf("service_id = $%d", argCount))
+		argCount++
+		args = append(args, nil, nil)
+		periodChanged = true
 	}
 
-	if req.Price > 0 {
+	if req.Price != nil {
+		price := *req.Price
 		setParts = append(setParts, fmt.Sprintf("price = $%d", argCount))
-		args = append(args, req.Price)
+		args = append(args, price)
 		argCount++
+		patches = append(patches, func(s *models.Subscription) { s.Price = price })
 	}
 
 	if req.StartDate != "" {
-		startDate, err := validation.ParseMonthYear(req.StartDate)
+		startDate, err := validation.ParseDate(req.StartDate)
 		if err != nil {
 			h.logger.WithError(err).Error("Invalid start date format")
-			http.Error(w, "Invalid start date format (use MM-YYYY)", http.StatusBadRequest)
-			return
+			http.Error(w, "Invalid start date format (use MM-YYYY or YYYY-MM-DD)", http.StatusBadRequest)
+			return false
 		}
 		setParts = append(setParts, fmt.Sprintf("start_date = $%d", argCount))
 		args = append(args, startDate)
 		argCount++
+		patches = append(patches, func(s *models.Subscription) { s.StartDate = startDate })
+		periodChanged = true
 	}
 
 	if req.EndDate != "" {
 		if req.EndDate == "null" {
 			setParts = append(setParts, fmt.Sprintf("end_date = $%d", argCount))
 			args = append(args, nil)
+			patches = append(patches, func(s *models.Subscription) { s.EndDate = nil })
 		} else {
-			endDate, err := validation.ParseMonthYear(req.EndDate)
+			endDate, err := validation.ParseEndDate(req.EndDate)
 			if err != nil {
 				h.logger.WithError(err).Error("Invalid end date format")
-				http.Error(w, "Invalid end date format (use MM-YYYY)", http.StatusBadRequest)
-				return
+				http.Error(w, "Invalid end date format (use MM-YYYY or YYYY-MM-DD)", http.StatusBadRequest)
+				return false
 			}
 			setParts = append(setParts, fmt.Sprintf("end_date = $%d", argCount))
 			args = append(args, endDate)
+			patches = append(patches, func(s *models.Subscription) { s.EndDate = &endDate })
 		}
 		argCount++
+		periodChanged = true
+	}
+
+	if req.Category == "null" {
+		setParts = append(setParts, "category = NULL")
+	} else if req.Category != "" {
+		setParts = append(setParts, fmt.Sprintf("category = $%d", argCount))
+		args = append(args, req.Category)
+		argCount++
+	}
+
+	if req.Tags != nil {
+		setParts = append(setParts, fmt.Sprintf("tags = $%d", argCount))
+		args = append(args, pq.StringArray(validation.NormalizeTags(req.Tags)))
+		argCount++
 	}
 
 	if len(setParts) == 0 {
 		http.Error(w, "No fields to update", http.StatusBadRequest)
-		return
+		return false
 	}
 
 	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argCount))
 	args = append(args, time.Now())
 	argCount++
 
-	args = append(args, id)
-	query := fmt.Sprintf("UPDATE subscriptions SET %s WHERE id = $%d", strings.Join(setParts, ", "), argCount)
+	args = append(args, db.TenantID(), id)
+	query := fmt.Sprintf("UPDATE subscriptions SET %s WHERE tenant_id = $%d AND id = $%d",
+		strings.Join(setParts, ", "), argCount, argCount+1)
 
-	result, err := h.db.Exec(query, args...)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to update subscription")
-		http.Error(w, "Failed to update subscription", http.StatusInternalServerError)
-		return
-	}
+	err := db.Transact(func(tx *sqlx.Tx) error {
+		var row records.Subscription
+		err := tx.Get(&row, `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2 FOR UPDATE`,
+			db.TenantID(), id)
+		if err != nil {
+			return err
+		}
+		subscription := row.Model()
+		before := subscription
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to get rows affected")
-		http.Error(w, "Failed to update subscription", http.StatusInternalServerError)
-		return
-	}
+		if serviceArg >= 0 {
+			service, err := lookupService(tx, db.TenantID(), req.ServiceID, req.ServiceName)
+			if err != nil {
+				return &catalogError{err}
+			}
+			args[serviceArg], args[serviceArg+1] = service.Name, service.ID
+			subscription.ServiceName, subscription.ServiceID = service.Name, service.ID
+		}
 
-	if rowsAffected == 0 {
-		http.Error(w, "Subscription not found", http.StatusNotFound)
-		return
+		for _, patch := range patches {
+			patch(&subscription)
+		}
+
+		shares, err := subscriptions.Shares(tx, db.TenantID(), []uuid.UUID{id})
+		if err != nil {
+			return err
+		}
+		if err := rules.CheckSubscription(subscription, shares[id]); err != nil {
+			return err
+		}
+
+		// Rows that already overlapped before overlap checks existed stay
+		// editable as long as their service and period are left alone
+		if periodChanged {
+			if err := rules.CheckOverlap(tx, subscription); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+
+		err = tx.Get(&row, `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+		if err != nil {
+			return err
+		}
+		subscription = row.Model()
+
+		if err := rollup.Apply(tx, &before, &subscription); err != nil {
+			return err
+		}
+		return outbox.Write(tx, models.EventSubscriptionUpdated, subscription)
+	})
+	if err != nil {
+		h.subscriptionWriteError(w, err, "Failed to update subscription")
+		return false
 	}
 
-	w.WriteHeader(http.StatusOK)
-	h.logger.WithField("subscription_id", id).Info("Subscription updated successfully")
+	return true
 }
 
 // DELETE /subscriptions/{id}
 func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
-	id, err := uuid.Parse(idStr)
+	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
 		h.logger.WithError(err).Error("Invalid subscription ID format")
 		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
 		return
 	}
 
-	result, err := h.db.Exec("DELETE FROM subscriptions WHERE id = $1", id)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to delete subscription")
-		http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
-		return
-	}
+	db := tenantDB(h.db, r)
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to get rows affected")
-		http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
+	if !h.authorizeExisting(w, r, db, authz.ActionDelete, id) {
 		return
 	}
 
-	if rowsAffected == 0 {
+	err = db.Transact(func(tx *sqlx.Tx) error {
+		var row records.Subscription
+		err := tx.Get(&row, "SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2 FOR UPDATE", db.TenantID(), id)
+		if err != nil {
+			return err
+		}
+		subscription := row.Model()
+
+		// The rollup prices the months with the past prices the delete removes
+		if err := rollup.Apply(tx, &subscription, nil); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM subscriptions WHERE tenant_id = $1 AND id = $2", db.TenantID(), id); err != nil {
+			return err
+		}
+		return outbox.Write(tx, models.EventSubscriptionDeleted, subscription)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
 		http.Error(w, "Subscription not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to delete subscription")
+		http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 	h.logger.WithField("subscription_id", id).Info("Subscription deleted successfully")
@@ -276,151 +453,226 @@ func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.
 
 // GET /subscriptions
 func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
-	query := "SELECT * FROM subscriptions"
-	conditions := []string{}
-	args := []interface{}{}
-	argCount := 0
+	subscriptions, ok := h.listSubscriptions(w, r)
+	if !ok {
+		return
+	}
 
-	// Parse query parameters
-	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+// listSubscriptions queries subscriptions matching the query parameters,
+// writing the error response and returning false when it fails
+func (h *SubscriptionHandler) listSubscriptions(w http.ResponseWriter, r *http.Request) ([]models.Subscription, bool) {
+	var filter models.SubscriptionFilter
+	query := r.URL.Query()
+
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
 			h.logger.WithError(err).Error("Invalid user ID format")
 			http.Error(w, "Invalid user ID format", http.StatusBadRequest)
-			return
+			return nil, false
 		}
-		argCount++
-		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argCount))
-		args = append(args, userID)
+		filter.UserID = &userID
 	}
 
-	if serviceName := r.URL.Query().Get("service_name"); serviceName != "" {
-		argCount++
-		conditions = append(conditions, fmt.Sprintf("service_name ILIKE $%d", argCount))
-		args = append(args, "%"+serviceName+"%")
+	if serviceIDStr := query.Get("service_id"); serviceIDStr != "" {
+		serviceID, err := uuid.Parse(serviceIDStr)
+		if err != nil {
+			h.logger.WithError(err).Error("Invalid service ID format")
+			http.Error(w, "Invalid service ID format", http.StatusBadRequest)
+			return nil, false
+		}
+		filter.ServiceID = &serviceID
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if serviceName := query.Get("service_name"); serviceName != "" {
+		filter.ServiceName = &serviceName
 	}
 
-	// Add ordering
-	query += " ORDER BY created_at DESC"
-
-	// Add pagination
-	limit := 100 // default limit
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
-			limit = parsedLimit
-		}
+	if category := query.Get("category"); category != "" {
+		filter.Category = &category
 	}
-	query += fmt.Sprintf(" LIMIT %d", limit)
 
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-			query += fmt.Sprintf(" OFFSET %d", offset)
-		}
-	}
+	filter.Tags = query["tag"]
+
+	// Invalid pagination parameters fall back to the defaults
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
 
-	var subscriptions []models.Subscription
-	err := h.db.Select(&subscriptions, query, args...)
+	subscriptions, err := h.service.List(r.Context(), filter, limit, offset)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to list subscriptions")
-		http.Error(w, "Failed to list subscriptions", http.StatusInternalServerError)
-		return
+		h.serviceError(w, err, "Failed to list subscriptions")
+		return nil, false
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(subscriptions)
+	return subscriptions, true
 }
 
 // POST /subscriptions/aggregate
 func (h *SubscriptionHandler) AggregateSubscriptions(w http.ResponseWriter, r *http.Request) {
-	var req models.AggregationRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.WithError(err).Error("Failed to decode request body")
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	response, ok := h.aggregate(w, r, false)
+	if !ok {
 		return
 	}
 
-	// Validate request
-	if err := validation.ValidateAggregationRequest(req); err != nil {
-		h.logger.WithError(err).Error("Validation failed")
-		if validationErrors, ok := err.(validation.ValidationErrors); ok {
-			var errorMessages []string
-			for _, validationErr := range validationErrors {
-				errorMessages = append(errorMessages, validationErr.Message)
-			}
-			http.Error(w, strings.Join(errorMessages, "; "), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
-		return
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// aggregate computes the aggregation requested in the body, writing the
+// error response and returning false when it fails
+func (h *SubscriptionHandler) aggregate(w http.ResponseWriter, r *http.Request, monthly bool) (*models.AggregationResponse, bool) {
+	var req models.AggregationRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return nil, false
 	}
 
-	// Parse dates
-	startDate, err := validation.ParseMonthYear(req.StartDate)
+	response, err := h.service.Aggregate(r.Context(), req, monthly)
 	if err != nil {
-		h.logger.WithError(err).Error("Invalid start date format")
-		http.Error(w, "Invalid start date format (use MM-YYYY)", http.StatusBadRequest)
+		h.serviceError(w, err, "Failed to aggregate subscriptions")
+		return nil, false
+	}
+
+	return response, true
+}
+
+// POST /subscriptions/forecast
+func (h *SubscriptionHandler) ForecastSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req models.ForecastRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
 		return
 	}
 
-	endDate, err := validation.ParseMonthYear(req.EndDate)
+	response, err := h.service.Forecast(r.Context(), req)
 	if err != nil {
-		h.logger.WithError(err).Error("Invalid end date format")
-		http.Error(w, "Invalid end date format (use MM-YYYY)", http.StatusBadRequest)
+		h.serviceError(w, err, "Failed to forecast subscriptions")
 		return
 	}
 
-	// Build query
-	query := `
-		SELECT COALESCE(SUM(price), 0) as total_cost
-		FROM subscriptions
-		WHERE start_date >= $1 AND (end_date IS NULL OR end_date <= $2)`
-
-	args := []interface{}{startDate, endDate}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-	argCount := 2
-	if req.UserID != nil {
-		argCount++
-		query += fmt.Sprintf(" AND user_id = $%d", argCount)
-		args = append(args, req.UserID)
+// GET /subscriptions/anomalies
+func (h *SubscriptionHandler) ListAnomalies(w http.ResponseWriter, r *http.Request) {
+	var userID *uuid.UUID
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		id, err := uuid.Parse(userIDStr)
+		if err != nil {
+			h.logger.WithError(err).Error("Invalid user ID format")
+			http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+			return
+		}
+		userID = &id
 	}
 
-	if req.ServiceName != nil {
-		argCount++
-		query += fmt.Sprintf(" AND service_name ILIKE $%d", argCount)
-		args = append(args, "%"+*req.ServiceName+"%")
+	report, err := h.service.Anomalies(r.Context(), userID)
+	if err != nil {
+		h.serviceError(w, err, "Failed to check subscriptions")
+		return
 	}
 
-	var result struct {
-		TotalCost int64 `db:"total_cost"`
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// lookupService finds the catalog service by ID, or resolves it by name
+// registering unknown names in the catalog
+func lookupService(q database.Querier, tenantID uuid.UUID, serviceID, serviceName string) (*records.Service, error) {
+	if serviceID == "" {
+		return resolveService(q, tenantID, serviceName)
 	}
 
-	err = h.db.Get(&result, query, args...)
+	id, err := uuid.Parse(serviceID)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to aggregate subscriptions")
-		http.Error(w, "Failed to aggregate subscriptions", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	response := models.AggregationResponse{
-		TotalCost: result.TotalCost,
-		Period:    fmt.Sprintf("%s to %s", req.StartDate, req.EndDate),
-		UserID:    req.UserID,
+	var service records.Service
+	if err := q.Get(&service, `SELECT * FROM services WHERE tenant_id = $1 AND id = $2`, tenantID, id); err != nil {
+		return nil, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	return &service, nil
+}
 
-	h.logger.WithFields(logrus.Fields{
-		"total_cost": result.TotalCost,
-		"period":     response.Period,
-	}).Info("Subscription aggregation completed")
+// catalogError is a failed catalog lookup within a subscription write
+type catalogError struct {
+	err error
+}
+
+func (e *catalogError) Error() string {
+	return e.err.Error()
+}
+
+func (e *catalogError) Unwrap() error {
+	return e.err
+}
+
+// serviceLookupError writes the response for a failed catalog lookup
+func (h *SubscriptionHandler) serviceLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		h.logger.WithError(err).Error("Service not found in catalog")
+		http.Error(w, "Service not found", http.StatusBadRequest)
+		return
+	}
+
+	h.logger.WithError(err).Error("Failed to resolve service")
+	http.Error(w, "Failed to resolve service", http.StatusInternalServerError)
+}
+
+// subscriptionWriteError maps subscription write errors to HTTP responses
+func (h *SubscriptionHandler) subscriptionWriteError(w http.ResponseWriter, err error, message string) {
+	var violation *rules.Violation
+	var overlap *rules.OverlapError
+	var catalogErr *catalogError
+	var pqErr *pq.Error
+
+	switch {
+	case errors.As(err, &catalogErr):
+		h.serviceLookupError(w, catalogErr.err)
+	case errors.As(err, &violation):
+		h.logger.WithField("rule", violation.Rule).WithError(err).Warn("Subscription rule violated")
+		http.Error(w, violation.Message, http.StatusUnprocessableEntity)
+	case errors.As(err, &overlap):
+		h.logger.WithField("existing_id", overlap.Existing.ID).WithError(err).Warn("Overlapping subscription")
+		http.Error(w, overlap.Error(), http.StatusConflict)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+	case errors.As(err, &pqErr) && pqErr.Code == pqCheckViolation:
+		h.logger.WithError(err).Warn(message)
+		http.Error(w, "Subscription violates constraint "+pqErr.Constraint, http.StatusUnprocessableEntity)
+	default:
+		h.logger.WithError(err).Error(message)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
 }
 
+// serviceError maps errors of the subscription service to HTTP responses
+func (h *SubscriptionHandler) serviceError(w http.ResponseWriter, err error, message string) {
+	var denied *authz.DeniedError
+	var validationErrs validation.ValidationErrors
+	var inputErr *subscriptions.InputError
 
+	switch {
+	case errors.As(err, &denied):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.As(err, &validationErrs):
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+	case errors.As(err, &inputErr):
+		h.logger.WithError(err).Error(inputErr.Message)
+		http.Error(w, inputErr.Message, http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		h.logger.WithError(err).Error("Subscription not found")
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+	default:
+		h.logger.WithError(err).Error(message)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
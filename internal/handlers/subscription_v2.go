@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// SubscriptionV2 adapts SubscriptionHandler to the breaking changes of the
+// v2 API. Responses are wrapped into envelopes by the envelope middleware.
+type SubscriptionV2 struct {
+	h *SubscriptionHandler
+}
+
+func NewSubscriptionV2(h *SubscriptionHandler) *SubscriptionV2 {
+	return &SubscriptionV2{h: h}
+}
+
+// GET /v2/subscriptions
+func (v *SubscriptionV2) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, ok := v.h.listSubscriptions(w, r)
+	if !ok {
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []models.Subscription{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+// PATCH /v2/subscriptions/{id}
+func (v *SubscriptionV2) PatchSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		v.h.logger.WithError(err).Error("Invalid subscription ID format")
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return
+	}
+
+	data, err := validation.ReadBody(r.Body)
+	if err != nil {
+		v.h.logger.WithError(err).Error("Failed to read request body")
+		if errors.Is(err, validation.ErrBodyTooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	var patch models.SubscriptionPatch
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if !decodeJSON(w, r, v.h.logger, &patch) {
+		return
+	}
+
+	req := mergePatchRequest(patch, data)
+
+	if !v.h.updateSubscription(w, r, id, req) {
+		return
+	}
+
+	v.h.logger.WithField("subscription_id", id).Info("Subscription patched successfully")
+
+	// Answer with the subscription as saved
+	v.h.GetSubscription(w, r)
+}
+
+// POST /v2/subscriptions/aggregate
+func (v *SubscriptionV2) AggregateSubscriptions(w http.ResponseWriter, r *http.Request) {
+	response, ok := v.h.aggregate(w, r, true)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// mergePatchRequest turns a merge patch into the update it stands for.
+// Absent fields are left alone, including price, while "price": 0 sets it.
+func mergePatchRequest(patch models.SubscriptionPatch, data []byte) models.UpdateSubscriptionRequest {
+	// Nulls decode like absent fields, tell them apart on the raw object
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+
+	req := models.UpdateSubscriptionRequest{
+		ServiceName: patch.ServiceName,
+		ServiceID:   patch.ServiceID,
+		Price:       patch.Price,
+		StartDate:   patch.StartDate,
+		Tags:        patch.Tags,
+	}
+
+	if patch.EndDate != nil {
+		req.EndDate = *patch.EndDate
+	} else if isNull(fields["end_date"]) {
+		req.EndDate = "null"
+	}
+
+	if patch.Category != nil {
+		req.Category = *patch.Category
+	} else if isNull(fields["category"]) {
+		req.Category = "null"
+	}
+
+	if isNull(fields["tags"]) {
+		req.Tags = []string{}
+	}
+
+	return req
+}
+
+func isNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
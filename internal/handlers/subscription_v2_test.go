@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"strings"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+	"testing"
+)
+
+func TestMergePatchRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		check    func(req models.UpdateSubscriptionRequest) bool
+		describe string
+	}{
+		{
+			name:     "price zero",
+			body:     `{"price": 0}`,
+			check:    func(req models.UpdateSubscriptionRequest) bool { return req.Price != nil && *req.Price == 0 },
+			describe: "price set to 0",
+		},
+		{
+			name:     "price",
+			body:     `{"price": 499}`,
+			check:    func(req models.UpdateSubscriptionRequest) bool { return req.Price != nil && *req.Price == 499 },
+			describe: "price set to 499",
+		},
+		{
+			name: "absent price",
+			body: `{"service_name": "Netflix"}`,
+			check: func(req models.UpdateSubscriptionRequest) bool {
+				return req.Price == nil && req.ServiceName == "Netflix"
+			},
+			describe: "price left alone",
+		},
+		{
+			name: "null end date, category and tags",
+			body: `{"end_date": null, "category": null, "tags": null}`,
+			check: func(req models.UpdateSubscriptionRequest) bool {
+				return req.EndDate == "null" && req.Category == "null" && req.Tags != nil && len(req.Tags) == 0
+			},
+			describe: "end date, category and tags removed",
+		},
+		{
+			name: "end date and category",
+			body: `{"end_date": "12-2025", "category": "music"}`,
+			check: func(req models.UpdateSubscriptionRequest) bool {
+				return req.EndDate == "12-2025" && req.Category == "music" && req.Tags == nil
+			},
+			describe: "end date and category set, tags left alone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch models.SubscriptionPatch
+			if err := validation.DecodeJSON(strings.NewReader(tt.body), &patch); err != nil {
+				t.Fatalf("DecodeJSON() error = %v", err)
+			}
+
+			if req := mergePatchRequest(patch, []byte(tt.body)); !tt.check(req) {
+				t.Errorf("mergePatchRequest(%s) = %+v, want %s", tt.body, req, tt.describe)
+			}
+		})
+	}
+}
+
+func TestUpdateRequestPrice(t *testing.T) {
+	var req models.UpdateSubscriptionRequest
+	if err := validation.DecodeJSON(strings.NewReader(`{"price": 0}`), &req); err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	if req.Price == nil || *req.Price != 0 {
+		t.Errorf("price = %v, want 0", req.Price)
+	}
+	if err := validation.ValidateUpdateSubscription(req); err != nil {
+		t.Errorf("ValidateUpdateSubscription() error = %v", err)
+	}
+
+	negative := -1
+	if err := validation.ValidateUpdateSubscription(models.UpdateSubscriptionRequest{Price: &negative}); err == nil {
+		t.Error("ValidateUpdateSubscription() accepted a negative price")
+	}
+}
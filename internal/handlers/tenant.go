@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/tenant"
+)
+
+// tenantDB returns the database handle bound to the request tenant. Requests
+// without a resolved tenant get the nil tenant, which owns no rows.
+func tenantDB(db *database.DB, r *http.Request) *database.TenantDB {
+	tenantID, _ := tenant.FromContext(r.Context())
+	return db.Tenant(tenantID)
+}
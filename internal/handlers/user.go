@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type UserHandler struct {
+	db      *database.DB
+	service *subscriptions.Service
+	logger  *logrus.Logger
+}
+
+func NewUserHandler(db *database.DB, service *subscriptions.Service, logger *logrus.Logger) *UserHandler {
+	return &UserHandler{
+		db:      db,
+		service: service,
+		logger:  logger,
+	}
+}
+
+// POST /users
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.service, authz.ActionCreate) {
+		return
+	}
+
+	var req models.CreateUserRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate request
+	if err := validation.ValidateCreateUser(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	id := uuid.New()
+	if req.ID != "" {
+		id = uuid.MustParse(req.ID)
+	}
+
+	db := tenantDB(h.db, r)
+
+	var user records.User
+	query := `
+		INSERT INTO users (tenant_id, id, name, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *`
+
+	err := db.Get(&user, query, db.TenantID(), id, req.Name, req.Email)
+	if err != nil {
+		h.userWriteError(w, err, "Failed to create user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user.Model())
+
+	h.logger.WithField("user_id", user.ID).Info("User created successfully")
+}
+
+// GET /users/{id}
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID format")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !authorizeUser(w, r, h.service, authz.ActionRead, &id) {
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var user records.User
+	err = db.Get(&user, `SELECT * FROM users WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+	if err != nil {
+		h.logger.WithError(err).Error("User not found")
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.Model())
+}
+
+// GET /users
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if !authorizeUser(w, r, h.service, authz.ActionList, nil) {
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	// Regular users only see themselves
+	query := `SELECT * FROM users WHERE tenant_id = $1`
+	args := []interface{}{db.TenantID()}
+	if scope := h.service.OwnerScope(r.Context()); scope != nil {
+		query += ` AND id = $2`
+		args = append(args, *scope)
+	}
+
+	users := []records.User{}
+	err := db.Select(&users, query+` ORDER BY created_at DESC`, args...)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list users")
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records.Models[models.User](users))
+}
+
+// PUT /users/{id}
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.service, authz.ActionUpdate) {
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID format")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate
+	if err := validation.ValidateUpdateUser(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	// Build update query
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.Name != nil {
+		setParts = append(setParts, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, req.Name)
+		argCount++
+	}
+
+	if req.Email != nil {
+		setParts = append(setParts, fmt.Sprintf("email = $%d", argCount))
+		args = append(args, req.Email)
+		argCount++
+	}
+
+	if len(setParts) == 0 {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argCount))
+	args = append(args, time.Now())
+	argCount++
+
+	db := tenantDB(h.db, r)
+
+	args = append(args, db.TenantID(), id)
+	query := fmt.Sprintf("UPDATE users SET %s WHERE tenant_id = $%d AND id = $%d",
+		strings.Join(setParts, ", "), argCount, argCount+1)
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		h.userWriteError(w, err, "Failed to update user")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	h.logger.WithField("user_id", id).Info("User updated successfully")
+}
+
+// DELETE /users/{id}
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, h.service, authz.ActionDelete) {
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID format")
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	result, err := db.Exec("DELETE FROM users WHERE tenant_id = $1 AND id = $2", db.TenantID(), id)
+	if err != nil {
+		h.userWriteError(w, err, "Failed to delete user")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithField("user_id", id).Info("User deleted successfully")
+}
+
+// userWriteError maps user errors to HTTP responses
+func (h *UserHandler) userWriteError(w http.ResponseWriter, err error, message string) {
+	h.logger.WithError(err).Error(message)
+
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation:
+		http.Error(w, "User with this ID or email already exists", http.StatusConflict)
+	case errors.As(err, &pqErr) && pqErr.Code == pqForeignKeyViolation:
+		http.Error(w, "User owns subscriptions", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// ensureUser registers a bare user row for IDs the tenant does not know yet,
+// so clients may keep sending arbitrary user_id values
+func ensureUser(db database.Querier, tenantID, id uuid.UUID) error {
+	_, err := db.Exec(`
+		INSERT INTO users (tenant_id, id) VALUES ($1, $2)
+		ON CONFLICT (tenant_id, id) DO NOTHING`, tenantID, id)
+	return err
+}
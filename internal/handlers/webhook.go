@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/internal/webhooks"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Page sizes of the delivery list
+const (
+	defaultDeliveryLimit = 100
+	maxDeliveryLimit     = 1000
+)
+
+type WebhookHandler struct {
+	db     *database.DB
+	logger *logrus.Logger
+}
+
+func NewWebhookHandler(db *database.DB, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// POST /webhooks
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate request
+	if err := validation.ValidateCreateWebhook(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhooks.GenerateSecret(); err != nil {
+			h.logger.WithError(err).Error("Failed to generate webhook secret")
+			http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	db := tenantDB(h.db, r)
+
+	var row records.Webhook
+	query := `
+		INSERT INTO webhooks (tenant_id, url, secret, event_types)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *`
+
+	err := db.Get(&row, query, db.TenantID(), req.URL, secret, pq.StringArray(uniqueStrings(req.EventTypes)))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to create webhook")
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+	created := models.CreatedWebhook{Webhook: row.Model(), Secret: secret}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+
+	h.logger.WithField("webhook_id", created.ID).Info("Webhook created successfully")
+}
+
+// GET /webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	db := tenantDB(h.db, r)
+
+	list := []records.Webhook{}
+	err := db.Select(&list, `SELECT * FROM webhooks WHERE tenant_id = $1 ORDER BY created_at DESC`, db.TenantID())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list webhooks")
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records.Models[models.Webhook](list))
+}
+
+// GET /webhooks/{id}
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var webhook records.Webhook
+	err := db.Get(&webhook, `SELECT * FROM webhooks WHERE tenant_id = $1 AND id = $2`, db.TenantID(), id)
+	if err != nil {
+		h.webhookError(w, err, "Failed to load webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook.Model())
+}
+
+// PUT /webhooks/{id}
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if !decodeJSON(w, r, h.logger, &req) {
+		return
+	}
+
+	// Validate
+	if err := validation.ValidateUpdateWebhook(req); err != nil {
+		h.logger.WithError(err).Error("Validation failed")
+		writeValidationError(w, err)
+		return
+	}
+
+	// Build update query
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 1
+
+	if req.URL != nil {
+		setParts = append(setParts, fmt.Sprintf("url = $%d", argCount))
+		args = append(args, *req.URL)
+		argCount++
+	}
+
+	if req.EventTypes != nil {
+		setParts = append(setParts, fmt.Sprintf("event_types = $%d", argCount))
+		args = append(args, pq.StringArray(uniqueStrings(req.EventTypes)))
+		argCount++
+	}
+
+	if req.Active != nil {
+		setParts = append(setParts, fmt.Sprintf("active = $%d", argCount))
+		args = append(args, *req.Active)
+		argCount++
+	}
+
+	if len(setParts) == 0 {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argCount))
+	args = append(args, time.Now())
+	argCount++
+
+	db := tenantDB(h.db, r)
+
+	args = append(args, db.TenantID(), id)
+	query := fmt.Sprintf("UPDATE webhooks SET %s WHERE tenant_id = $%d AND id = $%d RETURNING *",
+		strings.Join(setParts, ", "), argCount, argCount+1)
+
+	var webhook records.Webhook
+	if err := db.Get(&webhook, query, args...); err != nil {
+		h.webhookError(w, err, "Failed to update webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook.Model())
+
+	h.logger.WithField("webhook_id", id).Info("Webhook updated successfully")
+}
+
+// DELETE /webhooks/{id}
+// Deliveries of the webhook are deleted with it
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	result, err := db.Exec("DELETE FROM webhooks WHERE tenant_id = $1 AND id = $2", db.TenantID(), id)
+	if err != nil {
+		h.webhookError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get rows affected")
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	h.logger.WithField("webhook_id", id).Info("Webhook deleted successfully")
+}
+
+// GET /webhooks/{id}/deliveries
+// status=failed lists the dead letters of the webhook
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status != "" && status != models.DeliveryPending && status != models.DeliveryDelivered && status != models.DeliveryFailed {
+		http.Error(w, "Invalid delivery status", http.StatusBadRequest)
+		return
+	}
+
+	// Invalid pagination parameters fall back to the defaults
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 || limit > maxDeliveryLimit {
+		limit = defaultDeliveryLimit
+	}
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	db := tenantDB(h.db, r)
+
+	var exists bool
+	err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM webhooks WHERE tenant_id = $1 AND id = $2)`, db.TenantID(), id)
+	if err != nil {
+		h.webhookError(w, err, "Failed to list deliveries")
+		return
+	}
+	if !exists {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	deliveries := []records.WebhookDelivery{}
+	err = db.Select(&deliveries, `
+		SELECT * FROM webhook_deliveries
+		WHERE tenant_id = $1 AND webhook_id = $2 AND ($3::text = '' OR status = $3)
+		ORDER BY created_at DESC, id
+		LIMIT $4 OFFSET $5`, db.TenantID(), id, status, limit, offset)
+	if err != nil {
+		h.webhookError(w, err, "Failed to list deliveries")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records.Models[models.WebhookDelivery](deliveries))
+}
+
+// POST /webhooks/{id}/deliveries/{delivery_id}/redeliver
+// Queues the delivery for an immediate attempt with a fresh attempt budget
+func (h *WebhookHandler) RedeliverDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(mux.Vars(r)["delivery_id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid delivery ID format")
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	db := tenantDB(h.db, r)
+
+	var delivery records.WebhookDelivery
+	err = db.Get(&delivery, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		WHERE tenant_id = $2 AND webhook_id = $3 AND id = $4
+		RETURNING *`, models.DeliveryPending, db.TenantID(), id, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.webhookError(w, err, "Failed to redeliver")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery.Model())
+
+	h.logger.WithFields(logrus.Fields{
+		"webhook_id":  id,
+		"delivery_id": deliveryID,
+	}).Info("Webhook delivery queued again")
+}
+
+// webhookID parses the webhook ID of the path, answering 400 when it is
+// malformed
+func (h *WebhookHandler) webhookID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid webhook ID format")
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// webhookError maps webhook errors to HTTP responses
+func (h *WebhookHandler) webhookError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	h.logger.WithError(err).Error(message)
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// uniqueStrings drops repeated values, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
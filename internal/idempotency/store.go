@@ -0,0 +1,127 @@
+package idempotency
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TTL is how long responses are kept for replay
+const TTL = 24 * time.Hour
+
+// cleanupInterval is how often expired keys are deleted
+const cleanupInterval = 10 * time.Minute
+
+var (
+	// ErrInProgress means the first request with the key has not finished
+	ErrInProgress = errors.New("request with this idempotency key is in progress")
+	// ErrMismatch means the key was used for a different request
+	ErrMismatch = errors.New("idempotency key was used for a different request")
+)
+
+// Response is a stored response
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Keys claims idempotency keys and keeps the responses of their requests
+type Keys interface {
+	Begin(key, fingerprint string) (*Response, error)
+	Complete(key string, resp Response) error
+	Release(key string) error
+}
+
+// Store keeps responses in Postgres so retries reaching another replica are
+// answered as well
+type Store struct {
+	db *sqlx.DB
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+// Begin claims the key for a request with the fingerprint. It returns nil
+// when the request should run, the stored response when it already ran, or
+// ErrInProgress or ErrMismatch.
+func (s *Store) Begin(key, fingerprint string) (*Response, error) {
+	s.cleanup()
+
+	// Expired keys are claimed anew
+	var claimed string
+	err := s.db.Get(&claimed, `
+		INSERT INTO idempotency_keys AS k (key, fingerprint)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status = NULL,
+			content_type = NULL,
+			body = NULL,
+			created_at = NOW()
+		WHERE k.created_at < NOW() - $3 * INTERVAL '1 second'
+		RETURNING key`, key, fingerprint, TTL.Seconds())
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var row struct {
+		Fingerprint string         `db:"fingerprint"`
+		Status      sql.NullInt64  `db:"status"`
+		ContentType sql.NullString `db:"content_type"`
+		Body        []byte         `db:"body"`
+	}
+	err = s.db.Get(&row, `SELECT fingerprint, status, content_type, body FROM idempotency_keys WHERE key = $1`, key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case row.Fingerprint != fingerprint:
+		return nil, ErrMismatch
+	case !row.Status.Valid:
+		return nil, ErrInProgress
+	}
+
+	return &Response{
+		Status:      int(row.Status.Int64),
+		ContentType: row.ContentType.String,
+		Body:        row.Body,
+	}, nil
+}
+
+// Complete stores the response of the request that claimed the key
+func (s *Store) Complete(key string, resp Response) error {
+	_, err := s.db.Exec(`UPDATE idempotency_keys SET status = $2, content_type = $3, body = $4 WHERE key = $1`,
+		key, resp.Status, resp.ContentType, resp.Body)
+	return err
+}
+
+// Release drops the key so the request may be retried
+func (s *Store) Release(key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE key = $1`, key)
+	return err
+}
+
+// cleanup deletes expired keys
+func (s *Store) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.lastCleanup) < cleanupInterval {
+		return
+	}
+	s.lastCleanup = time.Now()
+
+	go s.db.Exec(`DELETE FROM idempotency_keys WHERE created_at < NOW() - $1 * INTERVAL '1 second'`, TTL.Seconds())
+}
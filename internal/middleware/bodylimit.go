@@ -0,0 +1,25 @@
+package middleware
+
+import "net/http"
+
+// BodyLimitMiddleware caps request bodies at the limit of the matched route
+// in any API version, falling back to defaultLimit. Bodies declared larger are rejected with 413
+// right away; others fail with 413 once decoding reads past the limit.
+func BodyLimitMiddleware(defaultLimit int64, routes map[string]int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, ok := routes[apiRoute(r)]
+			if !ok {
+				limit = defaultLimit
+			}
+
+			if r.ContentLength > limit {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"subscription-aggregator/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(BodyLimitMiddleware(32, map[string]int64{"/subscriptions/aggregate": 8}))
+
+	read := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := validation.ReadBody(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	router.HandleFunc("/subscriptions/aggregate", read)
+	router.HandleFunc("/v2/subscriptions/aggregate", read)
+	router.HandleFunc("/subscriptions", read)
+
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		unknownLength bool
+		want          int
+	}{
+		{name: "within the default", path: "/subscriptions", body: strings.Repeat("x", 32), want: http.StatusOK},
+		{name: "over the default", path: "/subscriptions", body: strings.Repeat("x", 33), want: http.StatusRequestEntityTooLarge},
+		{name: "within the route limit", path: "/subscriptions/aggregate", body: "12345678", want: http.StatusOK},
+		{name: "over the route limit", path: "/subscriptions/aggregate", body: "123456789", want: http.StatusRequestEntityTooLarge},
+		{name: "route limit in another version", path: "/v2/subscriptions/aggregate", body: "123456789", want: http.StatusRequestEntityTooLarge},
+		{name: "undeclared length over the limit", path: "/subscriptions/aggregate", body: "123456789", unknownLength: true, want: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if tt.unknownLength {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/idempotency"
+	"subscription-aggregator/internal/tenant"
+	"subscription-aggregator/internal/validation"
+
+	"github.com/sirupsen/logrus"
+)
+
+// IdempotencyHeader carries the key a client picks for a POST or PATCH
+const IdempotencyHeader = "Idempotency-Key"
+
+// MaxIdempotencyKeyLength bounds the keys clients may send
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware lets clients retry POST and PATCH requests safely.
+// The response to the first request with an Idempotency-Key is stored and
+// replayed for repeats by the same client of the same tenant; reusing the
+// key for another request answers 422, repeating it while the first one
+// runs answers 409. Server errors and panics are not stored, so such
+// requests may be retried. The request is refused with 503 when the store
+// fails, since running it unguarded could apply it twice.
+func IdempotencyMiddleware(store idempotency.Keys, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > MaxIdempotencyKeyLength {
+				http.Error(w, "Idempotency key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := validation.ReadBody(r.Body)
+			if err != nil {
+				if errors.Is(err, validation.ErrBodyTooLarge) {
+					http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				logger.WithError(err).Error("Failed to read request body")
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := idempotencyStoreKey(idempotencyScope(r), key)
+			stored, err := store.Begin(storeKey, fingerprint(r, body))
+			switch {
+			case errors.Is(err, idempotency.ErrInProgress):
+				http.Error(w, "Request with this idempotency key is in progress", http.StatusConflict)
+				return
+			case errors.Is(err, idempotency.ErrMismatch):
+				http.Error(w, "Idempotency key was used for a different request", http.StatusUnprocessableEntity)
+				return
+			case err != nil:
+				logger.WithError(err).Error("Idempotency store failed")
+				http.Error(w, "Idempotency store is unavailable", http.StatusServiceUnavailable)
+				return
+			case stored != nil:
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+
+			// A panicking handler leaves no response, so the key is released
+			// for a retry before the panic goes on
+			finished := false
+			defer func() {
+				if !finished {
+					if err := store.Release(storeKey); err != nil {
+						logger.WithError(err).Error("Idempotency store failed")
+					}
+				}
+			}()
+
+			rec := &recordingWriter{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			finished = true
+
+			if rec.status >= http.StatusInternalServerError {
+				err = store.Release(storeKey)
+			} else {
+				err = store.Complete(storeKey, idempotency.Response{
+					Status:      rec.status,
+					ContentType: rec.header.Get("Content-Type"),
+					Body:        rec.body.Bytes(),
+				})
+			}
+			if err != nil {
+				logger.WithError(err).Error("Idempotency store failed")
+			}
+
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// idempotencyScope keeps keys of different tenants and clients apart
+func idempotencyScope(r *http.Request) string {
+	tenantID, _ := tenant.FromContext(r.Context())
+	scope := tenantID.String()
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		scope += ":" + principal.Subject
+	}
+	return scope
+}
+
+// idempotencyStoreKey hashes the scope and the client's key, since
+// together they may exceed the length of the key column
+func idempotencyStoreKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(scope + "|" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprint identifies the request a key was first used for
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
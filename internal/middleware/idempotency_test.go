@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"subscription-aggregator/internal/idempotency"
+)
+
+// memoryKeys keeps idempotency keys in memory the way the Postgres store does
+type memoryKeys struct {
+	mu        sync.Mutex
+	keys      map[string]memoryKey
+	err       error
+	completed int
+	released  int
+}
+
+type memoryKey struct {
+	fingerprint string
+	response    *idempotency.Response
+}
+
+func newMemoryKeys() *memoryKeys {
+	return &memoryKeys{keys: make(map[string]memoryKey)}
+}
+
+func (m *memoryKeys) Begin(key, fingerprint string) (*idempotency.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+	stored, ok := m.keys[key]
+	switch {
+	case !ok:
+		m.keys[key] = memoryKey{fingerprint: fingerprint}
+		return nil, nil
+	case stored.fingerprint != fingerprint:
+		return nil, idempotency.ErrMismatch
+	case stored.response == nil:
+		return nil, idempotency.ErrInProgress
+	}
+	return stored.response, nil
+}
+
+func (m *memoryKeys) Complete(key string, resp idempotency.Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.completed++
+	m.keys[key] = memoryKey{fingerprint: m.keys[key].fingerprint, response: &resp}
+	return nil
+}
+
+func (m *memoryKeys) Release(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.released++
+	delete(m.keys, key)
+	return nil
+}
+
+func idempotentRequest(method, key, body string) *http.Request {
+	r := httptest.NewRequest(method, "/subscriptions", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(IdempotencyHeader, key)
+	}
+	return r
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	store := newMemoryKeys()
+	calls := 0
+	handler := IdempotencyMiddleware(store, testLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantCalls  int
+		wantReplay bool
+	}{
+		{"first request runs", idempotentRequest("POST", "a", `{"price":1}`), http.StatusCreated, 1, false},
+		{"repeat is replayed", idempotentRequest("POST", "a", `{"price":1}`), http.StatusCreated, 1, true},
+		{"another body answers 422", idempotentRequest("POST", "a", `{"price":2}`), http.StatusUnprocessableEntity, 1, false},
+		{"another key runs", idempotentRequest("POST", "b", `{"price":1}`), http.StatusCreated, 2, false},
+		{"no key runs", idempotentRequest("POST", "", `{"price":1}`), http.StatusCreated, 3, false},
+		{"GET ignores the key", idempotentRequest("GET", "a", ""), http.StatusCreated, 4, false},
+		{"too long key", idempotentRequest("POST", strings.Repeat("k", MaxIdempotencyKeyLength+1), `{}`), http.StatusBadRequest, 4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("handler calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.wantReplay {
+				t.Fatalf("replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+			if tt.wantReplay && w.Body.String() != `{"id":1}` {
+				t.Fatalf("replayed body = %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareInProgress(t *testing.T) {
+	store := newMemoryKeys()
+	started := make(chan struct{})
+	done := make(chan struct{})
+	handler := IdempotencyMiddleware(store, testLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-done
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("POST", "a", `{}`))
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, idempotentRequest("POST", "a", `{}`))
+	close(done)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestIdempotencyMiddlewareReleasesFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}},
+		{"panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("handler failed")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryKeys()
+			handler := IdempotencyMiddleware(store, testLogger())(tt.handler)
+
+			func() {
+				defer func() { recover() }()
+				handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("POST", "a", `{}`))
+			}()
+
+			if store.released != 1 || store.completed != 0 || len(store.keys) != 0 {
+				t.Fatalf("released = %d, completed = %d, keys = %d, want the key released",
+					store.released, store.completed, len(store.keys))
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareStoreFailure(t *testing.T) {
+	store := newMemoryKeys()
+	store.err = errors.New("database is down")
+	called := false
+	handler := IdempotencyMiddleware(store, testLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, idempotentRequest("POST", "a", `{}`))
+
+	if w.Code != http.StatusServiceUnavailable || called {
+		t.Fatalf("status = %d, called = %v, want 503 without running the request", w.Code, called)
+	}
+}
+
+func TestIdempotencyStoreKey(t *testing.T) {
+	long := idempotencyStoreKey(strings.Repeat("s", 600), strings.Repeat("k", MaxIdempotencyKeyLength))
+	if len(long) != 64 {
+		t.Fatalf("store key length = %d, want 64", len(long))
+	}
+
+	if idempotencyStoreKey("tenant-1", "a") == idempotencyStoreKey("tenant-2", "a") {
+		t.Fatal("store keys of different scopes are equal")
+	}
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"subscription-aggregator/internal/openapi"
+	"subscription-aggregator/internal/validation"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// OpenAPIMiddleware validates path and query parameters, headers and JSON
+// bodies of requests against the operation of the matched route, answering
+// 400 before the handler runs. Routes without an operation pass through.
+func OpenAPIMiddleware(doc *openapi.Document, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			op, ok := doc.Operation(r.Method, route)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			errs := validateParameters(doc, op, r)
+
+			if op.RequestBody != nil {
+				data, bodyErrs, err := validateBody(doc, op.RequestBody, r)
+				switch {
+				case errors.Is(err, validation.ErrBodyTooLarge):
+					http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				case err != nil:
+					logger.WithError(err).Error("Failed to decode request body")
+					http.Error(w, "Invalid JSON", http.StatusBadRequest)
+					return
+				}
+				errs = append(errs, bodyErrs...)
+				r.Body = io.NopCloser(bytes.NewReader(data))
+			}
+
+			if len(errs) > 0 {
+				logger.WithError(errs).WithField("route", route).Error("Request does not match the OpenAPI document")
+				http.Error(w, "Validation failed: "+errs.Error(), http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OpenAPIResponseMiddleware checks responses against the operation of the
+// matched route and replaces undocumented ones with 500. It buffers every
+// response and is meant for tests and staging.
+func OpenAPIResponseMiddleware(doc *openapi.Document, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			op, ok := doc.Operation(r.Method, route)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recordingWriter{header: make(http.Header), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if err := validateResponse(doc, op, rec); err != nil {
+				logger.WithError(err).WithFields(logrus.Fields{
+					"route":  r.Method + " " + route,
+					"status": rec.status,
+				}).Error("Response does not match the OpenAPI document")
+				http.Error(w, "Response does not match the OpenAPI document", http.StatusInternalServerError)
+				return
+			}
+
+			rec.flush(w)
+		})
+	}
+}
+
+func validateParameters(doc *openapi.Document, op *openapi.Operation, r *http.Request) validation.ValidationErrors {
+	var errs validation.ValidationErrors
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	for _, param := range op.Parameters {
+		param = doc.Parameter(param)
+
+		var values []string
+		switch param.In {
+		case "path":
+			values = []string{vars[param.Name]}
+		case "query":
+			values = query[param.Name]
+		case "header":
+			if value := r.Header.Get(param.Name); value != "" {
+				values = []string{value}
+			}
+		}
+
+		if len(values) == 0 {
+			if param.Required {
+				errs = append(errs, validation.ValidationError{
+					Field:   param.Name,
+					Message: "параметр " + param.Name + " обязателен",
+				})
+			}
+			continue
+		}
+
+		for _, value := range values {
+			errs = append(errs, doc.ValidateParameter(param, value)...)
+		}
+	}
+
+	return errs
+}
+
+// validateBody reads the body and checks it against the JSON schema of its
+// content type, application/json by default. It returns the body for the
+// handler to decode again.
+func validateBody(doc *openapi.Document, body *openapi.RequestBody, r *http.Request) ([]byte, validation.ValidationErrors, error) {
+	data, err := validation.ReadBody(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contentType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	media, ok := body.Content[strings.TrimSpace(contentType)]
+	if !ok {
+		media, ok = body.Content["application/json"]
+	}
+	if !ok {
+		return data, nil, nil
+	}
+
+	value, err := validation.ParseJSON(data)
+	if err != nil {
+		var fieldErrs validation.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			return data, fieldErrs, nil
+		}
+		return nil, nil, err
+	}
+
+	return data, doc.ValidateValue(media.Schema, value, ""), nil
+}
+
+func validateResponse(doc *openapi.Document, op *openapi.Operation, rec *recordingWriter) error {
+	documented, ok := op.Responses[strconv.Itoa(rec.status)]
+	if !ok {
+		return errors.New("undocumented status " + strconv.Itoa(rec.status))
+	}
+	documented = doc.Response(documented)
+
+	if len(documented.Content) == 0 {
+		if rec.body.Len() > 0 {
+			return errors.New("undocumented response body")
+		}
+		return nil
+	}
+
+	contentType, _, _ := strings.Cut(rec.header.Get("Content-Type"), ";")
+	media, ok := documented.Content[contentType]
+	if !ok {
+		return errors.New("undocumented content type " + contentType)
+	}
+	if contentType != "application/json" {
+		return nil
+	}
+
+	value, err := validation.ParseJSON(rec.body.Bytes())
+	if err != nil {
+		return err
+	}
+	if errs := doc.ValidateValue(media.Schema, value, ""); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// recordingWriter buffers a response until it has been validated
+type recordingWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.status = code
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	return rw.body.Write(b)
+}
+
+func (rw *recordingWriter) flush(w http.ResponseWriter) {
+	for key, values := range rw.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(rw.status)
+	w.Write(rw.body.Bytes())
+}
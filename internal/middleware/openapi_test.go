@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"subscription-aggregator/internal/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOpenAPIMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(OpenAPIMiddleware(openapi.Build(), testLogger()))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.HandleFunc("/subscriptions", ok).Methods("GET", "POST")
+	router.HandleFunc("/subscriptions/{id}", ok).Methods("GET")
+	router.HandleFunc("/v2/subscriptions/{id}", ok).Methods("PATCH")
+	router.HandleFunc("/undocumented", ok)
+
+	const userID = "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e"
+	valid := `{"service_name":"Netflix","price":400,"user_id":"` + userID + `","start_date":"07-2025"}`
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+	}{
+		{"valid body", "POST", "/subscriptions", valid, http.StatusOK},
+		{"unknown field", "POST", "/subscriptions", strings.Replace(valid, `"price"`, `"owner":"x","price"`, 1), http.StatusBadRequest},
+		{"string price", "POST", "/subscriptions", strings.Replace(valid, `400`, `"400"`, 1), http.StatusBadRequest},
+		{"negative price", "POST", "/subscriptions", strings.Replace(valid, `400`, `-1`, 1), http.StatusBadRequest},
+		{"invalid user_id", "POST", "/subscriptions", strings.Replace(valid, userID, "nope", 1), http.StatusBadRequest},
+		{"unknown category", "POST", "/subscriptions", strings.Replace(valid, `}`, `,"category":"toys"}`, 1), http.StatusBadRequest},
+		{"missing required field", "POST", "/subscriptions", `{"service_name":"Netflix","price":400}`, http.StatusBadRequest},
+		{"invalid JSON", "POST", "/subscriptions", `{"price":`, http.StatusBadRequest},
+		{"valid query", "GET", "/subscriptions?user_id=" + userID + "&limit=10&tag=a&tag=b", "", http.StatusOK},
+		{"invalid limit", "GET", "/subscriptions?limit=abc", "", http.StatusBadRequest},
+		{"invalid user_id query", "GET", "/subscriptions?user_id=nope", "", http.StatusBadRequest},
+		{"valid path", "GET", "/subscriptions/" + userID, "", http.StatusOK},
+		{"invalid path id", "GET", "/subscriptions/not-a-uuid", "", http.StatusBadRequest},
+		{"v2 patch with null", "PATCH", "/v2/subscriptions/" + userID, `{"end_date":null}`, http.StatusOK},
+		{"v2 patch with wrong type", "PATCH", "/v2/subscriptions/" + userID, `{"tags":"a"}`, http.StatusBadRequest},
+		{"undocumented route", "POST", "/undocumented", `{"anything":1}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("%s %s = %d %q, want %d", tt.method, tt.path, w.Code, w.Body.String(), tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestOpenAPIResponseMiddleware(t *testing.T) {
+	const id = "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e"
+	subscription := `{"id":"` + id + `","service_name":"Netflix","service_id":"` + id + `","price":400,"user_id":"` + id + `",` +
+		`"start_date":"2025-07-01T00:00:00Z","tags":[],"split_type":"none","created_at":"2025-07-01T00:00:00Z","updated_at":"2025-07-01T00:00:00Z"}`
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantCode    int
+	}{
+		{"documented response", http.StatusOK, "application/json", subscription, http.StatusOK},
+		{"documented error", http.StatusNotFound, "text/plain; charset=utf-8", "Subscription not found", http.StatusNotFound},
+		{"undocumented status", http.StatusTeapot, "text/plain", "teapot", http.StatusInternalServerError},
+		{"undocumented content type", http.StatusOK, "text/html", "<p>", http.StatusInternalServerError},
+		{"body not matching the schema", http.StatusOK, "application/json", `{"id":"nope"}`, http.StatusInternalServerError},
+		{"invalid JSON", http.StatusOK, "application/json", `{`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(OpenAPIResponseMiddleware(openapi.Build(), testLogger()))
+			router.HandleFunc("/subscriptions/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/subscriptions/"+id, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("response = %d %q, want %d", w.Code, w.Body.String(), tt.wantCode)
+			}
+			if tt.wantCode == tt.status && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want it passed through as %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/ratelimit"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitOptions configures RateLimitMiddleware
+type RateLimitOptions struct {
+	Default           ratelimit.Limit
+	Routes            map[string]ratelimit.Limit // Keyed by path template without version prefix
+	TrustForwardedFor bool
+}
+
+// RateLimitMiddleware gives every client a token bucket per route, shared by
+// all API versions of the route. Clients
+// are identified by their credentials, or by IP address when the request
+// is not authenticated. Limiter failures are logged and let the request
+// through rather than taking the API down.
+func RateLimitMiddleware(limiter ratelimit.Limiter, opts RateLimitOptions, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := apiRoute(r)
+
+			limit, ok := opts.Routes[route]
+			if !ok {
+				limit = opts.Default
+			}
+
+			res, err := limiter.Allow(clientKey(r, opts.TrustForwardedFor)+"|"+route, limit)
+			if err != nil {
+				logger.WithError(err).Error("Rate limiter failed")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies the caller
+func clientKey(r *http.Request, trustForwardedFor bool) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return "principal:" + principal.TenantID.String() + ":" + principal.Subject
+	}
+
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			return "ip:" + strings.TrimSpace(ip)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// routeTemplate returns the path template of the matched route, so that
+// requests for different IDs share a bucket
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/ratelimit"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("database is down")
+}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func rateLimitedRouter(limiter ratelimit.Limiter, opts RateLimitOptions) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(RateLimitMiddleware(limiter, opts, testLogger()))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.HandleFunc("/subscriptions/aggregate", ok)
+	router.HandleFunc("/subscriptions/{id}", ok)
+	router.HandleFunc("/v2/subscriptions/{id}", ok)
+	return router
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := rateLimitedRouter(ratelimit.NewMemoryLimiter(), RateLimitOptions{
+		Default: ratelimit.Limit{RequestsPerMinute: 60, Burst: 2},
+		Routes: map[string]ratelimit.Limit{
+			"/subscriptions/aggregate": {RequestsPerMinute: 60, Burst: 1},
+		},
+	})
+
+	do := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// IDs and API versions of a route share a bucket
+	w := do("/subscriptions/1", "10.0.0.1:1234")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("first request = %d %v, want 200 with 1 remaining", w.Code, w.Header())
+	}
+	if w := do("/v2/subscriptions/2", "10.0.0.1:5678"); w.Code != http.StatusOK {
+		t.Fatalf("second request = %d, want 200", w.Code)
+	}
+	w = do("/subscriptions/3", "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("third request = %d with Retry-After %q, want 429 after 1s", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Other clients and routes have their own buckets and limits
+	if w := do("/subscriptions/3", "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("request of another client = %d, want 200", w.Code)
+	}
+	if w := do("/subscriptions/aggregate", "10.0.0.1:1234"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("request of another route = %d with limit %q, want 200 with the route limit", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimitMiddlewareLetsRequestsThroughOnFailure(t *testing.T) {
+	router := rateLimitedRouter(failingLimiter{}, RateLimitOptions{Default: ratelimit.Limit{RequestsPerMinute: 60, Burst: 1}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/subscriptions/1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("request with a failing limiter = %d, want 200", w.Code)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name              string
+		principal         *auth.Principal
+		forwardedFor      string
+		trustForwardedFor bool
+		want              string
+	}{
+		{name: "remote address", want: "ip:192.0.2.1"},
+		{name: "untrusted forwarded for", forwardedFor: "203.0.113.7", want: "ip:192.0.2.1"},
+		{name: "trusted forwarded for", forwardedFor: "203.0.113.7, 10.0.0.1", trustForwardedFor: true, want: "ip:203.0.113.7"},
+		{
+			name:              "principal",
+			principal:         &auth.Principal{Subject: "key-1"},
+			forwardedFor:      "203.0.113.7",
+			trustForwardedFor: true,
+			want:              "principal:00000000-0000-0000-0000-000000000000:key-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/subscriptions", nil)
+			r.RemoteAddr = "192.0.2.1:4321"
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), tt.principal))
+			}
+
+			if got := clientKey(r, tt.trustForwardedFor); got != tt.want {
+				t.Errorf("clientKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
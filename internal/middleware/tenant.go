@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// TenantMiddleware resolves the tenant of the request. Credentials determine
+// it, falling back to the default tenant for credentials not bound to one
+// when a tenant is not required, and a tenant header naming another tenant
+// is rejected. Anonymous requests use the tenant header, and without the
+// header the default tenant unless a tenant is required.
+func TenantMiddleware(header string, required bool, defaultTenant uuid.UUID) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credentialTenant := uuid.Nil
+			principal, authenticated := auth.FromContext(r.Context())
+			if authenticated {
+				credentialTenant = principal.TenantID
+			}
+
+			tenantID, err := tenant.Resolve(authenticated, credentialTenant, r.Header.Get(header), required, defaultTenant)
+			switch {
+			case errors.Is(err, tenant.ErrForeignTenant):
+				http.Error(w, "Credentials do not belong to this tenant", http.StatusForbidden)
+				return
+			case errors.Is(err, tenant.ErrUnboundCredentials):
+				http.Error(w, "Credentials are not bound to a tenant", http.StatusForbidden)
+				return
+			case errors.Is(err, tenant.ErrInvalidID):
+				http.Error(w, "Invalid tenant ID", http.StatusBadRequest)
+				return
+			case err != nil:
+				http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), tenantID)))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+/`)
+
+// DeprecationOptions configures DeprecationMiddleware
+type DeprecationOptions struct {
+	Current      string    // Prefix of the current version, e.g. "/v2"
+	DeprecatedAt time.Time // Announced in the Deprecation header when set
+	Sunset       time.Time // Announced in the Sunset header when set
+	Unversioned  []string  // Route templates outside the versioning, e.g. "/graphql"
+}
+
+// DeprecationMiddleware marks responses of routes outside the current API
+// version as deprecated (RFC 9745), announces their sunset (RFC 8594) and
+// links the same path under the current version
+func DeprecationMiddleware(opts DeprecationOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(routeTemplate(r), opts.Current+"/") || isRoute(r, opts.Unversioned) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !opts.DeprecatedAt.IsZero() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", opts.DeprecatedAt.Unix()))
+			}
+			if !opts.Sunset.IsZero() {
+				w.Header().Set("Sunset", opts.Sunset.UTC().Format(http.TimeFormat))
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, opts.Current, unversioned(r.URL.Path)))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// EnvelopeMiddleware wraps responses of routes under prefix into envelopes:
+// JSON bodies of successful responses become {"data": ...} and errors
+// {"error": {"status": ..., "message": ...}}. It must run before other
+// middlewares so their errors are wrapped as well.
+func EnvelopeMiddleware(prefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(routeTemplate(r), prefix+"/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recordingWriter{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			var envelope interface{}
+			switch {
+			case rec.status >= http.StatusBadRequest:
+				envelope = models.ErrorEnvelope{Error: models.APIError{
+					Status:  rec.status,
+					Message: strings.TrimSpace(rec.body.String()),
+				}}
+			case isJSON(rec.header) && rec.body.Len() > 0:
+				data := bytes.TrimSpace(rec.body.Bytes())
+				envelope = models.Envelope{Data: json.RawMessage(append([]byte(nil), data...))}
+			}
+
+			if envelope != nil {
+				rec.body.Reset()
+				json.NewEncoder(&rec.body).Encode(envelope)
+				rec.header.Set("Content-Type", "application/json")
+				rec.header.Del("Content-Length") // Set by the handler for the original body
+				rec.header.Del("X-Content-Type-Options")
+			}
+
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// apiRoute returns the path template of the matched route without the API
+// version prefix, so that settings of a route apply to all its versions
+func apiRoute(r *http.Request) string {
+	return unversioned(routeTemplate(r))
+}
+
+func unversioned(path string) string {
+	if loc := versionPrefix.FindStringIndex(path); loc != nil {
+		return path[loc[1]-1:]
+	}
+	return path
+}
+
+func isJSON(header http.Header) bool {
+	contentType, _, _ := strings.Cut(header.Get("Content-Type"), ";")
+	return strings.TrimSpace(contentType) == "application/json"
+}
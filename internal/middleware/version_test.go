@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/ratelimit"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDeprecationMiddleware(t *testing.T) {
+	deprecatedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	router := mux.NewRouter()
+	router.Use(DeprecationMiddleware(DeprecationOptions{
+		Current:      "/v2",
+		DeprecatedAt: deprecatedAt,
+		Sunset:       sunset,
+		Unversioned:  []string{"/graphql"},
+	}))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.HandleFunc("/subscriptions/{id}", ok)
+	router.HandleFunc("/v1/subscriptions/{id}", ok)
+	router.HandleFunc("/v2/subscriptions/{id}", ok)
+	router.HandleFunc("/graphql", ok)
+
+	tests := []struct {
+		path       string
+		deprecated bool
+		wantLink   string
+	}{
+		{"/subscriptions/1", true, `</v2/subscriptions/1>; rel="successor-version"`},
+		{"/v1/subscriptions/1", true, `</v2/subscriptions/1>; rel="successor-version"`},
+		{"/v2/subscriptions/1", false, ""},
+		{"/graphql", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			header := w.Header()
+			if !tt.deprecated {
+				for _, name := range []string{"Deprecation", "Sunset", "Link"} {
+					if header.Get(name) != "" {
+						t.Errorf("%s = %q, want none", name, header.Get(name))
+					}
+				}
+				return
+			}
+			if got, want := header.Get("Deprecation"), "@"+strconv.FormatInt(deprecatedAt.Unix(), 10); got != want {
+				t.Errorf("Deprecation = %q, want %q", got, want)
+			}
+			if got, want := header.Get("Sunset"), "Thu, 01 Jan 2026 00:00:00 GMT"; got != want {
+				t.Errorf("Sunset = %q, want %q", got, want)
+			}
+			if got := header.Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
+
+func TestEnvelopeMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(EnvelopeMiddleware("/v2"))
+	router.Use(RateLimitMiddleware(ratelimit.NewMemoryLimiter(), RateLimitOptions{
+		Default: ratelimit.Limit{RequestsPerMinute: 6000, Burst: 100},
+		Routes:  map[string]ratelimit.Limit{"/limited": {RequestsPerMinute: 60, Burst: 1}},
+	}, testLogger()))
+
+	body := `{"id":1}`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("status") {
+		case "404":
+			http.Error(w, "Subscription not found", http.StatusNotFound)
+		case "204":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write([]byte(body))
+		}
+	})
+	router.Handle("/v2/subscriptions", handler)
+	router.Handle("/v2/limited", handler)
+	router.Handle("/v2/private", AuthMiddleware(auth.NewAuthenticator(nil, nil), testLogger())(handler))
+	router.Handle("/v1/subscriptions", handler)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	errorOf := func(t *testing.T, w *httptest.ResponseRecorder) models.APIError {
+		t.Helper()
+		var envelope models.ErrorEnvelope
+		if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("body %q is not an error envelope: %v", w.Body.String(), err)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		return envelope.Error
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := do("/v2/subscriptions")
+		if w.Code != http.StatusOK || w.Body.String() != `{"data":{"id":1}}`+"\n" {
+			t.Fatalf("response = %d %q, want the body under data", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Length"); got != "" {
+			t.Errorf("Content-Length = %q of the original body, want none", got)
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		if w := do("/v2/subscriptions?status=204"); w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+			t.Errorf("response = %d %q, want empty 204", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		w := do("/v2/subscriptions?status=404")
+		want := models.APIError{Status: http.StatusNotFound, Message: "Subscription not found"}
+		if got := errorOf(t, w); w.Code != http.StatusNotFound || got != want {
+			t.Errorf("response = %d %+v, want 404 %+v", w.Code, got, want)
+		}
+		if got := w.Header().Get("X-Content-Type-Options"); got != "" {
+			t.Errorf("X-Content-Type-Options = %q, want none", got)
+		}
+	})
+
+	t.Run("authentication error", func(t *testing.T) {
+		w := do("/v2/private")
+		want := models.APIError{Status: http.StatusUnauthorized, Message: "Authentication required"}
+		if got := errorOf(t, w); w.Code != http.StatusUnauthorized || got != want {
+			t.Errorf("response = %d %+v, want 401 %+v", w.Code, got, want)
+		}
+		if w.Header().Get("WWW-Authenticate") == "" {
+			t.Error("WWW-Authenticate header was dropped")
+		}
+	})
+
+	t.Run("rate limit error", func(t *testing.T) {
+		do("/v2/limited")
+		w := do("/v2/limited")
+		want := models.APIError{Status: http.StatusTooManyRequests, Message: "Rate limit exceeded"}
+		if got := errorOf(t, w); w.Code != http.StatusTooManyRequests || got != want {
+			t.Errorf("response = %d %+v, want 429 %+v", w.Code, got, want)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("Retry-After header was dropped")
+		}
+	})
+
+	t.Run("v1", func(t *testing.T) {
+		if w := do("/v1/subscriptions"); w.Body.String() != body {
+			t.Errorf("body = %q, want %q unwrapped", w.Body.String(), body)
+		}
+		if w := do("/v1/subscriptions?status=404"); w.Body.String() != "Subscription not found\n" {
+			t.Errorf("error body = %q, want plain text", w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// CheckRoutes compares the routes registered on the router with the
+// document and returns an error listing routes missing on either side.
+// The service refuses to start on drift, so a route cannot ship undocumented.
+func CheckRoutes(router *mux.Router, doc *Document) error {
+	registered := make(map[string]bool)
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		// Path prefixes of subrouters have no methods
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			registered[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var problems []string
+	for route := range registered {
+		if !documented[route] {
+			problems = append(problems, "undocumented route "+route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			problems = append(problems, "documented route not registered "+route)
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package openapi_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"subscription-aggregator/internal/handlers"
+	"subscription-aggregator/internal/openapi"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCheckRoutesMatchesRouter(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// The same registration as cmd/main.go; handlers are never called,
+	// so they are built without a database
+	router := mux.NewRouter()
+	spec := openapi.Build()
+	handlers.RegisterSystem(router, spec)
+	handlers.NewRoutes(nil, nil, logger).Register(router.PathPrefix("/").Subrouter())
+
+	if err := openapi.CheckRoutes(router, spec); err != nil {
+		t.Fatalf("CheckRoutes() = %v", err)
+	}
+}
+
+func TestCheckRoutesReportsDrift(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	tests := []struct {
+		name     string
+		register func(r *mux.Router)
+		want     string
+	}{
+		{
+			name: "undocumented route",
+			register: func(r *mux.Router) {
+				r.HandleFunc("/undocumented", noop).Methods("GET")
+			},
+			want: "undocumented route GET /undocumented",
+		},
+		{
+			name: "undocumented method",
+			register: func(r *mux.Router) {
+				r.HandleFunc("/health", noop).Methods("DELETE")
+			},
+			want: "undocumented route DELETE /health",
+		},
+		{
+			name:     "documented route not registered",
+			register: func(r *mux.Router) {},
+			want:     "documented route not registered POST /subscriptions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			spec := openapi.Build()
+			handlers.RegisterSystem(router, spec)
+			tt.register(router)
+
+			err := openapi.CheckRoutes(router, spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("CheckRoutes() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
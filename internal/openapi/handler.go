@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Handler serves the document as JSON
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		panic("openapi: failed to encode document: " + err.Error())
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// DocsHandler serves a self-contained HTML explorer of the document, so the
+// docs work without access to a CDN
+func DocsHandler(doc *Document) http.HandlerFunc {
+	var page bytes.Buffer
+	if err := docsTemplate.Execute(&page, docsView(doc)); err != nil {
+		panic("openapi: failed to render docs: " + err.Error())
+	}
+	body := page.Bytes()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(body)
+	}
+}
+
+type docsPage struct {
+	Info    Info
+	Groups  []docsGroup
+	Schemas []docsSchema
+}
+
+type docsGroup struct {
+	Tag        Tag
+	Operations []docsOperation
+}
+
+type docsOperation struct {
+	Method    string
+	Path      string
+	Operation *Operation
+	Body      string
+	Responses []docsResponse
+}
+
+type docsResponse struct {
+	Status string
+	Schema string
+}
+
+type docsSchema struct {
+	Name string
+	JSON string
+}
+
+func docsView(doc *Document) docsPage {
+	page := docsPage{Info: doc.Info}
+
+	for _, tag := range doc.Tags {
+		group := docsGroup{Tag: tag}
+		for _, path := range sortedKeys(doc.Paths) {
+			for _, method := range methodOrder {
+				op, ok := doc.Paths[path][method]
+				if !ok || op.Tags[0] != tag.Name {
+					continue
+				}
+				group.Operations = append(group.Operations, docsOperation{
+					Method:    strings.ToUpper(method),
+					Path:      path,
+					Operation: op,
+					Body:      requestBodyName(op),
+					Responses: responsesView(op),
+				})
+			}
+		}
+		page.Groups = append(page.Groups, group)
+	}
+
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		encoded, _ := json.MarshalIndent(doc.Components.Schemas[name], "", "  ")
+		page.Schemas = append(page.Schemas, docsSchema{Name: name, JSON: string(encoded)})
+	}
+
+	return page
+}
+
+var methodOrder = []string{"get", "post", "put", "patch", "delete"}
+
+func requestBodyName(op *Operation) string {
+	if op.RequestBody == nil {
+		return ""
+	}
+	return schemaLabel(op.RequestBody.Content["application/json"].Schema)
+}
+
+func responsesView(op *Operation) []docsResponse {
+	var responses []docsResponse
+	for _, status := range sortedKeys(op.Responses) {
+		response := op.Responses[status]
+		label := "text/plain"
+		if response.Ref == "#/components/responses/ErrorEnvelope" {
+			label = "ErrorEnvelope"
+		}
+		if response.Ref == "" {
+			label = ""
+			if media, ok := response.Content["application/json"]; ok {
+				label = schemaLabel(media.Schema)
+			}
+		}
+		responses = append(responses, docsResponse{Status: status, Schema: label})
+	}
+	return responses
+}
+
+// schemaLabel names a referenced or array-of-referenced schema
+func schemaLabel(schema *Schema) string {
+	switch {
+	case schema == nil:
+		return ""
+	case schema.Ref != "":
+		return schemaName(schema.Ref)
+	case schema.Items != nil:
+		return schemaLabel(schema.Items) + "[]"
+	}
+	return ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Info.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: .3em; }
+details { border: 1px solid #ddd; border-radius: 4px; margin: .5em 0; padding: .5em; }
+summary { cursor: pointer; }
+.method { display: inline-block; width: 5em; font-weight: bold; }
+.GET { color: #1a7f37; } .POST { color: #0969da; } .PUT { color: #9a6700; } .DELETE { color: #cf222e; }
+code, pre { background: #f6f8fa; }
+pre { padding: .5em; overflow-x: auto; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: .2em .5em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Info.Title}} <small>{{.Info.Version}}</small></h1>
+<p>{{.Info.Description}}</p>
+<p>Машиночитаемая спецификация: <a href="/openapi.json">/openapi.json</a></p>
+{{range .Groups}}
+<h2>{{.Tag.Name}}</h2>
+<p>{{.Tag.Description}}</p>
+{{range .Operations}}
+<details id="{{.Operation.OperationID}}">
+<summary><span class="method {{.Method}}">{{.Method}}</span> <code>{{.Path}}</code> {{.Operation.Summary}}{{if .Operation.Deprecated}} <em>(устарело)</em>{{end}}</summary>
+{{if .Operation.Parameters}}
+<h4>Параметры</h4>
+<table>
+<tr><th>Имя</th><th>Где</th><th>Описание</th></tr>
+{{range .Operation.Parameters}}{{if .Ref}}<tr><td>X-Tenant-ID</td><td>header</td><td>Арендатор</td></tr>{{else}}<tr><td>{{.Name}}</td><td>{{.In}}</td><td>{{.Description}}</td></tr>{{end}}
+{{end}}
+</table>
+{{end}}
+{{if .Body}}<h4>Тело запроса</h4><p><a href="#schema-{{.Body}}">{{.Body}}</a></p>{{end}}
+<h4>Ответы</h4>
+<table>
+{{range .Responses}}<tr><td>{{.Status}}</td><td>{{.Schema}}</td></tr>
+{{end}}
+</table>
+</details>
+{{end}}
+{{end}}
+<h2>Схемы</h2>
+{{range .Schemas}}
+<details id="schema-{{.Name}}">
+<summary><code>{{.Name}}</code></summary>
+<pre>{{.JSON}}</pre>
+</details>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,280 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"subscription-aggregator/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// Patterns of the date formats accepted by validation.ParseDate
+const (
+	datePattern      = `^(\d{4}-\d{2}-\d{2}|\d{2}-\d{4})$`
+	monthYearPattern = `^\d{2}-\d{4}$`
+)
+
+var (
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaBuilder turns model structs into component schemas. Request models
+// take required fields from their validate tags and reject unknown
+// properties; response models require every field without omitempty.
+type schemaBuilder struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{schemas: make(map[string]*Schema)}
+}
+
+// ref returns a reference to the component schema of the model, building it
+// on first use
+func (b *schemaBuilder) ref(model interface{}, request bool) *Schema {
+	return b.schemaOf(reflect.TypeOf(model), nil, request)
+}
+
+func (b *schemaBuilder) schemaOf(t reflect.Type, rules []string, request bool) *Schema {
+	nullable := false
+	if t.Kind() == reflect.Pointer {
+		nullable = true
+		t = t.Elem()
+	}
+
+	var schema *Schema
+	var itemRules []string
+
+	for i, rule := range rules {
+		if rule == "dive" {
+			rules, itemRules = rules[:i], rules[i+1:]
+			break
+		}
+	}
+
+	switch {
+	case t == rawType || t.Kind() == reflect.Interface:
+		// Any JSON value
+		return &Schema{}
+	case t == uuidType:
+		schema = &Schema{Type: SchemaType{"string"}, Format: "uuid"}
+	case t == timeType:
+		schema = &Schema{Type: SchemaType{"string"}, Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		return &Schema{Ref: schemaRefPrefix + b.component(t, request)}
+	case t.Kind() == reflect.Map:
+		// Nil maps are encoded as null
+		nullable = true
+		schema = &Schema{Type: SchemaType{"object"}}
+	case t.Kind() == reflect.Slice:
+		// Nil slices are encoded as null
+		nullable = true
+		schema = &Schema{Type: SchemaType{"array"}, Items: b.schemaOf(t.Elem(), itemRules, request)}
+	case t.Kind() == reflect.String:
+		schema = &Schema{Type: SchemaType{"string"}}
+	case t.Kind() == reflect.Bool:
+		schema = &Schema{Type: SchemaType{"boolean"}}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema = &Schema{Type: SchemaType{"integer"}}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = &Schema{Type: SchemaType{"number"}}
+	default:
+		panic(fmt.Sprintf("openapi: unsupported type %s", t))
+	}
+
+	applyRules(schema, rules)
+
+	for _, rule := range rules {
+		if rule == "nullable" {
+			nullable = true
+		}
+	}
+
+	if nullable {
+		schema.Type = append(schema.Type, "null")
+		if schema.Enum != nil {
+			schema.Enum = append(schema.Enum, nil)
+		}
+	}
+
+	return schema
+}
+
+// component builds the named object schema of a struct type
+func (b *schemaBuilder) component(t reflect.Type, request bool) string {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, ok := b.schemas[name]; ok {
+		return name
+	}
+
+	schema := &Schema{Type: SchemaType{"object"}, Properties: make(map[string]*Schema)}
+	if request {
+		closed := false
+		schema.AdditionalProperties = &closed
+	}
+	b.schemas[name] = schema
+
+	b.addFields(schema, t, request)
+	return name
+}
+
+func (b *schemaBuilder) addFields(schema *Schema, t reflect.Type, request bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Embedded structs are flattened like encoding/json does
+		if field.Anonymous && name == "" {
+			b.addFields(schema, field.Type, request)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		rules := splitRules(field.Tag.Get("validate"))
+		for j, rule := range rules {
+			// Refer to the other field by its JSON name
+			if other, ok := strings.CutPrefix(rule, "gtefield="); ok {
+				if f, found := t.FieldByName(other); found {
+					rules[j] = "gtefield=" + jsonName(f)
+				}
+			}
+		}
+		schema.Properties[name] = b.schemaOf(field.Type, rules, request)
+
+		required := !strings.Contains(options, "omitempty")
+		if request {
+			required = len(rules) > 0 && rules[0] == "required"
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyRules maps validate tag rules onto schema keywords
+func applyRules(schema *Schema, rules []string) {
+	nullable := false
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			switch {
+			case schema.Type.Has("string"):
+				schema.MinLength = intPtr(1)
+			case schema.Type.Has("array"):
+				schema.MinItems = intPtr(1)
+			}
+		case "min", "max", "len":
+			applyBound(schema, name, param)
+		case "uuid":
+			schema.Format = "uuid"
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "date":
+			schema.Pattern = datePattern
+		case "monthyear":
+			schema.Pattern = monthYearPattern
+		case "enum":
+			values, ok := validation.Enum(param)
+			if !ok {
+				values = strings.Split(param, "|")
+			}
+			for _, value := range values {
+				schema.Enum = append(schema.Enum, value)
+			}
+		case "gtefield":
+			schema.Description = "Не раньше поля " + param
+		case "nullable":
+			nullable = true
+		}
+	}
+
+	// Validation skips empty strings unless they are required, and accepts
+	// the literal string "null" of nullable fields before any other rule
+	if schema.Pattern != "" && schema.MinLength == nil {
+		schema.Pattern = "^$|" + schema.Pattern
+	}
+	if nullable && schema.Pattern != "" {
+		schema.Pattern = "^null$|" + schema.Pattern
+	}
+	if nullable && schema.Enum != nil {
+		schema.Enum = append(schema.Enum, "null")
+	}
+}
+
+func applyBound(schema *Schema, name, param string) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("openapi: invalid %s parameter %q", name, param))
+	}
+	length := int(limit)
+
+	switch {
+	case schema.Type.Has("string"):
+		if name != "max" {
+			schema.MinLength = &length
+		}
+		if name != "min" {
+			schema.MaxLength = &length
+		}
+	case schema.Type.Has("array"):
+		if name != "max" {
+			schema.MinItems = &length
+		}
+		if name != "min" {
+			schema.MaxItems = &length
+		}
+	default:
+		if name != "max" {
+			schema.Minimum = &limit
+		}
+		if name != "min" {
+			schema.Maximum = &limit
+		}
+	}
+}
+
+func splitRules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func schemaName(ref string) string {
+	return strings.TrimPrefix(ref, schemaRefPrefix)
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func lower(s string) string {
+	return strings.ToLower(s)
+}
@@ -0,0 +1,425 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"subscription-aggregator/pkg/models"
+)
+
+// route describes one operation of the API. Every route registered in
+// cmd/main.go must have an entry here, CheckRoutes enforces it.
+//
+// Routes of the original API are documented unversioned, under /v1 and,
+// unless v1Only, under /v2 with enveloped responses; v2Routes lists the
+// operations that only exist in v2. Unversioned routes are documented once.
+type route struct {
+	method      string
+	path        string
+	id          string
+	summary     string
+	tag         string
+	public      bool // Served without authentication and tenant header
+	v1Only      bool // Replaced in v2
+	unversioned bool // Outside the API versions
+	query       []Parameter
+	body        interface{}
+	bodyTypes   []string // Media types of the body, application/json by default
+	status      int
+	response    interface{}
+	errors      []int // Errors besides the ones every authenticated route may return
+}
+
+var routes = []route{
+	{method: "GET", path: "/health", id: "health", summary: "Проверка работоспособности", tag: "system", public: true,
+		status: http.StatusOK, response: healthResponse{}},
+	{method: "GET", path: "/openapi.json", id: "getOpenAPI", summary: "Этот документ OpenAPI", tag: "system", public: true,
+		status: http.StatusOK},
+	{method: "GET", path: "/docs", id: "getDocs", summary: "Документация API в HTML", tag: "system", public: true,
+		status: http.StatusOK},
+
+	// Subscriptions
+	{method: "GET", path: "/subscriptions", id: "listSubscriptions", summary: "Список подписок", tag: "subscriptions",
+		query: []Parameter{
+			queryParam("user_id", "Подписки пользователя", uuidSchema()),
+			queryParam("service_id", "Подписки на сервис каталога", uuidSchema()),
+			queryParam("service_name", "Название или алиас сервиса", stringSchema()),
+			queryParam("category", "Категория", stringSchema()),
+			queryParam("tag", "Тег, можно повторять: подписка должна иметь все теги", stringSchema()),
+			queryParam("limit", "Размер страницы от 1 до 1000, по умолчанию 100", integerSchema()),
+			queryParam("offset", "Смещение", integerSchema()),
+		},
+		status: http.StatusOK, response: []models.Subscription{}},
+	{method: "POST", path: "/subscriptions", id: "createSubscription", summary: "Создание подписки", tag: "subscriptions",
+		body: models.CreateSubscriptionRequest{}, status: http.StatusCreated, response: models.Subscription{},
+		errors: []int{http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}},
+	{method: "POST", path: "/subscriptions/aggregate", id: "aggregateSubscriptions", summary: "Суммарная стоимость подписок за период", tag: "subscriptions",
+		v1Only: true, body: models.AggregationRequest{}, status: http.StatusOK, response: models.AggregationResponse{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+	{method: "POST", path: "/subscriptions/forecast", id: "forecastSubscriptions", summary: "Прогноз расходов на будущие месяцы", tag: "subscriptions",
+		body: models.ForecastRequest{}, status: http.StatusOK, response: models.ForecastResponse{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+	{method: "GET", path: "/subscriptions/anomalies", id: "listAnomalies", summary: "Вероятные проблемы подписок: дубли, пересечения, скачки цен", tag: "subscriptions",
+		query: []Parameter{
+			queryParam("user_id", "Подписки пользователя, по умолчанию все", uuidSchema()),
+		},
+		status: http.StatusOK, response: models.AnomalyReport{}},
+	{method: "GET", path: "/subscriptions/{id}", id: "getSubscription", summary: "Подписка по ID", tag: "subscriptions",
+		status: http.StatusOK, response: models.Subscription{}, errors: []int{http.StatusNotFound}},
+	{method: "PUT", path: "/subscriptions/{id}", id: "updateSubscription", summary: "Обновление подписки", tag: "subscriptions",
+		v1Only: true, body: models.UpdateSubscriptionRequest{}, status: http.StatusOK,
+		errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}},
+	{method: "DELETE", path: "/subscriptions/{id}", id: "deleteSubscription", summary: "Удаление подписки", tag: "subscriptions",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+
+	// Cost sharing
+	{method: "GET", path: "/subscriptions/{id}/sharing", id: "getSharing", summary: "Распределение стоимости подписки", tag: "sharing",
+		status: http.StatusOK, response: models.SubscriptionSharing{}, errors: []int{http.StatusNotFound}},
+	{method: "PUT", path: "/subscriptions/{id}/sharing", id: "updateSharing", summary: "Изменение распределения стоимости", tag: "sharing",
+		body: models.UpdateSharingRequest{}, status: http.StatusOK, response: models.SubscriptionSharing{},
+		errors: []int{http.StatusNotFound, http.StatusRequestEntityTooLarge}},
+
+	// Scheduled price changes
+	{method: "GET", path: "/subscriptions/{id}/price-changes", id: "listPriceChanges", summary: "Запланированные изменения цены", tag: "subscriptions",
+		status: http.StatusOK, response: []models.PriceChange{}, errors: []int{http.StatusNotFound}},
+	{method: "POST", path: "/subscriptions/{id}/price-changes", id: "schedulePriceChange", summary: "Планирование изменения цены", tag: "subscriptions",
+		body: models.SchedulePriceChangeRequest{}, status: http.StatusCreated, response: models.PriceChange{},
+		errors: []int{http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}},
+	{method: "DELETE", path: "/subscriptions/{id}/price-changes/{date}", id: "cancelPriceChange", summary: "Отмена изменения цены", tag: "subscriptions",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+
+	// Service catalog
+	{method: "GET", path: "/services", id: "listServices", summary: "Каталог сервисов", tag: "services",
+		query: []Parameter{
+			queryParam("name", "Название или алиас сервиса", stringSchema()),
+			queryParam("category", "Категория", stringSchema()),
+		},
+		status: http.StatusOK, response: []models.Service{}},
+	{method: "POST", path: "/services", id: "createService", summary: "Добавление сервиса в каталог", tag: "services",
+		body: models.CreateServiceRequest{}, status: http.StatusCreated, response: models.Service{},
+		errors: []int{http.StatusConflict, http.StatusRequestEntityTooLarge}},
+	{method: "GET", path: "/services/{id}", id: "getService", summary: "Сервис по ID", tag: "services",
+		status: http.StatusOK, response: models.Service{}, errors: []int{http.StatusNotFound}},
+	{method: "PUT", path: "/services/{id}", id: "updateService", summary: "Обновление сервиса", tag: "services",
+		body: models.UpdateServiceRequest{}, status: http.StatusOK,
+		errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge}},
+	{method: "DELETE", path: "/services/{id}", id: "deleteService", summary: "Удаление сервиса", tag: "services",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound, http.StatusConflict}},
+
+	// Users
+	{method: "GET", path: "/users", id: "listUsers", summary: "Список пользователей", tag: "users",
+		status: http.StatusOK, response: []models.User{}},
+	{method: "POST", path: "/users", id: "createUser", summary: "Создание пользователя", tag: "users",
+		body: models.CreateUserRequest{}, status: http.StatusCreated, response: models.User{},
+		errors: []int{http.StatusConflict, http.StatusRequestEntityTooLarge}},
+	{method: "GET", path: "/users/{id}", id: "getUser", summary: "Пользователь по ID", tag: "users",
+		status: http.StatusOK, response: models.User{}, errors: []int{http.StatusNotFound}},
+	{method: "PUT", path: "/users/{id}", id: "updateUser", summary: "Обновление пользователя", tag: "users",
+		body: models.UpdateUserRequest{}, status: http.StatusOK,
+		errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge}},
+	{method: "DELETE", path: "/users/{id}", id: "deleteUser", summary: "Удаление пользователя", tag: "users",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound, http.StatusConflict}},
+
+	// Households
+	{method: "GET", path: "/households", id: "listHouseholds", summary: "Список домохозяйств", tag: "households",
+		query: []Parameter{
+			queryParam("user_id", "Только домохозяйства пользователя", uuidSchema()),
+		},
+		status: http.StatusOK, response: []models.Household{}},
+	{method: "POST", path: "/households", id: "createHousehold", summary: "Создание домохозяйства", tag: "households",
+		body: models.CreateHouseholdRequest{}, status: http.StatusCreated, response: models.Household{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+	{method: "GET", path: "/households/{id}", id: "getHousehold", summary: "Домохозяйство по ID", tag: "households",
+		status: http.StatusOK, response: models.Household{}, errors: []int{http.StatusNotFound}},
+	{method: "DELETE", path: "/households/{id}", id: "deleteHousehold", summary: "Удаление домохозяйства", tag: "households",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+	{method: "POST", path: "/households/{id}/members", id: "addHouseholdMember", summary: "Добавление участника", tag: "households",
+		body: models.AddHouseholdMemberRequest{}, status: http.StatusNoContent,
+		errors: []int{http.StatusNotFound, http.StatusRequestEntityTooLarge}},
+	{method: "DELETE", path: "/households/{id}/members/{user_id}", id: "removeHouseholdMember", summary: "Удаление участника", tag: "households",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+
+	// Budgets
+	{method: "GET", path: "/budgets", id: "listBudgets", summary: "Список бюджетов", tag: "budgets",
+		query: []Parameter{
+			queryParam("user_id", "Только бюджеты пользователя", uuidSchema()),
+		},
+		status: http.StatusOK, response: []models.Budget{}},
+	{method: "POST", path: "/budgets", id: "createBudget", summary: "Создание бюджета", tag: "budgets",
+		body: models.CreateBudgetRequest{}, status: http.StatusCreated, response: models.Budget{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+	{method: "GET", path: "/budgets/{id}", id: "getBudget", summary: "Бюджет по ID", tag: "budgets",
+		status: http.StatusOK, response: models.Budget{}, errors: []int{http.StatusNotFound}},
+	{method: "PUT", path: "/budgets/{id}", id: "updateBudget", summary: "Изменение бюджета", tag: "budgets",
+		body: models.UpdateBudgetRequest{}, status: http.StatusOK, response: models.Budget{},
+		errors: []int{http.StatusNotFound, http.StatusRequestEntityTooLarge}},
+	{method: "DELETE", path: "/budgets/{id}", id: "deleteBudget", summary: "Удаление бюджета", tag: "budgets",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+	{method: "GET", path: "/budgets/{id}/status", id: "getBudgetStatus", summary: "Расходы по бюджету за период", tag: "budgets",
+		query: []Parameter{
+			queryParam("date", "Дата периода YYYY-MM-DD или MM-YYYY, по умолчанию сегодня; месяцы после неё не учитываются", stringSchema()),
+		},
+		status: http.StatusOK, response: models.BudgetStatus{}, errors: []int{http.StatusNotFound}},
+
+	// API keys
+	{method: "GET", path: "/api-keys", id: "listAPIKeys", summary: "Список API-ключей", tag: "api-keys",
+		status: http.StatusOK, response: []models.APIKey{}},
+	{method: "POST", path: "/api-keys", id: "createAPIKey", summary: "Выпуск API-ключа", tag: "api-keys",
+		body: models.CreateAPIKeyRequest{}, status: http.StatusCreated, response: models.CreatedAPIKey{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+	{method: "DELETE", path: "/api-keys/{id}", id: "revokeAPIKey", summary: "Отзыв API-ключа", tag: "api-keys",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+
+	// Webhooks
+	{method: "GET", path: "/webhooks", id: "listWebhooks", summary: "Список вебхуков", tag: "webhooks",
+		status: http.StatusOK, response: []models.Webhook{}},
+	{method: "POST", path: "/webhooks", id: "createWebhook", summary: "Регистрация вебхука", tag: "webhooks",
+		body: models.CreateWebhookRequest{}, status: http.StatusCreated, response: models.CreatedWebhook{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+	{method: "GET", path: "/webhooks/{id}", id: "getWebhook", summary: "Вебхук по ID", tag: "webhooks",
+		status: http.StatusOK, response: models.Webhook{}, errors: []int{http.StatusNotFound}},
+	{method: "PUT", path: "/webhooks/{id}", id: "updateWebhook", summary: "Изменение вебхука", tag: "webhooks",
+		body: models.UpdateWebhookRequest{}, status: http.StatusOK, response: models.Webhook{},
+		errors: []int{http.StatusNotFound, http.StatusRequestEntityTooLarge}},
+	{method: "DELETE", path: "/webhooks/{id}", id: "deleteWebhook", summary: "Удаление вебхука", tag: "webhooks",
+		status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+	{method: "GET", path: "/webhooks/{id}/deliveries", id: "listWebhookDeliveries", summary: "Доставки событий вебхука", tag: "webhooks",
+		query: []Parameter{
+			queryParam("status", "Статус доставки; failed — недоставленные после всех попыток",
+				&Schema{Type: SchemaType{"string"}, Enum: []interface{}{models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed}}),
+			queryParam("limit", "Размер страницы от 1 до 1000, по умолчанию 100", integerSchema()),
+			queryParam("offset", "Смещение", integerSchema()),
+		},
+		status: http.StatusOK, response: []models.WebhookDelivery{}, errors: []int{http.StatusNotFound}},
+	{method: "POST", path: "/webhooks/{id}/deliveries/{delivery_id}/redeliver", id: "redeliverWebhookDelivery",
+		summary: "Повторная отправка события", tag: "webhooks",
+		status: http.StatusAccepted, response: models.WebhookDelivery{}, errors: []int{http.StatusNotFound}},
+
+	// GraphQL
+	{method: "POST", path: "/graphql", id: "graphql", summary: "Запрос GraphQL; ошибки запроса возвращаются в поле errors", tag: "graphql",
+		unversioned: true, body: models.GraphQLRequest{}, status: http.StatusOK, response: models.GraphQLResponse{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+}
+
+var v2Routes = []route{
+	{method: "POST", path: "/subscriptions/aggregate", id: "aggregateSubscriptions",
+		summary: "Суммарная стоимость подписок за период: каждая подписка, пересекающая период, за каждый месяц", tag: "subscriptions",
+		body: models.AggregationRequest{}, status: http.StatusOK, response: models.AggregationResponse{},
+		errors: []int{http.StatusRequestEntityTooLarge}},
+	{method: "PATCH", path: "/subscriptions/{id}", id: "patchSubscription", summary: "Изменение подписки (JSON Merge Patch)", tag: "subscriptions",
+		body: models.SubscriptionPatch{}, bodyTypes: []string{"application/merge-patch+json", "application/json"},
+		status: http.StatusOK, response: models.Subscription{},
+		errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}},
+}
+
+var tags = []Tag{
+	{Name: "subscriptions", Description: "Подписки и агрегация стоимости"},
+	{Name: "sharing", Description: "Совместная оплата подписок"},
+	{Name: "services", Description: "Каталог сервисов"},
+	{Name: "users", Description: "Пользователи"},
+	{Name: "households", Description: "Домохозяйства"},
+	{Name: "budgets", Description: "Бюджеты расходов и оповещения о превышении"},
+	{Name: "api-keys", Description: "API-ключи, требуется scope admin"},
+	{Name: "webhooks", Description: "Уведомления о событиях подписок и бюджетов, требуется scope admin"},
+	{Name: "graphql", Description: "Гибкие запросы подписок и расходов"},
+	{Name: "system", Description: "Служебные маршруты"},
+}
+
+// Errors every authenticated route may return
+var commonErrors = []int{
+	http.StatusBadRequest,
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+}
+
+// maxIdempotencyKeyLength mirrors middleware.MaxIdempotencyKeyLength
+var maxIdempotencyKeyLength = 255
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// Build assembles the OpenAPI document of the service
+func Build() *Document {
+	schemas := newSchemaBuilder()
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:   "Subscription Aggregator API",
+			Version: "1.0.0",
+			Description: "Учёт онлайн-подписок пользователей и расчёт их суммарной стоимости. " +
+				"Ошибки возвращаются текстом (text/plain), ошибки валидации перечисляются через \"; \". " +
+				"Маршруты без версии и /v1 устарели; /v2 оборачивает ответы в {\"data\": ...} и ошибки в {\"error\": ...}.",
+		},
+		Tags:  tags,
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Parameters: map[string]Parameter{
+				"TenantID": {
+					Name:        "X-Tenant-ID",
+					In:          "header",
+					Description: "Арендатор; не нужен, если он задан учётными данными или TENANT_REQUIRED=false",
+					Schema:      uuidSchema(),
+				},
+				"IdempotencyKey": {
+					Name:        "Idempotency-Key",
+					In:          "header",
+					Description: "Ключ повтора: ответ на первый запрос с ключом сохраняется на сутки и возвращается при повторах",
+					Schema:      &Schema{Type: SchemaType{"string"}, MaxLength: &maxIdempotencyKeyLength},
+				},
+			},
+			Responses: map[string]Response{
+				"Error": {
+					Description: "Ошибка",
+					Content:     map[string]MediaType{"text/plain": {Schema: stringSchema()}},
+				},
+				"ErrorEnvelope": {
+					Description: "Ошибка",
+					Content:     map[string]MediaType{"application/json": {Schema: schemas.ref(models.ErrorEnvelope{}, false)}},
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "API-ключ вида sa_..."},
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "JWT или API-ключ"},
+			},
+		},
+		// Credentials are checked only with AUTH_ENABLED=true
+		Security: []SecurityRequirement{{"apiKey": {}}, {"bearer": {}}},
+	}
+
+	for _, rt := range routes {
+		if rt.public || rt.unversioned {
+			doc.add(rt.path, rt.method, rt.operation(schemas, ""))
+			continue
+		}
+		doc.add(rt.path, rt.method, rt.operation(schemas, ""))
+		doc.add("/v1"+rt.path, rt.method, rt.operation(schemas, "v1"))
+		if !rt.v1Only {
+			doc.add("/v2"+rt.path, rt.method, rt.operation(schemas, "v2"))
+		}
+	}
+	for _, rt := range v2Routes {
+		doc.add("/v2"+rt.path, rt.method, rt.operation(schemas, "v2"))
+	}
+
+	doc.Components.Schemas = schemas.schemas
+	return doc
+}
+
+func (d *Document) add(path, method string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[lower(method)] = op
+}
+
+// operation describes the route in the API version: "" for unversioned
+// routes, "v1" or "v2"
+func (rt route) operation(schemas *schemaBuilder, version string) *Operation {
+	op := &Operation{
+		OperationID: rt.id,
+		Summary:     rt.summary,
+		Tags:        []string{rt.tag},
+		Responses:   make(map[string]Response),
+		Deprecated:  !rt.public && !rt.unversioned && version != "v2",
+	}
+	if version != "" {
+		op.OperationID = version + strings.ToUpper(rt.id[:1]) + rt.id[1:]
+	}
+	envelope := version == "v2"
+
+	for _, name := range pathParams(rt.path) {
+		// Path variables are IDs, except the dates of price changes
+		schema := uuidSchema()
+		if name == "date" {
+			schema = &Schema{Type: SchemaType{"string"}, Format: "date"}
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	op.Parameters = append(op.Parameters, rt.query...)
+
+	if rt.public {
+		// An empty requirement list overrides the document security
+		op.Security = []SecurityRequirement{{}}
+	} else {
+		op.Parameters = append(op.Parameters, Parameter{Ref: "#/components/parameters/TenantID"})
+	}
+	idempotent := !rt.public && (rt.method == http.MethodPost || rt.method == http.MethodPatch)
+	if idempotent {
+		op.Parameters = append(op.Parameters, Parameter{Ref: "#/components/parameters/IdempotencyKey"})
+	}
+
+	if rt.body != nil {
+		bodyTypes := rt.bodyTypes
+		if bodyTypes == nil {
+			bodyTypes = []string{"application/json"}
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: make(map[string]MediaType)}
+		for _, mediaType := range bodyTypes {
+			op.RequestBody.Content[mediaType] = MediaType{Schema: schemas.ref(rt.body, true)}
+		}
+	}
+
+	success := Response{Description: http.StatusText(rt.status)}
+	if rt.response != nil {
+		schema := schemas.ref(rt.response, false)
+		if envelope {
+			schema = &Schema{
+				Type:       SchemaType{"object"},
+				Properties: map[string]*Schema{"data": schema},
+				Required:   []string{"data"},
+			}
+		}
+		success.Content = map[string]MediaType{"application/json": {Schema: schema}}
+	}
+	op.Responses[strconv.Itoa(rt.status)] = success
+
+	errors := rt.errors
+	if !rt.public {
+		errors = append(append([]int{}, commonErrors...), errors...)
+	}
+	if idempotent {
+		errors = append(errors, http.StatusConflict, http.StatusUnprocessableEntity)
+	}
+	errorRef := "#/components/responses/Error"
+	if envelope {
+		errorRef = "#/components/responses/ErrorEnvelope"
+	}
+	for _, status := range errors {
+		op.Responses[strconv.Itoa(status)] = Response{Ref: errorRef}
+	}
+
+	return op
+}
+
+// pathParams returns the variable names of a path template
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.Trim(segment, "{}"))
+		}
+	}
+	return names
+}
+
+func queryParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func stringSchema() *Schema {
+	return &Schema{Type: SchemaType{"string"}}
+}
+
+func uuidSchema() *Schema {
+	return &Schema{Type: SchemaType{"string"}, Format: "uuid"}
+}
+
+func integerSchema() *Schema {
+	return &Schema{Type: SchemaType{"integer"}}
+}
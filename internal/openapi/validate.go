@@ -0,0 +1,297 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"subscription-aggregator/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+// Compiled schema patterns, shared by all documents
+var patterns sync.Map
+
+// ValidateValue checks a decoded JSON value against the schema. Values are
+// expected as produced by a json.Decoder with UseNumber. Problems are
+// reported by field path with the messages of the validation package.
+func (d *Document) ValidateValue(schema *Schema, value interface{}, path string) validation.ValidationErrors {
+	return d.validate(schema, value, subject{path: path})
+}
+
+// ValidateParameter checks a raw path, query or header value against the
+// parameter schema
+func (d *Document) ValidateParameter(param Parameter, raw string) validation.ValidationErrors {
+	target := subject{path: param.Name, param: true}
+	schema := d.Resolve(param.Schema)
+	if schema == nil {
+		return nil
+	}
+
+	var value interface{} = raw
+	switch {
+	case schema.Type.Has("integer"), schema.Type.Has("number"):
+		value = json.Number(raw)
+	case schema.Type.Has("boolean"):
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return target.fail("должно быть true или false")
+		}
+		value = parsed
+	}
+
+	return d.validate(schema, value, target)
+}
+
+// Parameter resolves a "#/components/parameters/..." reference
+func (d *Document) Parameter(param Parameter) Parameter {
+	if name, ok := strings.CutPrefix(param.Ref, "#/components/parameters/"); ok {
+		return d.Components.Parameters[name]
+	}
+	return param
+}
+
+// Response resolves a "#/components/responses/..." reference
+func (d *Document) Response(response Response) Response {
+	if name, ok := strings.CutPrefix(response.Ref, "#/components/responses/"); ok {
+		return d.Components.Responses[name]
+	}
+	return response
+}
+
+// subject names the checked value in messages: body fields are "поле X",
+// parameters "параметр X"
+type subject struct {
+	path  string
+	param bool
+}
+
+func (s subject) field(name string) subject {
+	if s.path == "" {
+		return subject{path: name, param: s.param}
+	}
+	return subject{path: s.path + "." + name, param: s.param}
+}
+
+func (s subject) index(i int) subject {
+	return subject{path: fmt.Sprintf("%s[%d]", s.path, i), param: s.param}
+}
+
+// fail reports the value; the message continues "значение поля X ..."
+func (s subject) fail(message string) validation.ValidationErrors {
+	noun := "поля"
+	if s.param {
+		noun = "параметра"
+	}
+	return validation.ValidationErrors{{
+		Field:   s.path,
+		Message: fmt.Sprintf("значение %s %s %s", noun, s.path, message),
+	}}
+}
+
+func (s subject) failLength(message string) validation.ValidationErrors {
+	noun := "поля"
+	if s.param {
+		noun = "параметра"
+	}
+	return validation.ValidationErrors{{
+		Field:   s.path,
+		Message: fmt.Sprintf("длина %s %s должна %s", noun, s.path, message),
+	}}
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, target subject) validation.ValidationErrors {
+	schema = d.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	if value == nil {
+		if len(schema.Type) == 0 || schema.Type.Has("null") {
+			return nil
+		}
+		return target.fail("не может быть null")
+	}
+
+	if schema.Enum != nil && !inEnum(schema.Enum, value) {
+		var allowed []string
+		for _, option := range schema.Enum {
+			if option != nil {
+				allowed = append(allowed, fmt.Sprint(option))
+			}
+		}
+		return target.fail("должно быть одним из: " + strings.Join(allowed, ", "))
+	}
+
+	// Schemas without a type accept any value
+	if len(schema.Type) == 0 {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		if !schema.Type.Has("string") {
+			return target.fail("должно иметь тип " + typeName(schema.Type))
+		}
+		return validateString(schema, v, target)
+
+	case json.Number:
+		return validateNumber(schema, v, target)
+
+	case bool:
+		if !schema.Type.Has("boolean") {
+			return target.fail("должно иметь тип " + typeName(schema.Type))
+		}
+
+	case []interface{}:
+		if !schema.Type.Has("array") {
+			return target.fail("должно иметь тип " + typeName(schema.Type))
+		}
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			return target.failLength(fmt.Sprintf("быть не меньше %d", *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			return target.failLength(fmt.Sprintf("быть не больше %d", *schema.MaxItems))
+		}
+		var errors validation.ValidationErrors
+		for i, item := range v {
+			errors = append(errors, d.validate(schema.Items, item, target.index(i))...)
+		}
+		return errors
+
+	case map[string]interface{}:
+		if !schema.Type.Has("object") {
+			return target.fail("должно иметь тип " + typeName(schema.Type))
+		}
+		return d.validateObject(schema, v, target)
+	}
+
+	return nil
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]interface{}, target subject) validation.ValidationErrors {
+	var errors validation.ValidationErrors
+
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			field := target.field(name)
+			errors = append(errors, validation.ValidationError{
+				Field:   field.path,
+				Message: fmt.Sprintf("поле %s обязательно", field.path),
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(object) {
+		property, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				field := target.field(name)
+				errors = append(errors, validation.ValidationError{Field: field.path, Message: "поле не разрешено"})
+			}
+			continue
+		}
+		errors = append(errors, d.validate(property, object[name], target.field(name))...)
+	}
+
+	return errors
+}
+
+func validateString(schema *Schema, value string, target subject) validation.ValidationErrors {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		if *schema.MinLength == 1 && !target.param {
+			return validation.ValidationErrors{{Field: target.path, Message: fmt.Sprintf("поле %s обязательно", target.path)}}
+		}
+		return target.failLength(fmt.Sprintf("быть не меньше %d", *schema.MinLength))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return target.failLength(fmt.Sprintf("быть не больше %d", *schema.MaxLength))
+	}
+
+	if schema.Pattern != "" {
+		compiled, ok := patterns.Load(schema.Pattern)
+		if !ok {
+			compiled, _ = patterns.LoadOrStore(schema.Pattern, regexp.MustCompile(schema.Pattern))
+		}
+		if !compiled.(*regexp.Regexp).MatchString(value) {
+			return target.fail("имеет неверный формат")
+		}
+	}
+
+	// Like the validate tags, formats do not apply to empty strings
+	if value == "" {
+		return nil
+	}
+
+	switch schema.Format {
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return target.fail("должно быть в формате UUID")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return target.fail("должно быть в формате RFC 3339")
+		}
+	case "email":
+		if at := strings.Index(value, "@"); at <= 0 || at == len(value)-1 {
+			return target.fail("должно быть адресом электронной почты")
+		}
+	}
+
+	return nil
+}
+
+func validateNumber(schema *Schema, value json.Number, target subject) validation.ValidationErrors {
+	switch {
+	case schema.Type.Has("integer"):
+		if _, err := strconv.ParseInt(value.String(), 10, 64); err != nil {
+			return target.fail("должно быть целым числом")
+		}
+	case schema.Type.Has("number"):
+	default:
+		return target.fail("должно иметь тип " + typeName(schema.Type))
+	}
+
+	number, err := value.Float64()
+	if err != nil {
+		return target.fail("должно быть числом")
+	}
+	if schema.Minimum != nil && number < *schema.Minimum {
+		return target.fail("должно быть не меньше " + formatNumber(*schema.Minimum))
+	}
+	if schema.Maximum != nil && number > *schema.Maximum {
+		return target.fail("должно быть не больше " + formatNumber(*schema.Maximum))
+	}
+
+	return nil
+}
+
+func inEnum(options []interface{}, value interface{}) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName names the expected JSON type in messages
+func typeName(types SchemaType) string {
+	var names []string
+	for _, typ := range types {
+		if typ != "null" {
+			names = append(names, typ)
+		}
+	}
+	return strings.Join(names, " или ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
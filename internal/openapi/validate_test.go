@@ -0,0 +1,143 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"subscription-aggregator/internal/validation"
+)
+
+func decodeValue(t *testing.T, body string) interface{} {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func fields(errs validation.ValidationErrors) string {
+	names := make([]string, 0, len(errs))
+	for _, err := range errs {
+		if err.Field == "" {
+			names = append(names, "$")
+			continue
+		}
+		names = append(names, err.Field)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestValidateValue(t *testing.T) {
+	closed := false
+	minimum, maximum := 0.0, 100.0
+	one, two := 1, 2
+	doc := &Document{Components: Components{Schemas: map[string]*Schema{
+		"Item": {
+			Type:                 SchemaType{"object"},
+			Properties:           map[string]*Schema{"name": {Type: SchemaType{"string"}, MinLength: &one}},
+			Required:             []string{"name"},
+			AdditionalProperties: &closed,
+		},
+	}}}
+	schema := &Schema{
+		Type: SchemaType{"object"},
+		Properties: map[string]*Schema{
+			"id":      {Type: SchemaType{"string"}, Format: "uuid"},
+			"price":   {Type: SchemaType{"integer"}, Minimum: &minimum, Maximum: &maximum},
+			"ratio":   {Type: SchemaType{"number"}},
+			"active":  {Type: SchemaType{"boolean"}},
+			"end":     {Type: SchemaType{"string", "null"}, Format: "date-time"},
+			"email":   {Type: SchemaType{"string"}, Format: "email"},
+			"code":    {Type: SchemaType{"string"}, Pattern: "^[A-Z]{3}$", MaxLength: &two},
+			"kind":    {Type: SchemaType{"string"}, Enum: []interface{}{"fast", "slow"}},
+			"tags":    {Type: SchemaType{"array"}, MaxItems: &two, Items: &Schema{Type: SchemaType{"string"}, MinLength: &one}},
+			"item":    {Ref: "#/components/schemas/Item"},
+			"any":     {},
+			"comment": {Type: SchemaType{"string"}},
+		},
+		Required:             []string{"price"},
+		AdditionalProperties: &closed,
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantFields string // Fields reported, "$" for the value itself
+	}{
+		{"valid", `{"id":"7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e","price":100,"ratio":0.5,"active":true,"end":null,
+			"email":"a@b.c","kind":"slow","tags":["a","b"],"item":{"name":"x"},"any":[1,{}],"comment":""}`, ""},
+		{"unknown field", `{"price":1,"owner":"x"}`, "owner"},
+		{"unknown nested field", `{"price":1,"item":{"name":"x","extra":1}}`, "item.extra"},
+		{"missing required", `{}`, "price"},
+		{"missing nested required", `{"price":1,"item":{}}`, "item.name"},
+		{"string for integer", `{"price":"100"}`, "price"},
+		{"fraction for integer", `{"price":1.5}`, "price"},
+		{"below minimum", `{"price":-1}`, "price"},
+		{"above maximum", `{"price":101}`, "price"},
+		{"number for string", `{"price":1,"comment":5}`, "comment"},
+		{"string for boolean", `{"price":1,"active":"yes"}`, "active"},
+		{"null for non-nullable", `{"price":null}`, "price"},
+		{"invalid uuid", `{"price":1,"id":"nope"}`, "id"},
+		{"invalid date-time", `{"price":1,"end":"2025-01-01"}`, "end"},
+		{"invalid email", `{"price":1,"email":"nobody"}`, "email"},
+		{"too long", `{"price":1,"code":"ABC"}`, "code"},
+		{"not an enum value", `{"price":1,"kind":"medium"}`, "kind"},
+		{"too many items", `{"price":1,"tags":["a","b","c"]}`, "tags"},
+		{"invalid item", `{"price":1,"tags":["a",""]}`, "tags[1]"},
+		{"object for array", `{"price":1,"tags":{}}`, "tags"},
+		{"several problems", `{"price":"x","kind":"medium","owner":1}`, "kind,owner,price"},
+		{"array for object", `[]`, "$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := doc.ValidateValue(schema, decodeValue(t, tt.body), "")
+			if got := fields(errs); got != tt.wantFields {
+				t.Fatalf("ValidateValue() fields = %q, want %q (%v)", got, tt.wantFields, errs)
+			}
+			for _, err := range errs {
+				if err.Message == "" {
+					t.Errorf("error of %s has no message", err.Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateParameter(t *testing.T) {
+	doc := &Document{}
+	minimum := 1.0
+
+	tests := []struct {
+		name    string
+		schema  *Schema
+		raw     string
+		wantErr bool
+	}{
+		{"uuid", uuidSchema(), "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e", false},
+		{"invalid uuid", uuidSchema(), "nope", true},
+		{"integer", integerSchema(), "42", false},
+		{"invalid integer", integerSchema(), "ten", true},
+		{"fraction for integer", integerSchema(), "1.5", true},
+		{"below minimum", &Schema{Type: SchemaType{"integer"}, Minimum: &minimum}, "0", true},
+		{"boolean", &Schema{Type: SchemaType{"boolean"}}, "true", false},
+		{"invalid boolean", &Schema{Type: SchemaType{"boolean"}}, "maybe", true},
+		{"enum", &Schema{Type: SchemaType{"string"}, Enum: []interface{}{"service", "category"}}, "tag", true},
+		{"no schema", nil, "anything", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := doc.ValidateParameter(Parameter{Name: "p", In: "query", Schema: tt.schema}, tt.raw)
+			if (len(errs) > 0) != tt.wantErr {
+				t.Fatalf("ValidateParameter() = %v, want error %v", errs, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(errs[0].Message, "параметра p") {
+				t.Errorf("message %q does not name the parameter", errs[0].Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink produces messages to a topic keyed by subscription, so the
+// events of a subscription share a partition and keep their order
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaSink(brokers []string, topic string) *KafkaSink {
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// The relay publishes one message at a time and waits for it
+			BatchSize: 1,
+		},
+	}
+}
+
+func (s *KafkaSink) Publish(ctx context.Context, msg Message) error {
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.Key.String()),
+		Value: msg.Payload,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(msg.EventID.String())},
+			{Key: "event_type", Value: []byte(msg.EventType)},
+			{Key: "tenant_id", Value: []byte(msg.TenantID.String())},
+		},
+		Time: msg.CreatedAt,
+	})
+}
+
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
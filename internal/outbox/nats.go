@@ -0,0 +1,56 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSink publishes messages to <subject>.<event type>. With JetStream
+// each publish waits for the stream acknowledgement and the event ID is
+// sent as Nats-Msg-Id, so the stream drops duplicates; core NATS only
+// flushes to the server.
+type NATSSink struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+func NewNATSSink(url, subject string, jetStream bool) (*NATSSink, error) {
+	conn, err := nats.Connect(url, nats.Name("subscription-aggregator"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &NATSSink{conn: conn, subject: subject}
+	if jetStream {
+		if sink.js, err = conn.JetStream(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return sink, nil
+}
+
+func (s *NATSSink) Publish(ctx context.Context, msg Message) error {
+	natsMsg := nats.NewMsg(s.subject + "." + msg.EventType)
+	natsMsg.Data = msg.Payload
+	natsMsg.Header.Set(nats.MsgIdHdr, msg.EventID.String())
+	natsMsg.Header.Set("Tenant-Id", msg.TenantID.String())
+	natsMsg.Header.Set("Subscription-Id", msg.Key.String())
+
+	if s.js != nil {
+		_, err := s.js.PublishMsg(natsMsg, nats.Context(ctx))
+		return err
+	}
+
+	if err := s.conn.PublishMsg(natsMsg); err != nil {
+		return err
+	}
+	return s.conn.FlushWithContext(ctx)
+}
+
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Message is an outbox row as handed to sinks. Payload is the JSON encoded
+// models.Event; Key is the subscription or budget the event is about,
+// messages with the same key reach a sink in the order they were written.
+type Message struct {
+	ID        int64     `db:"id"`
+	TenantID  uuid.UUID `db:"tenant_id"`
+	EventID   uuid.UUID `db:"event_id"`
+	EventType string    `db:"event_type"`
+	Key       uuid.UUID `db:"aggregate_id"`
+	Payload   []byte    `db:"payload"`
+	Attempts  int       `db:"attempts"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Write records the event about the subscription. Called with the
+// transaction of the change, the event exists exactly when it commits.
+func Write(q database.Querier, eventType string, subscription models.Subscription) error {
+	return write(q, subscription.TenantID, subscription.ID, eventType, subscription)
+}
+
+// WriteBudgetAlert records the budget.threshold_crossed event of the alert
+func WriteBudgetAlert(q database.Querier, alert models.BudgetAlert) error {
+	return write(q, alert.Budget.TenantID, alert.Budget.ID, models.EventBudgetThresholdCrossed, alert)
+}
+
+func write(q database.Querier, tenantID, key uuid.UUID, eventType string, data interface{}) error {
+	event := models.Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO outbox (tenant_id, event_id, event_type, aggregate_id, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		tenantID, event.ID, eventType, key, string(payload), event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to write %s event: %w", eventType, err)
+	}
+
+	return nil
+}
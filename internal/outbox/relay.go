@@ -0,0 +1,317 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"time"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// relayLockID is the advisory lock letting one relay claim messages at a time
+const relayLockID = 0x6f7574626f78 // "outbox"
+
+// How often the relay records subscriptions whose end date passed and
+// removes old published messages
+const (
+	endedSweepInterval = 10 * time.Minute
+	cleanupInterval    = time.Hour
+)
+
+// RelayOptions configures the relay; zero values take the defaults
+type RelayOptions struct {
+	PollInterval time.Duration // 1s
+	BatchSize    int           // Messages per round, 100
+	MinBackoff   time.Duration // Delay after the first failure, doubled after each next one, 1s
+	MaxBackoff   time.Duration // 5m
+	MaxAttempts  int           // Attempts before a message is set aside as a dead letter, 20 (about an hour)
+	Retention    time.Duration // Published messages are kept this long, 7 days
+
+	PublishTimeout time.Duration // Bound of publishing one message, 30s
+	Lease          time.Duration // Claimed messages stay with the relay this long, 5m and at least two PublishTimeouts
+}
+
+// Relay publishes outbox messages to a sink, at least once and in order per
+// subscription: a message waits until the earlier messages of its
+// subscription are published. Failed ones are retried until MaxAttempts,
+// then set aside as dead letters so they stop holding up the messages
+// after them. Replicas may all run a relay, each publishes the messages it
+// claimed.
+type Relay struct {
+	db     *sqlx.DB
+	sink   Sink
+	opts   RelayOptions
+	logger *logrus.Logger
+}
+
+func NewRelay(db *sqlx.DB, sink Sink, opts RelayOptions, logger *logrus.Logger) *Relay {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 20
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	if opts.PublishTimeout <= 0 {
+		opts.PublishTimeout = 30 * time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 5 * time.Minute
+	}
+	opts.Lease = max(opts.Lease, 2*opts.PublishTimeout)
+
+	return &Relay{
+		db:     db,
+		sink:   sink,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Run publishes messages until the context is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+
+	var lastSweep, lastCleanup time.Time
+	for {
+		if time.Since(lastSweep) >= endedSweepInterval {
+			if err := r.sweepEnded(); err != nil {
+				r.logger.WithError(err).Error("Failed to record ended subscriptions")
+			}
+			lastSweep = time.Now()
+		}
+
+		if time.Since(lastCleanup) >= cleanupInterval {
+			_, err := r.db.Exec(`DELETE FROM outbox WHERE published_at < NOW() - $1 * INTERVAL '1 second'`,
+				r.opts.Retention.Seconds())
+			if err != nil {
+				r.logger.WithError(err).Error("Failed to remove published outbox messages")
+			}
+			lastCleanup = time.Now()
+		}
+
+		// Keep going while messages get published, a round only takes
+		// the oldest message of each subscription
+		for ctx.Err() == nil {
+			published, err := r.publishDue(ctx)
+			if err != nil {
+				r.logger.WithError(err).Error("Failed to relay outbox messages")
+			}
+			if err != nil || published == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDue publishes the oldest unpublished message of each subscription
+// when it is due and returns how many were published.
+//
+// Sinks may be slow, so messages are not published inside a transaction.
+// A short one holding the advisory lock claims a batch by moving its
+// next_attempt_at past the lease; each message is then published with
+// PublishTimeout and its result saved right away, and the claims left when
+// the lease runs short are released. A claimed message is still the oldest
+// of its subscription, so other relays skip it and the messages after it.
+// Claims of a relay that dies mid-batch expire with the lease, and messages
+// it published but did not mark are published again.
+func (r *Relay) publishDue(ctx context.Context) (int, error) {
+	deadline := time.Now().Add(r.opts.Lease)
+	messages, err := r.claim(deadline)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+
+	published, handled, err := r.publish(ctx, messages, deadline, r.mark)
+	if rest := messages[handled:]; len(rest) > 0 {
+		if err := r.release(rest); err != nil {
+			r.logger.WithError(err).Error("Failed to release claimed outbox messages")
+		}
+	}
+	return published, err
+}
+
+// claim takes the due messages for the relay until the given time
+func (r *Relay) claim(until time.Time) ([]Message, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.Get(&locked, `SELECT pg_try_advisory_xact_lock($1)`, relayLockID); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	var messages []Message
+	err = tx.Select(&messages, `
+		UPDATE outbox SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT DISTINCT ON (aggregate_id) id, next_attempt_at FROM outbox
+				WHERE published_at IS NULL AND failed_at IS NULL
+				ORDER BY aggregate_id, id
+			) head
+			WHERE next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+		)
+		RETURNING id, tenant_id, event_id, event_type, aggregate_id, payload, attempts, created_at`,
+		r.opts.BatchSize, until)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+
+	return messages, tx.Commit()
+}
+
+// publish publishes the messages in order and passes each result to mark.
+// It stops when the context is done, mark fails or the next publish could
+// outlast the deadline, and returns how many messages were published and
+// how many were handled, published or not.
+func (r *Relay) publish(ctx context.Context, messages []Message, deadline time.Time, mark func(Message, error) error) (int, int, error) {
+	published := 0
+	for handled, msg := range messages {
+		if ctx.Err() != nil || time.Now().Add(r.opts.PublishTimeout).After(deadline) {
+			return published, handled, nil
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, r.opts.PublishTimeout)
+		publishErr := r.sink.Publish(publishCtx, msg)
+		cancel()
+		if publishErr != nil && ctx.Err() != nil {
+			// Stopped, not failed
+			return published, handled, nil
+		}
+
+		if err := mark(msg, publishErr); err != nil {
+			return published, handled + 1, err
+		}
+		if publishErr == nil {
+			published++
+		}
+	}
+	return published, len(messages), nil
+}
+
+// mark saves the result of publishing a message: published, or failed and
+// due again after the backoff unless it is set aside as a dead letter
+func (r *Relay) mark(msg Message, publishErr error) error {
+	if publishErr == nil {
+		_, err := r.db.Exec(`UPDATE outbox SET attempts = attempts + 1, last_error = NULL, published_at = NOW() WHERE id = $1`, msg.ID)
+		return err
+	}
+
+	attempts := msg.Attempts + 1
+	logger := r.logger.WithError(publishErr).WithFields(logrus.Fields{
+		"event_id":   msg.EventID,
+		"event_type": msg.EventType,
+		"attempts":   attempts,
+	})
+
+	var failedAt *time.Time
+	if r.deadLetter(attempts) {
+		now := time.Now()
+		failedAt = &now
+		logger.Error("Outbox message failed for good, later messages of its aggregate go on")
+	} else {
+		logger.Warn("Failed to publish outbox message")
+	}
+
+	_, err := r.db.Exec(`UPDATE outbox SET attempts = $2, next_attempt_at = $3, last_error = $4, failed_at = $5 WHERE id = $1`,
+		msg.ID, attempts, time.Now().Add(r.backoff(attempts)), publishErr.Error(), failedAt)
+	return err
+}
+
+// release makes claimed messages due again
+func (r *Relay) release(messages []Message) error {
+	ids := make([]int64, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	_, err := r.db.Exec(`UPDATE outbox SET next_attempt_at = NOW() WHERE id = ANY($1) AND published_at IS NULL`, pq.Array(ids))
+	return err
+}
+
+// deadLetter reports whether a message is given up after the given number
+// of failed attempts
+func (r *Relay) deadLetter(attempts int) bool {
+	return attempts >= r.opts.MaxAttempts
+}
+
+// backoff returns the delay before the attempt following the given number
+// of failed ones
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.opts.MinBackoff
+	for i := 1; i < attempts && delay < r.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.opts.MaxBackoff)
+}
+
+// sweepEnded records subscription.ended for subscriptions whose end date
+// passed. subscription_end_events remembers recorded end dates, so each is
+// recorded once even with several relays.
+func (r *Relay) sweepEnded() error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var ended []records.Subscription
+	err = tx.Select(&ended, `
+		WITH ended AS (
+			INSERT INTO subscription_end_events (tenant_id, subscription_id, end_date)
+			SELECT tenant_id, id, end_date FROM subscriptions WHERE end_date < CURRENT_DATE
+			ON CONFLICT DO NOTHING
+			RETURNING tenant_id, subscription_id
+		)
+		SELECT s.* FROM subscriptions s
+		JOIN ended e ON e.tenant_id = s.tenant_id AND e.subscription_id = s.id`)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range ended {
+		if err := Write(tx, models.EventSubscriptionEnded, subscription.Model()); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(ended) > 0 {
+		r.logger.WithField("count", len(ended)).Info("Recorded ended subscriptions")
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func testRelay(opts RelayOptions) *Relay {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewRelay(nil, MultiSink{}, opts, logger)
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := testRelay(RelayOptions{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := relay.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRelayDeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     RelayOptions
+		attempts int
+		want     bool
+	}{
+		{"retried below the limit", RelayOptions{MaxAttempts: 3}, 2, false},
+		{"given up at the limit", RelayOptions{MaxAttempts: 3}, 3, true},
+		{"given up past the limit", RelayOptions{MaxAttempts: 3}, 4, true},
+		{"default limit", RelayOptions{}, 19, false},
+		{"default limit reached", RelayOptions{}, 20, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testRelay(tt.opts).deadLetter(tt.attempts); got != tt.want {
+				t.Fatalf("deadLetter(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+// funcSink publishes messages with a function
+type funcSink func(ctx context.Context, msg Message) error
+
+func (f funcSink) Publish(ctx context.Context, msg Message) error { return f(ctx, msg) }
+
+func (f funcSink) Close() error { return nil }
+
+func TestRelayPublish(t *testing.T) {
+	messages := []Message{{ID: 1}, {ID: 2}, {ID: 3}}
+	errDown := errors.New("broker is down")
+
+	tests := []struct {
+		name          string
+		publish       func(ctx context.Context, msg Message, cancel context.CancelFunc) error
+		markErr       error
+		lease         time.Duration // From now to the deadline, a minute when zero
+		wantPublished int
+		wantHandled   int
+		wantMarked    []string // Results passed to mark
+		wantErr       bool
+	}{
+		{
+			name:          "all published",
+			publish:       func(context.Context, Message, context.CancelFunc) error { return nil },
+			wantPublished: 3, wantHandled: 3,
+			wantMarked: []string{"1 ok", "2 ok", "3 ok"},
+		},
+		{
+			name: "failures are marked and the batch goes on",
+			publish: func(_ context.Context, msg Message, _ context.CancelFunc) error {
+				if msg.ID == 2 {
+					return errDown
+				}
+				return nil
+			},
+			wantPublished: 2, wantHandled: 3,
+			wantMarked: []string{"1 ok", "2 broker is down", "3 ok"},
+		},
+		{
+			name: "slow publish times out",
+			publish: func(ctx context.Context, msg Message, _ context.CancelFunc) error {
+				if msg.ID == 1 {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				return nil
+			},
+			wantPublished: 2, wantHandled: 3,
+			wantMarked: []string{"1 context deadline exceeded", "2 ok", "3 ok"},
+		},
+		{
+			name: "stopped relay leaves the rest",
+			publish: func(ctx context.Context, msg Message, cancel context.CancelFunc) error {
+				if msg.ID == 2 {
+					cancel()
+					return ctx.Err()
+				}
+				return nil
+			},
+			wantPublished: 1, wantHandled: 1,
+			wantMarked: []string{"1 ok"},
+		},
+		{
+			name:          "failed mark stops the batch",
+			publish:       func(context.Context, Message, context.CancelFunc) error { return nil },
+			markErr:       errors.New("database is down"),
+			wantPublished: 0, wantHandled: 1,
+			wantMarked: []string{"1 ok"},
+			wantErr:    true,
+		},
+		{
+			name:          "lease too short for a publish",
+			publish:       func(context.Context, Message, context.CancelFunc) error { return nil },
+			lease:         5 * time.Millisecond,
+			wantPublished: 0, wantHandled: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			relay := testRelay(RelayOptions{PublishTimeout: 10 * time.Millisecond})
+			relay.sink = funcSink(func(ctx context.Context, msg Message) error { return tt.publish(ctx, msg, cancel) })
+
+			var marked []string
+			mark := func(msg Message, err error) error {
+				result := "ok"
+				if err != nil {
+					result = err.Error()
+				}
+				marked = append(marked, strconv.FormatInt(msg.ID, 10)+" "+result)
+				return tt.markErr
+			}
+
+			lease := tt.lease
+			if lease == 0 {
+				lease = time.Minute
+			}
+			published, handled, err := relay.publish(ctx, messages, time.Now().Add(lease), mark)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("publish() error = %v, want error %v", err, tt.wantErr)
+			}
+			if published != tt.wantPublished || handled != tt.wantHandled {
+				t.Errorf("publish() = %d published, %d handled, want %d, %d", published, handled, tt.wantPublished, tt.wantHandled)
+			}
+			if !reflect.DeepEqual(marked, tt.wantMarked) {
+				t.Errorf("marked %q, want %q", marked, tt.wantMarked)
+			}
+		})
+	}
+}
+
+func TestRelayLease(t *testing.T) {
+	tests := []struct {
+		opts RelayOptions
+		want time.Duration
+	}{
+		{RelayOptions{}, 5 * time.Minute},
+		{RelayOptions{Lease: time.Minute}, time.Minute},
+		{RelayOptions{Lease: time.Minute, PublishTimeout: 45 * time.Second}, 90 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := testRelay(tt.opts).opts.Lease; got != tt.want {
+			t.Errorf("lease of %+v = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+type failingSink struct{ published int }
+
+func (s *failingSink) Publish(context.Context, Message) error {
+	s.published++
+	return errors.New("broker is down")
+}
+
+func (s *failingSink) Close() error { return nil }
+
+func TestMultiSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	file, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	failing := &failingSink{}
+
+	msg := Message{
+		ID:        1,
+		TenantID:  uuid.New(),
+		EventID:   uuid.New(),
+		EventType: "subscription.created",
+		Key:       uuid.New(),
+		Payload:   []byte(`{"price":599}`),
+		CreatedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// A failing sink fails the message after the sinks before it
+	if err := (MultiSink{file, failing}).Publish(context.Background(), msg); err == nil {
+		t.Fatal("Publish() error = nil, want the error of the failing sink")
+	}
+	if err := (MultiSink{failing, file}).Publish(context.Background(), msg); err == nil {
+		t.Fatal("Publish() error = nil, want the error of the failing sink")
+	}
+	if failing.published != 2 {
+		t.Fatalf("failing sink got %d messages, want 2", failing.published)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var lines []fileRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record fileRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, record)
+	}
+
+	if len(lines) != 1 {
+		t.Fatalf("file has %d lines, want 1", len(lines))
+	}
+	if got := lines[0]; got.EventID != msg.EventID || got.Key != msg.Key || string(got.Event) != `{"price":599}` {
+		t.Fatalf("line = %+v, want the message", got)
+	}
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sink publishes outbox messages. Publish returns once the message is
+// stored by the receiving system; an error makes the relay retry it, so
+// sinks may see a message more than once.
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// MultiSink publishes every message to each sink in turn. A failing sink
+// makes the message retried on all of them.
+type MultiSink []Sink
+
+func (m MultiSink) Publish(ctx context.Context, msg Message) error {
+	for _, sink := range m {
+		if err := sink.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m MultiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// FileSink appends messages to a file as JSON lines, for tests and local
+// development
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// fileRecord is a line of a FileSink file
+type fileRecord struct {
+	EventID   uuid.UUID       `json:"event_id"`
+	TenantID  uuid.UUID       `json:"tenant_id"`
+	EventType string          `json:"event_type"`
+	Key       uuid.UUID       `json:"key"`
+	Event     json.RawMessage `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Publish(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(fileRecord{
+		EventID:   msg.EventID,
+		TenantID:  msg.TenantID,
+		EventType: msg.EventType,
+		Key:       msg.Key,
+		Event:     msg.Payload,
+		CreatedAt: msg.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
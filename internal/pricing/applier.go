@@ -0,0 +1,195 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"subscription-aggregator/internal/outbox"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/rollup"
+	"subscription-aggregator/internal/rules"
+	"subscription-aggregator/internal/subscriptions"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Options configures the applier; zero values take the defaults
+type Options struct {
+	Interval time.Duration // 1h
+}
+
+// Applier switches subscriptions of every tenant to their scheduled prices
+// once the effective date comes, recording subscription.updated events.
+// The price each change replaces is kept in subscription_prices, so months
+// before the change are still charged at it. Applied changes are removed;
+// a change the subscription no longer admits, e.g. because fixed shares
+// grew above it, is dropped with a warning.
+type Applier struct {
+	db     *sqlx.DB
+	opts   Options
+	logger *logrus.Logger
+}
+
+func NewApplier(db *sqlx.DB, opts Options, logger *logrus.Logger) *Applier {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+
+	return &Applier{
+		db:     db,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Run applies price changes until the context is done
+func (a *Applier) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.RunOnce(time.Now()); err != nil {
+			a.logger.WithError(err).Error("Failed to apply price changes")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce applies the changes effective by the time
+func (a *Applier) RunOnce(now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Replicas running concurrently skip the changes claimed here
+	var due []records.PriceChange
+	err = tx.Select(&due, `
+		DELETE FROM subscription_price_changes
+		WHERE (tenant_id, subscription_id, effective_date) IN (
+			SELECT tenant_id, subscription_id, effective_date FROM subscription_price_changes
+			WHERE effective_date <= $1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING *`, today)
+	if err != nil {
+		return err
+	}
+
+	// Changes missed while the applier was down are applied together, in
+	// the order of their effective dates
+	type subscriptionKey struct{ tenantID, subscriptionID uuid.UUID }
+	bySubscription := make(map[subscriptionKey][]models.PriceChange)
+	for _, change := range due {
+		key := subscriptionKey{change.TenantID, change.SubscriptionID}
+		bySubscription[key] = append(bySubscription[key], change.Model())
+	}
+
+	applied := 0
+	for _, changes := range bySubscription {
+		ok, err := a.apply(tx, changes)
+		if err != nil {
+			return err
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if applied > 0 {
+		a.logger.WithField("count", applied).Info("Price changes applied")
+	}
+
+	return nil
+}
+
+// apply switches the subscription to the price of its latest change,
+// recording the prices the changes replace. It returns false when the
+// subscription does not admit the new price.
+func (a *Applier) apply(tx *sqlx.Tx, changes []models.PriceChange) (bool, error) {
+	tenantID, subscriptionID := changes[0].TenantID, changes[0].SubscriptionID
+
+	var row records.Subscription
+	err := tx.Get(&row, `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2 FOR UPDATE`,
+		tenantID, subscriptionID)
+	if err != nil {
+		return false, err
+	}
+	before := row.Model()
+
+	shares, err := subscriptions.Shares(tx, tenantID, []uuid.UUID{subscriptionID})
+	if err != nil {
+		return false, err
+	}
+
+	subscription, history := reprice(before, changes)
+	var violation *rules.Violation
+	err = rules.CheckSubscription(subscription, shares[subscriptionID])
+	if errors.As(err, &violation) {
+		a.logger.WithError(err).WithField("subscription_id", subscriptionID).Warn("Dropped price change")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Recorded before the rollup moves, which prices months with them
+	for _, period := range history {
+		_, err := tx.Exec(`
+			INSERT INTO subscription_prices (tenant_id, subscription_id, valid_until, price)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`, period.TenantID, period.SubscriptionID, period.ValidUntil, period.Price)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = tx.Get(&row, `
+		UPDATE subscriptions SET price = $1, updated_at = NOW()
+		WHERE tenant_id = $2 AND id = $3
+		RETURNING *`, subscription.Price, tenantID, subscriptionID)
+	if err != nil {
+		return false, err
+	}
+	subscription = row.Model()
+
+	if err := rollup.Apply(tx, &before, &subscription); err != nil {
+		return false, err
+	}
+	return true, outbox.Write(tx, models.EventSubscriptionUpdated, subscription)
+}
+
+// reprice returns the subscription at the price of its latest change and
+// the past prices to record: each price the changes replace, valid until
+// the effective date of the change replacing it
+func reprice(subscription models.Subscription, changes []models.PriceChange) (models.Subscription, []models.PricePeriod) {
+	changes = append([]models.PriceChange(nil), changes...)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].EffectiveDate.Before(changes[j].EffectiveDate) })
+
+	history := make([]models.PricePeriod, 0, len(changes))
+	for _, change := range changes {
+		history = append(history, models.PricePeriod{
+			SubscriptionID: subscription.ID,
+			TenantID:       subscription.TenantID,
+			ValidUntil:     change.EffectiveDate,
+			Price:          subscription.Price,
+		})
+		subscription.Price = change.Price
+	}
+	return subscription, history
+}
@@ -0,0 +1,107 @@
+package pricing
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"subscription-aggregator/internal/billing"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// monthTotals charges the subscription month by month from its start, the
+// way aggregation and the rollup do
+func monthTotals(subscription models.Subscription, history []models.PricePeriod, months int) []float64 {
+	totals := make([]float64, months)
+	first := time.Date(subscription.StartDate.Year(), subscription.StartDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := range totals {
+		monthStart := first.AddDate(0, i, 0)
+		monthEnd := monthStart.AddDate(0, 1, -1)
+		for _, span := range billing.PriceSpans(subscription.Price, history, subscription.StartDate, monthStart, monthEnd) {
+			totals[i] += billing.MonthlyCost(span.Price, subscription.StartDate, subscription.EndDate, span.From, span.To)
+		}
+	}
+	return totals
+}
+
+func TestReprice(t *testing.T) {
+	subscription := models.Subscription{ID: uuid.New(), TenantID: uuid.New(), Price: 399, StartDate: date(2024, 11, 15)}
+	change := func(effective time.Time, price int) models.PriceChange {
+		return models.PriceChange{SubscriptionID: subscription.ID, TenantID: subscription.TenantID,
+			EffectiveDate: effective, Price: price}
+	}
+
+	tests := []struct {
+		name    string
+		history []models.PricePeriod // Recorded by earlier runs
+		changes []models.PriceChange
+		price   int
+		totals  []float64 // November 2024 to June 2025
+	}{
+		{
+			name:    "mid-month change",
+			changes: []models.PriceChange{change(date(2025, 2, 10), 499)},
+			price:   499,
+			totals:  []float64{399, 399, 399, 399, 499, 499, 499, 499},
+		},
+		{
+			name:    "change on the first of the month",
+			changes: []models.PriceChange{change(date(2025, 2, 1), 499)},
+			price:   499,
+			totals:  []float64{399, 399, 399, 499, 499, 499, 499, 499},
+		},
+		{
+			name:    "several changes in one run",
+			changes: []models.PriceChange{change(date(2025, 4, 1), 599), change(date(2025, 2, 10), 499)},
+			price:   599,
+			totals:  []float64{399, 399, 399, 399, 499, 599, 599, 599},
+		},
+		{
+			name: "change after an applied one",
+			history: []models.PricePeriod{{SubscriptionID: subscription.ID, TenantID: subscription.TenantID,
+				ValidUntil: date(2024, 12, 1), Price: 299}},
+			changes: []models.PriceChange{change(date(2025, 3, 1), 499)},
+			price:   499,
+			totals:  []float64{299, 399, 399, 399, 499, 499, 499, 499},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := monthTotals(subscription, tt.history, 8)
+
+			repriced, recorded := reprice(subscription, tt.changes)
+			if repriced.Price != tt.price {
+				t.Fatalf("price = %d, want %d", repriced.Price, tt.price)
+			}
+			history := append(append([]models.PricePeriod(nil), tt.history...), recorded...)
+			after := monthTotals(repriced, history, 8)
+			if !reflect.DeepEqual(after, tt.totals) {
+				t.Fatalf("totals = %v, want %v", after, tt.totals)
+			}
+
+			// Months before the first change keep what they were charged
+			effective := tt.changes[0].EffectiveDate
+			for _, c := range tt.changes {
+				if c.EffectiveDate.Before(effective) {
+					effective = c.EffectiveDate
+				}
+			}
+			first := time.Date(subscription.StartDate.Year(), subscription.StartDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+			for i := range before {
+				monthStart := first.AddDate(0, i, 0)
+				if !monthStart.Before(effective) {
+					break
+				}
+				if after[i] != before[i] {
+					t.Errorf("month %s = %v, was %v", monthStart.Format("2006-01"), after[i], before[i])
+				}
+			}
+		})
+	}
+}
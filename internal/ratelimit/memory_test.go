@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(now *time.Time) *MemoryLimiter {
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func allow(t *testing.T, l Limiter, key string, limit Limit) Result {
+	t.Helper()
+
+	res, err := l.Allow(key, limit)
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	return res
+}
+
+func TestMemoryLimiterBurst(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	limit := Limit{RequestsPerMinute: 60, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res := allow(t, l, "client", limit)
+		if !res.Allowed || res.Remaining != i || res.Limit != 3 || res.RetryAfter != 0 {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", 3-i, res, i)
+		}
+	}
+	if res := allow(t, l, "client", limit); res.Reset != 3*time.Second {
+		t.Errorf("Reset = %s, want 3s", res.Reset)
+	}
+
+	res := allow(t, l, "client", limit)
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("request over the burst = %+v, want denied", res)
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %s, want 1s", res.RetryAfter)
+	}
+
+	// Buckets are per key
+	if res := allow(t, l, "other", limit); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("request of another key = %+v, want allowed with 2 remaining", res)
+	}
+}
+
+func TestMemoryLimiterRefill(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	limit := Limit{RequestsPerMinute: 30, Burst: 2}
+
+	allow(t, l, "client", limit)
+	allow(t, l, "client", limit)
+
+	now = now.Add(time.Second)
+	res := allow(t, l, "client", limit)
+	if res.Allowed {
+		t.Fatalf("request after half a token = %+v, want denied", res)
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %s, want 1s", res.RetryAfter)
+	}
+
+	now = now.Add(time.Second)
+	if res := allow(t, l, "client", limit); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("request after a refilled token = %+v, want allowed with 0 remaining", res)
+	}
+
+	// Refill stops at the burst
+	now = now.Add(time.Hour)
+	if res := allow(t, l, "client", limit); !res.Allowed || res.Remaining != 1 {
+		t.Errorf("request after an hour = %+v, want allowed with 1 remaining", res)
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	limit := Limit{RequestsPerMinute: 60, Burst: 1}
+
+	allow(t, l, "idle", limit)
+	now = now.Add(idleTimeout / 2)
+	allow(t, l, "active", limit)
+
+	now = now.Add(idleTimeout/2 + 2*time.Minute)
+	allow(t, l, "sweeper", limit)
+
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("idle bucket was kept")
+	}
+	if _, ok := l.buckets["active"]; !ok {
+		t.Error("active bucket was dropped")
+	}
+}
+
+func TestLimitValid(t *testing.T) {
+	tests := []struct {
+		limit Limit
+		want  bool
+	}{
+		{Limit{RequestsPerMinute: 60, Burst: 10}, true},
+		{Limit{RequestsPerMinute: 0, Burst: 10}, false},
+		{Limit{RequestsPerMinute: 60, Burst: 0}, false},
+		{Limit{RequestsPerMinute: -1, Burst: -1}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.Valid(); got != tt.want {
+			t.Errorf("%+v.Valid() = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
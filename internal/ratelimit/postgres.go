@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// cleanupInterval is how often stale buckets are deleted
+const cleanupInterval = 10 * time.Minute
+
+// PostgresLimiter keeps buckets in Postgres so limits hold across replicas.
+// Each request is a single upsert, which locks only its own bucket row.
+type PostgresLimiter struct {
+	db *sqlx.DB
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewPostgresLimiter(db *sqlx.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+func (l *PostgresLimiter) Allow(key string, limit Limit) (Result, error) {
+	l.cleanup()
+
+	var row struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+
+	// Columns on the right-hand side refer to the bucket before this request
+	refilled := `LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3::float8)`
+	query := fmt.Sprintf(`
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, true, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN %[1]s >= 1 THEN %[1]s - 1 ELSE %[1]s END,
+			allowed = %[1]s >= 1,
+			updated_at = NOW()
+		RETURNING tokens, allowed`, refilled)
+
+	if err := l.db.Get(&row, query, key, limit.Burst, limit.rate()); err != nil {
+		return Result{}, err
+	}
+
+	return result(row.Allowed, row.Tokens, limit), nil
+}
+
+// cleanup deletes buckets idle long enough to be full again
+func (l *PostgresLimiter) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = time.Now()
+
+	go l.db.Exec(`DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - INTERVAL '1 hour'`)
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at
+// RequestsPerMinute
+type Limit struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// Valid reports whether the limit admits any requests
+func (l Limit) Valid() bool {
+	return l.RequestsPerMinute > 0 && l.Burst > 0
+}
+
+// rate returns the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.RequestsPerMinute) / 60
+}
+
+// Result describes the state of a bucket after a request
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next request is allowed, zero when allowed
+}
+
+// Limiter takes a token from the bucket identified by key
+type Limiter interface {
+	Allow(key string, limit Limit) (Result, error)
+}
+
+// result builds the result from the tokens left in the bucket
+func result(allowed bool, tokens float64, limit Limit) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.rate())
+	}
+	return res
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
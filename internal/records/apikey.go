@@ -0,0 +1,41 @@
+package records
+
+import (
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type APIKey struct {
+	ID         uuid.UUID      `db:"id"`
+	TenantID   uuid.UUID      `db:"tenant_id"`
+	Name       string         `db:"name"`
+	KeyPrefix  string         `db:"key_prefix"`
+	KeyHash    string         `db:"key_hash"`
+	UserID     *uuid.UUID     `db:"user_id"`
+	Scopes     pq.StringArray `db:"scopes"`
+	Roles      pq.StringArray `db:"roles"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// Model leaves out the hash of the key
+func (k APIKey) Model() models.APIKey {
+	return models.APIKey{
+		ID:         k.ID,
+		TenantID:   k.TenantID,
+		Name:       k.Name,
+		KeyPrefix:  k.KeyPrefix,
+		UserID:     k.UserID,
+		Scopes:     []string(k.Scopes),
+		Roles:      []string(k.Roles),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
@@ -0,0 +1,39 @@
+package records
+
+import (
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Budget struct {
+	ID         uuid.UUID     `db:"id"`
+	TenantID   uuid.UUID     `db:"tenant_id"`
+	UserID     *uuid.UUID    `db:"user_id"`
+	Name       string        `db:"name"`
+	Period     string        `db:"period"`
+	Amount     int64         `db:"amount"`
+	Category   *string       `db:"category"`
+	ServiceID  *uuid.UUID    `db:"service_id"`
+	Thresholds pq.Int64Array `db:"thresholds"`
+	CreatedAt  time.Time     `db:"created_at"`
+	UpdatedAt  time.Time     `db:"updated_at"`
+}
+
+func (b Budget) Model() models.Budget {
+	return models.Budget{
+		ID:         b.ID,
+		TenantID:   b.TenantID,
+		UserID:     b.UserID,
+		Name:       b.Name,
+		Period:     b.Period,
+		Amount:     b.Amount,
+		Category:   b.Category,
+		ServiceID:  b.ServiceID,
+		Thresholds: []int64(b.Thresholds),
+		CreatedAt:  b.CreatedAt,
+		UpdatedAt:  b.UpdatedAt,
+	}
+}
@@ -0,0 +1,13 @@
+// Package records holds the rows of the database tables. Handlers and
+// workers scan rows into records and answer with the models of pkg/models,
+// which carry no database types.
+package records
+
+// Models converts scanned rows to their models
+func Models[M any, R interface{ Model() M }](rows []R) []M {
+	models := make([]M, len(rows))
+	for i, row := range rows {
+		models[i] = row.Model()
+	}
+	return models
+}
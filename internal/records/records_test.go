@@ -0,0 +1,156 @@
+package records
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestModel(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	category := "video"
+	price := 299
+	userID := uuid.New()
+	status := 200
+
+	tests := []struct {
+		name    string
+		record  interface{}
+		model   interface{}
+		omitted []string // Fields kept out of the model
+	}{
+		{
+			name: "subscription",
+			record: Subscription{
+				ID: uuid.New(), TenantID: uuid.New(), ServiceName: "Netflix", ServiceID: uuid.New(), Price: 599,
+				UserID: userID, StartDate: now, EndDate: &now, Category: &category, Tags: pq.StringArray{"family"},
+				HouseholdID: &userID, SplitType: "equal", CreatedAt: now, UpdatedAt: now,
+			},
+		},
+		{
+			name: "service",
+			record: Service{
+				ID: uuid.New(), TenantID: uuid.New(), Name: "Yandex Plus", Aliases: pq.StringArray{"Яндекс Плюс"},
+				Category: &category, DefaultPrice: &price, Website: &category, CreatedAt: now, UpdatedAt: now,
+			},
+		},
+		{
+			name:   "user",
+			record: User{ID: userID, TenantID: uuid.New(), Name: &category, Email: &category, CreatedAt: now, UpdatedAt: now},
+		},
+		{
+			name:   "household",
+			record: Household{ID: uuid.New(), TenantID: uuid.New(), Name: "Home", CreatedAt: now, UpdatedAt: now},
+		},
+		{
+			name: "api key",
+			record: APIKey{
+				ID: uuid.New(), TenantID: uuid.New(), Name: "ci", KeyPrefix: "sa_1234", KeyHash: "hash", UserID: &userID,
+				Scopes: pq.StringArray{"read"}, Roles: pq.StringArray{"admin"}, ExpiresAt: &now, LastUsedAt: &now,
+				RevokedAt: &now, CreatedAt: now,
+			},
+			omitted: []string{"KeyHash"},
+		},
+		{
+			name: "webhook",
+			record: Webhook{
+				ID: uuid.New(), TenantID: uuid.New(), URL: "https://example.com", Secret: "secret",
+				EventTypes: pq.StringArray{"subscription.created"}, Active: true, CreatedAt: now, UpdatedAt: now,
+			},
+			omitted: []string{"Secret"},
+		},
+		{
+			name: "webhook delivery",
+			record: WebhookDelivery{
+				ID: uuid.New(), TenantID: uuid.New(), WebhookID: uuid.New(), EventID: uuid.New(), EventType: "subscription.created",
+				Payload: json.RawMessage(`{}`), Status: "failed", Attempts: 3, NextAttemptAt: now, LastStatus: &status,
+				LastError: &category, DeliveredAt: &now, CreatedAt: now,
+			},
+		},
+		{
+			name: "budget",
+			record: Budget{
+				ID: uuid.New(), TenantID: uuid.New(), UserID: &userID, Name: "Video", Period: "monthly", Amount: 1000,
+				Category: &category, ServiceID: &userID, Thresholds: pq.Int64Array{80, 100}, CreatedAt: now, UpdatedAt: now,
+			},
+		},
+		{
+			name:   "subscription share",
+			record: SubscriptionShare{SubscriptionID: uuid.New(), UserID: userID, Amount: &price},
+		},
+		{
+			name:   "price change",
+			record: PriceChange{SubscriptionID: uuid.New(), TenantID: uuid.New(), EffectiveDate: now, Price: 399, CreatedAt: now},
+		},
+		{
+			name:   "price period",
+			record: PricePeriod{SubscriptionID: uuid.New(), TenantID: uuid.New(), ValidUntil: now, Price: 399},
+		},
+		{
+			name:   "aggregation group",
+			record: AggregationGroup{Key: "Netflix", TotalCost: 1198},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := reflect.ValueOf(tt.record)
+			model := record.MethodByName("Model").Call(nil)[0]
+
+			// Every column but the omitted ones reaches the model
+			for i := 0; i < record.NumField(); i++ {
+				name := record.Type().Field(i).Name
+				field := model.FieldByName(name)
+				omitted := contains(tt.omitted, name)
+				switch {
+				case omitted && field.IsValid():
+					t.Errorf("model has %s", name)
+				case !omitted && !field.IsValid():
+					t.Errorf("model lacks %s", name)
+				case !omitted && !reflect.DeepEqual(field.Interface(), record.Field(i).Convert(field.Type()).Interface()):
+					t.Errorf("%s = %v, want %v", name, field.Interface(), record.Field(i).Interface())
+				}
+			}
+
+			// Models are wire types without database types or tags
+			for i := 0; i < model.NumField(); i++ {
+				field := model.Type().Field(i)
+				if _, ok := field.Tag.Lookup("db"); ok {
+					t.Errorf("model field %s has a db tag", field.Name)
+				}
+				if strings.HasPrefix(field.Type.PkgPath(), "github.com/lib/pq") {
+					t.Errorf("model field %s has type %s", field.Name, field.Type)
+				}
+			}
+		})
+	}
+}
+
+func TestModels(t *testing.T) {
+	rows := []User{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	users := Models[models.User](rows)
+	if len(users) != 2 || users[0].ID != rows[0].ID || users[1].ID != rows[1].ID {
+		t.Fatalf("Models() = %v", users)
+	}
+
+	// Empty lists encode as [] rather than null
+	if users := Models[models.User]([]User(nil)); users == nil || len(users) != 0 {
+		t.Fatalf("Models(nil) = %#v, want an empty slice", users)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package records
+
+import (
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Service struct {
+	ID           uuid.UUID      `db:"id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
+	Name         string         `db:"name"`
+	Aliases      pq.StringArray `db:"aliases"`
+	Category     *string        `db:"category"`
+	DefaultPrice *int           `db:"default_price"`
+	Website      *string        `db:"website"`
+	CreatedAt    time.Time      `db:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at"`
+}
+
+func (s Service) Model() models.Service {
+	return models.Service{
+		ID:           s.ID,
+		TenantID:     s.TenantID,
+		Name:         s.Name,
+		Aliases:      []string(s.Aliases),
+		Category:     s.Category,
+		DefaultPrice: s.DefaultPrice,
+		Website:      s.Website,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+	}
+}
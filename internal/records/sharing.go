@@ -0,0 +1,18 @@
+package records
+
+import (
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type SubscriptionShare struct {
+	SubscriptionID uuid.UUID `db:"subscription_id"`
+	UserID         uuid.UUID `db:"user_id"`
+	Percent        *float64  `db:"percent"`
+	Amount         *int      `db:"amount"`
+}
+
+func (s SubscriptionShare) Model() models.SubscriptionShare {
+	return models.SubscriptionShare(s)
+}
@@ -0,0 +1,80 @@
+package records
+
+import (
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Subscription struct {
+	ID          uuid.UUID      `db:"id"`
+	TenantID    uuid.UUID      `db:"tenant_id"`
+	ServiceName string         `db:"service_name"`
+	ServiceID   uuid.UUID      `db:"service_id"`
+	Price       int            `db:"price"`
+	UserID      uuid.UUID      `db:"user_id"`
+	StartDate   time.Time      `db:"start_date"`
+	EndDate     *time.Time     `db:"end_date"`
+	Category    *string        `db:"category"`
+	Tags        pq.StringArray `db:"tags"`
+	HouseholdID *uuid.UUID     `db:"household_id"`
+	SplitType   string         `db:"split_type"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+func (s Subscription) Model() models.Subscription {
+	return models.Subscription{
+		ID:          s.ID,
+		TenantID:    s.TenantID,
+		ServiceName: s.ServiceName,
+		ServiceID:   s.ServiceID,
+		Price:       s.Price,
+		UserID:      s.UserID,
+		StartDate:   s.StartDate,
+		EndDate:     s.EndDate,
+		Category:    s.Category,
+		Tags:        []string(s.Tags),
+		HouseholdID: s.HouseholdID,
+		SplitType:   s.SplitType,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+// PriceChange is a row of subscription_price_changes
+type PriceChange struct {
+	SubscriptionID uuid.UUID `db:"subscription_id"`
+	TenantID       uuid.UUID `db:"tenant_id"`
+	EffectiveDate  time.Time `db:"effective_date"`
+	Price          int       `db:"price"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (c PriceChange) Model() models.PriceChange {
+	return models.PriceChange(c)
+}
+
+// PricePeriod is a row of subscription_prices
+type PricePeriod struct {
+	SubscriptionID uuid.UUID `db:"subscription_id"`
+	TenantID       uuid.UUID `db:"tenant_id"`
+	ValidUntil     time.Time `db:"valid_until"`
+	Price          int       `db:"price"`
+}
+
+func (p PricePeriod) Model() models.PricePeriod {
+	return models.PricePeriod(p)
+}
+
+// AggregationGroup is a row of grouped aggregation queries
+type AggregationGroup struct {
+	Key       string `db:"key"`
+	TotalCost int64  `db:"total_cost"`
+}
+
+func (g AggregationGroup) Model() models.AggregationGroup {
+	return models.AggregationGroup(g)
+}
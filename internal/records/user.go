@@ -0,0 +1,40 @@
+package records
+
+import (
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID        uuid.UUID `db:"id"`
+	TenantID  uuid.UUID `db:"tenant_id"`
+	Name      *string   `db:"name"`
+	Email     *string   `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (u User) Model() models.User {
+	return models.User(u)
+}
+
+// Household is a row of households; members are kept in household_members
+type Household struct {
+	ID        uuid.UUID `db:"id"`
+	TenantID  uuid.UUID `db:"tenant_id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (h Household) Model() models.Household {
+	return models.Household{
+		ID:        h.ID,
+		TenantID:  h.TenantID,
+		Name:      h.Name,
+		CreatedAt: h.CreatedAt,
+		UpdatedAt: h.UpdatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package records
+
+import (
+	"encoding/json"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Webhook struct {
+	ID         uuid.UUID      `db:"id"`
+	TenantID   uuid.UUID      `db:"tenant_id"`
+	URL        string         `db:"url"`
+	Secret     string         `db:"secret"`
+	EventTypes pq.StringArray `db:"event_types"`
+	Active     bool           `db:"active"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+// Model leaves out the signing secret
+func (w Webhook) Model() models.Webhook {
+	return models.Webhook{
+		ID:         w.ID,
+		TenantID:   w.TenantID,
+		URL:        w.URL,
+		EventTypes: []string(w.EventTypes),
+		Active:     w.Active,
+		CreatedAt:  w.CreatedAt,
+		UpdatedAt:  w.UpdatedAt,
+	}
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID       `db:"id"`
+	TenantID      uuid.UUID       `db:"tenant_id"`
+	WebhookID     uuid.UUID       `db:"webhook_id"`
+	EventID       uuid.UUID       `db:"event_id"`
+	EventType     string          `db:"event_type"`
+	Payload       json.RawMessage `db:"payload"`
+	Status        string          `db:"status"`
+	Attempts      int             `db:"attempts"`
+	NextAttemptAt time.Time       `db:"next_attempt_at"`
+	LastStatus    *int            `db:"last_status"`
+	LastError     *string         `db:"last_error"`
+	DeliveredAt   *time.Time      `db:"delivered_at"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
+
+func (d WebhookDelivery) Model() models.WebhookDelivery {
+	return models.WebhookDelivery(d)
+}
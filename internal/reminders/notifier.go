@@ -0,0 +1,81 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoRecipient is returned by notifiers that have no address for the
+// user; the reminder is tried again on the next run
+var ErrNoRecipient = errors.New("no recipient for the user")
+
+// Notifier sends reminders through one channel. Name identifies the channel
+// in the record of sent reminders and must stay stable.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+// LogNotifier writes reminders to the log, for development
+type LogNotifier struct {
+	logger *logrus.Logger
+}
+
+func NewLogNotifier(logger *logrus.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Name() string {
+	return "log"
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	n.logger.WithFields(logrus.Fields{
+		"subscription_id": reminder.Subscription.ID,
+		"user_id":         reminder.Subscription.UserID,
+		"kind":            reminder.Kind,
+		"date":            reminder.Date.Format(dateLayout),
+	}).Info(reminder.Subject())
+	return nil
+}
+
+// FileNotifier appends reminders to a file as JSON lines, for development
+// and tests
+type FileNotifier struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileNotifier{file: file}, nil
+}
+
+func (n *FileNotifier) Name() string {
+	return "file"
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	line, err := json.Marshal(struct {
+		Reminder
+		Subject string `json:"subject"`
+		Text    string `json:"text"`
+	}{reminder, reminder.Subject(), reminder.Text()})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err = n.file.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,212 @@
+package reminders
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"subscription-aggregator/internal/webhooks"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func testReminder(kind string) Reminder {
+	name := "Анна"
+	return Reminder{
+		Kind:         kind,
+		Date:         date(2025, 3, 12),
+		Subscription: models.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 599},
+		UserName:     &name,
+	}
+}
+
+func TestReminderText(t *testing.T) {
+	empty := ""
+
+	tests := []struct {
+		name        string
+		reminder    Reminder
+		wantSubject string
+		wantText    []string
+	}{
+		{"renewal", testReminder(KindRenewal), "Подписка Netflix продлится 2025-03-12",
+			[]string{"Здравствуйте, Анна!", "2025-03-12 будет списано 599 ₽ за подписку Netflix"}},
+		{"expiry", testReminder(KindExpiry), "Подписка Netflix заканчивается 2025-03-12",
+			[]string{"Здравствуйте, Анна!", "Подписка Netflix заканчивается 2025-03-12."}},
+		{"without name", func() Reminder { r := testReminder(KindRenewal); r.UserName = &empty; return r }(), "Подписка Netflix продлится 2025-03-12",
+			[]string{"Здравствуйте!\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reminder.Subject(); got != tt.wantSubject {
+				t.Errorf("Subject() = %q, want %q", got, tt.wantSubject)
+			}
+			text := tt.reminder.Text()
+			for _, want := range tt.wantText {
+				if !strings.Contains(text, want) {
+					t.Errorf("Text() = %q, want it to contain %q", text, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.jsonl")
+	n, err := NewFileNotifier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{KindRenewal, KindExpiry} {
+		if err := n.Notify(context.Background(), testReminder(kind)); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var kinds []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line struct {
+			Kind    string `json:"kind"`
+			Subject string `json:"subject"`
+			Text    string `json:"text"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		if line.Subject == "" || line.Text == "" {
+			t.Errorf("line %q lacks the subject or text", scanner.Text())
+		}
+		kinds = append(kinds, line.Kind)
+	}
+	if strings.Join(kinds, ",") != "renewal,expiry" {
+		t.Fatalf("file holds reminders %v, want renewal and expiry", kinds)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		status     int
+		wantSigned bool
+		wantErr    bool
+	}{
+		{name: "signed", secret: "whsec_test", status: http.StatusOK, wantSigned: true},
+		{name: "unsigned", status: http.StatusNoContent},
+		{name: "failure", status: http.StatusBadGateway, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewWebhookNotifier(server.URL, tt.secret).Notify(context.Background(), testReminder(KindExpiry))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if header.Get(webhooks.EventHeader) != "reminder.expiry" {
+				t.Errorf("event header = %q, want reminder.expiry", header.Get(webhooks.EventHeader))
+			}
+			var payload struct {
+				Kind string `json:"kind"`
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(body, &payload); err != nil || payload.Kind != KindExpiry || payload.Text == "" {
+				t.Errorf("payload = %s, want the reminder with its text", body)
+			}
+
+			signature := header.Get(webhooks.SignatureHeader)
+			if !tt.wantSigned {
+				if signature != "" {
+					t.Errorf("unsigned reminder carries signature %q", signature)
+				}
+				return
+			}
+			unix, _ := strconv.ParseInt(header.Get(webhooks.TimestampHeader), 10, 64)
+			if signature != webhooks.Sign(tt.secret, time.Unix(unix, 0), body) {
+				t.Errorf("signature %q does not match the timestamp and body", signature)
+			}
+		})
+	}
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		answer  string
+		wantErr string
+	}{
+		{name: "sent", status: http.StatusOK, answer: `{"ok":true}`},
+		{name: "refused", status: http.StatusBadRequest, answer: `{"ok":false,"description":"chat not found"}`, wantErr: "chat not found"},
+		{name: "not JSON", status: http.StatusBadGateway, answer: "<html>", wantErr: "502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, chatID, text string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				chatID, text = r.FormValue("chat_id"), r.FormValue("text")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.answer)
+			}))
+			defer server.Close()
+
+			n := NewTelegramNotifier("123:token", "-100")
+			n.baseURL = server.URL
+			err := n.Notify(context.Background(), testReminder(KindRenewal))
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Notify() error = %v, want %q", err, tt.wantErr)
+			}
+			if path != "/bot123:token/sendMessage" || chatID != "-100" || !strings.Contains(text, "Netflix") {
+				t.Fatalf("request to %s with chat %q and text %q", path, chatID, text)
+			}
+		})
+	}
+}
+
+func TestTelegramNotifierHidesToken(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	n := NewTelegramNotifier("123:token", "-100")
+	n.baseURL = server.URL
+	err := n.Notify(context.Background(), testReminder(KindRenewal))
+	if err == nil || strings.Contains(err.Error(), "token") {
+		t.Fatalf("Notify() error = %v, want an error without the token", err)
+	}
+}
+
+func TestSMTPNotifierNoRecipient(t *testing.T) {
+	n := NewSMTPNotifier("localhost", 25, "", "", "reminders@example.com")
+	if err := n.Notify(context.Background(), testReminder(KindRenewal)); !errors.Is(err, ErrNoRecipient) {
+		t.Fatalf("Notify() error = %v, want ErrNoRecipient", err)
+	}
+}
@@ -0,0 +1,47 @@
+package reminders
+
+import (
+	"fmt"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+// Reminder kinds
+const (
+	KindRenewal = "renewal" // The subscription is about to be charged again
+	KindExpiry  = "expiry"  // The subscription reaches its end date
+)
+
+const dateLayout = "2006-01-02"
+
+// Reminder is a notice about an upcoming renewal or end of a subscription
+type Reminder struct {
+	Kind         string              `json:"kind"`
+	Date         time.Time           `json:"date"` // Renewal or end date
+	Subscription models.Subscription `json:"subscription"`
+	UserName     *string             `json:"user_name,omitempty"`
+	UserEmail    *string             `json:"-"`
+}
+
+// Subject is a one-line summary of the reminder
+func (r Reminder) Subject() string {
+	if r.Kind == KindExpiry {
+		return fmt.Sprintf("Подписка %s заканчивается %s", r.Subscription.ServiceName, r.Date.Format(dateLayout))
+	}
+	return fmt.Sprintf("Подписка %s продлится %s", r.Subscription.ServiceName, r.Date.Format(dateLayout))
+}
+
+// Text is the full message of the reminder
+func (r Reminder) Text() string {
+	greeting := "Здравствуйте!"
+	if r.UserName != nil && *r.UserName != "" {
+		greeting = fmt.Sprintf("Здравствуйте, %s!", *r.UserName)
+	}
+
+	if r.Kind == KindExpiry {
+		return fmt.Sprintf("%s\n\nПодписка %s заканчивается %s. Если она ещё нужна, продлите её заранее.",
+			greeting, r.Subscription.ServiceName, r.Date.Format(dateLayout))
+	}
+	return fmt.Sprintf("%s\n\n%s будет списано %d ₽ за подписку %s. Если она больше не нужна, отмените её до этой даты.",
+		greeting, r.Date.Format(dateLayout), r.Subscription.Price, r.Subscription.ServiceName)
+}
@@ -0,0 +1,178 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"time"
+	"subscription-aggregator/internal/billing"
+	"subscription-aggregator/internal/records"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Records of sent reminders are kept this long after their date
+const sentRetention = 90 * 24 * time.Hour
+
+// Options configures the scheduler; zero values take the defaults
+type Options struct {
+	Window   time.Duration // Remind about dates this far ahead, 3 days
+	Interval time.Duration // 1h
+}
+
+// Scheduler finds subscriptions renewing or ending within the window and
+// sends reminders through every notifier. Sent reminders are recorded per
+// channel before sending, so replicas and reruns do not repeat them; a
+// failed send removes the record to try again on the next run.
+type Scheduler struct {
+	db        *sqlx.DB
+	notifiers []Notifier
+	opts      Options
+	logger    *logrus.Logger
+}
+
+func NewScheduler(db *sqlx.DB, notifiers []Notifier, opts Options, logger *logrus.Logger) *Scheduler {
+	if opts.Window <= 0 {
+		opts.Window = 3 * 24 * time.Hour
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+
+	return &Scheduler{
+		db:        db,
+		notifiers: notifiers,
+		opts:      opts,
+		logger:    logger,
+	}
+}
+
+// Run sends reminders until the context is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx, time.Now()); err != nil {
+			s.logger.WithError(err).Error("Failed to send reminders")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// candidate is an active subscription with the contacts of its owner
+type candidate struct {
+	records.Subscription
+	UserName  *string `db:"user_name"`
+	UserEmail *string `db:"user_email"`
+}
+
+// RunOnce sends the reminders due at the time
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizon := today.Add(s.opts.Window)
+
+	// Subscriptions of every tenant that are or will be active within the
+	// window; renewal dates are computed from the start date below
+	var candidates []candidate
+	err := s.db.Select(&candidates, `
+		SELECT s.*, u.name AS user_name, u.email AS user_email
+		FROM subscriptions s
+		LEFT JOIN users u ON u.tenant_id = s.tenant_id AND u.id = s.user_id
+		WHERE s.start_date < $2 AND (s.end_date IS NULL OR s.end_date >= $1)`, today, horizon)
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, c := range candidates {
+		for _, reminder := range due(c, today, horizon) {
+			for _, notifier := range s.notifiers {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if s.send(ctx, notifier, reminder) {
+					sent++
+				}
+			}
+		}
+	}
+
+	if sent > 0 {
+		s.logger.WithField("count", sent).Info("Reminders sent")
+	}
+
+	_, err = s.db.Exec(`DELETE FROM reminders_sent WHERE due_date < $1`, today.Add(-sentRetention))
+	return err
+}
+
+// due returns the reminders of the subscription for dates within
+// [today, horizon]
+func due(c candidate, today, horizon time.Time) []Reminder {
+	var reminders []Reminder
+	remind := func(kind string, date time.Time) {
+		reminders = append(reminders, Reminder{
+			Kind:         kind,
+			Date:         date,
+			Subscription: c.Subscription.Model(),
+			UserName:     c.UserName,
+			UserEmail:    c.UserEmail,
+		})
+	}
+
+	end := c.EndDate
+	if renewal := billing.NextRenewal(c.StartDate, today); !renewal.After(horizon) && (end == nil || !renewal.After(*end)) {
+		remind(KindRenewal, renewal)
+	}
+	if end != nil && !end.Before(today) && !end.After(horizon) {
+		remind(KindExpiry, *end)
+	}
+
+	return reminders
+}
+
+// send records and sends the reminder through the notifier, returning
+// whether it was sent now
+func (s *Scheduler) send(ctx context.Context, notifier Notifier, reminder Reminder) bool {
+	subscription := reminder.Subscription
+	logger := s.logger.WithFields(logrus.Fields{
+		"subscription_id": subscription.ID,
+		"kind":            reminder.Kind,
+		"channel":         notifier.Name(),
+	})
+
+	result, err := s.db.Exec(`
+		INSERT INTO reminders_sent (tenant_id, subscription_id, kind, due_date, channel)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`, subscription.TenantID, subscription.ID, reminder.Kind, reminder.Date, notifier.Name())
+	if err != nil {
+		logger.WithError(err).Error("Failed to record reminder")
+		return false
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		return false
+	}
+
+	err = notifier.Notify(ctx, reminder)
+	if err == nil {
+		return true
+	}
+
+	if !errors.Is(err, ErrNoRecipient) {
+		logger.WithError(err).Warn("Failed to send reminder")
+	}
+
+	_, releaseErr := s.db.Exec(`
+		DELETE FROM reminders_sent
+		WHERE tenant_id = $1 AND subscription_id = $2 AND kind = $3 AND due_date = $4 AND channel = $5`,
+		subscription.TenantID, subscription.ID, reminder.Kind, reminder.Date, notifier.Name())
+	if releaseErr != nil {
+		logger.WithError(releaseErr).Error("Failed to release reminder")
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package reminders
+
+import (
+	"testing"
+	"time"
+	"subscription-aggregator/internal/records"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDue(t *testing.T) {
+	today := date(2025, 3, 10)
+	horizon := today.Add(3 * 24 * time.Hour)
+	at := func(t time.Time) *time.Time { return &t }
+
+	type want struct {
+		kind string
+		date time.Time
+	}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   *time.Time
+		want  []want
+	}{
+		{name: "renews today", start: date(2025, 1, 10), want: []want{{KindRenewal, date(2025, 3, 10)}}},
+		{name: "renews on the horizon", start: date(2024, 11, 13), want: []want{{KindRenewal, date(2025, 3, 13)}}},
+		{name: "renews after the horizon", start: date(2025, 1, 14)},
+		{name: "renewed yesterday", start: date(2025, 2, 9)},
+		{name: "started today", start: date(2025, 3, 10)},
+		{name: "first renewal", start: date(2025, 2, 12), want: []want{{KindRenewal, date(2025, 3, 12)}}},
+		{name: "ends within the window", start: date(2025, 1, 20), end: at(date(2025, 3, 12)), want: []want{{KindExpiry, date(2025, 3, 12)}}},
+		{name: "renews then ends", start: date(2025, 1, 11), end: at(date(2025, 3, 13)),
+			want: []want{{KindRenewal, date(2025, 3, 11)}, {KindExpiry, date(2025, 3, 13)}}},
+		{name: "ends before the renewal", start: date(2025, 1, 12), end: at(date(2025, 3, 11)), want: []want{{KindExpiry, date(2025, 3, 11)}}},
+		{name: "ends on the renewal", start: date(2025, 1, 12), end: at(date(2025, 3, 12)),
+			want: []want{{KindRenewal, date(2025, 3, 12)}, {KindExpiry, date(2025, 3, 12)}}},
+		{name: "ends after the horizon", start: date(2025, 1, 20), end: at(date(2025, 4, 30))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := candidate{Subscription: records.Subscription{ServiceName: "Netflix", StartDate: tt.start, EndDate: tt.end}}
+			got := due(c, today, horizon)
+			if len(got) != len(tt.want) {
+				t.Fatalf("due() = %d reminders, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, reminder := range got {
+				if reminder.Kind != tt.want[i].kind || !reminder.Date.Equal(tt.want[i].date) {
+					t.Errorf("reminder %d = %s on %s, want %s on %s", i, reminder.Kind, reminder.Date.Format(dateLayout),
+						tt.want[i].kind, tt.want[i].date.Format(dateLayout))
+				}
+				if reminder.Subscription.ServiceName != "Netflix" {
+					t.Errorf("reminder %d is about %q", i, reminder.Subscription.ServiceName)
+				}
+			}
+		})
+	}
+}
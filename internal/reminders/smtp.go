@@ -0,0 +1,59 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier e-mails reminders to the address of the user
+type SMTPNotifier struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPNotifier sends through host:port, authenticating with PLAIN when
+// a username is set. The server must offer STARTTLS for authentication
+// unless it runs on localhost.
+func NewSMTPNotifier(host string, port int, username, password, from string) *SMTPNotifier {
+	n := &SMTPNotifier{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		host: host,
+		from: from,
+	}
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n
+}
+
+func (n *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	if reminder.UserEmail == nil || *reminder.UserEmail == "" {
+		return ErrNoRecipient
+	}
+	to := *reminder.UserEmail
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", reminder.Subject()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(reminder.Text(), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{to}, []byte(msg.String()))
+}
@@ -0,0 +1,70 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const telegramAPIURL = "https://api.telegram.org"
+
+// TelegramNotifier posts reminders to a chat through a Telegram bot. Users
+// have no Telegram accounts on record, so every reminder goes to the one
+// configured chat, such as a family or team group.
+type TelegramNotifier struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	chatID  string
+}
+
+func NewTelegramNotifier(token, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: telegramAPIURL,
+		token:   token,
+		chatID:  chatID,
+	}
+}
+
+func (n *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+func (n *TelegramNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	form := url.Values{
+		"chat_id": {n.chatID},
+		"text":    {reminder.Text()},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		n.baseURL+"/bot"+n.token+"/sendMessage", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// The error quotes the URL, which contains the token
+		return fmt.Errorf("telegram request failed: %w", errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram answered %s", resp.Status)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram answered %s: %s", resp.Status, result.Description)
+	}
+	return nil
+}
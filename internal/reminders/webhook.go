@@ -0,0 +1,66 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"subscription-aggregator/internal/webhooks"
+)
+
+// WebhookNotifier posts reminders as JSON to a URL, signed like webhook
+// deliveries when a secret is set
+type WebhookNotifier struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		url:    url,
+		secret: secret,
+	}
+}
+
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	body, err := json.Marshal(struct {
+		Reminder
+		Text string `json:"text"`
+	}{reminder, reminder.Text()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.EventHeader, "reminder."+reminder.Kind)
+	if n.secret != "" {
+		req.Header.Set(webhooks.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(webhooks.SignatureHeader, webhooks.Sign(n.secret, now, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return nil
+}
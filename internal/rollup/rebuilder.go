@@ -0,0 +1,102 @@
+package rollup
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Options configures the rebuilder; zero values take the defaults
+type Options struct {
+	Interval time.Duration // 24h
+	Months   int           // DefaultMonths
+}
+
+// Rebuilder recomputes the monthly spend rollup of every tenant from its
+// subscriptions. Writes keep rollups current between rebuilds; rebuilds
+// move the months open-ended subscriptions are counted through along with
+// the calendar and repair drift, e.g. from rows changed by hand.
+type Rebuilder struct {
+	db     *sqlx.DB
+	opts   Options
+	logger *logrus.Logger
+}
+
+func NewRebuilder(db *sqlx.DB, opts Options, logger *logrus.Logger) *Rebuilder {
+	if opts.Interval <= 0 {
+		opts.Interval = 24 * time.Hour
+	}
+	if opts.Months <= 0 {
+		opts.Months = DefaultMonths
+	}
+
+	return &Rebuilder{
+		db:     db,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Run rebuilds rollups until the context is done
+func (r *Rebuilder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RunOnce(ctx, time.Now()); err != nil {
+			r.logger.WithError(err).Error("Failed to rebuild monthly spend")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce rebuilds the rollup of every tenant with subscriptions or a
+// rollup, counting open-ended subscriptions through Months months past the
+// month of the time
+func (r *Rebuilder) RunOnce(ctx context.Context, now time.Time) error {
+	var tenants []uuid.UUID
+	err := r.db.Select(&tenants, `
+		SELECT tenant_id FROM subscriptions
+		UNION
+		SELECT tenant_id FROM monthly_spend_state`)
+	if err != nil {
+		return err
+	}
+
+	until := monthOf(now).AddDate(0, r.opts.Months, 0)
+	for _, tenantID := range tenants {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := r.rebuild(tenantID, until); err != nil {
+			r.logger.WithError(err).WithField("tenant_id", tenantID).Error("Failed to rebuild monthly spend")
+		}
+	}
+
+	r.logger.WithField("tenants", len(tenants)).Info("Monthly spend rebuilt")
+
+	return nil
+}
+
+func (r *Rebuilder) rebuild(tenantID uuid.UUID, until time.Time) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := Rebuild(tx, tenantID, until); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,206 @@
+package rollup
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMonths is how many months past the current one a rebuilt rollup
+// counts open-ended subscriptions for
+const DefaultMonths = 24
+
+// Mismatch is a month of an owner and service where the rollup differs
+// from the subscriptions
+type Mismatch struct {
+	Month     time.Time `db:"month"`
+	UserID    uuid.UUID `db:"user_id"`
+	ServiceID uuid.UUID `db:"service_id"`
+	Expected  int64     `db:"expected"`
+	Actual    int64     `db:"actual"`
+}
+
+// monthPrice is the price subscription s is charged in month m: the price
+// in effect on its first active day of the month, the past price recorded
+// by a later applied change or else the current one
+const monthPrice = `COALESCE((
+		SELECT p.price FROM subscription_prices p
+		WHERE p.tenant_id = s.tenant_id AND p.subscription_id = s.id
+			AND p.valid_until > GREATEST(m.month::date, s.start_date)
+		ORDER BY p.valid_until
+		LIMIT 1), s.price)`
+
+// spendQuery computes the rollup of tenant $1 from subscriptions,
+// counting open-ended ones through month $2
+const spendQuery = `
+	SELECT m.month::date AS month, s.user_id, s.service_id, SUM(` + monthPrice + `) AS amount
+	FROM subscriptions s
+	CROSS JOIN LATERAL generate_series(
+		date_trunc('month', s.start_date::timestamp),
+		LEAST(date_trunc('month', COALESCE(s.end_date, $2::date)::timestamp), $2::timestamp),
+		interval '1 month') AS m(month)
+	WHERE s.tenant_id = $1
+	GROUP BY 1, 2, 3`
+
+// Until returns the last month the rollup of the tenant covers, or false
+// when it has not been built yet
+func Until(q database.Querier, tenantID uuid.UUID) (time.Time, bool, error) {
+	return until(q, tenantID, "")
+}
+
+func until(q database.Querier, tenantID uuid.UUID, lock string) (time.Time, bool, error) {
+	var month time.Time
+	err := q.Get(&month, `SELECT until_month FROM monthly_spend_state WHERE tenant_id = $1`+lock, tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return month, true, nil
+}
+
+// Apply moves the cost of a subscription in the rollup from its stored
+// state before the change to the one after; before is nil for created
+// subscriptions and after for deleted ones. Called with the transaction of
+// the change, the rollup changes exactly when it commits. Both states are
+// priced with the past prices stored when Apply runs: record a past price
+// before applying the change, and delete the subscription, which deletes
+// its past prices, after. Tenants without a built rollup are left to the
+// rebuild.
+func Apply(q database.Querier, before, after *models.Subscription) error {
+	if before != nil && after != nil && sameCost(*before, *after) {
+		return nil
+	}
+
+	subscription := before
+	if subscription == nil {
+		subscription = after
+	}
+
+	// Rebuilds of the tenant wait for the change to commit
+	month, ok, err := until(q, subscription.TenantID, " FOR SHARE")
+	if err != nil || !ok {
+		return err
+	}
+
+	if before != nil {
+		if err := add(q, *before, -1, month); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if err := add(q, *after, 1, month); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sameCost reports whether both states of a subscription cost the same in
+// every month of the rollup. The rollup holds full prices by owner, so
+// sharing rules, category and tags never change it.
+func sameCost(a, b models.Subscription) bool {
+	if a.Price != b.Price || a.UserID != b.UserID || a.ServiceID != b.ServiceID || !a.StartDate.Equal(b.StartDate) {
+		return false
+	}
+	if a.EndDate == nil || b.EndDate == nil {
+		return a.EndDate == nil && b.EndDate == nil
+	}
+	return a.EndDate.Equal(*b.EndDate)
+}
+
+// Months returns the first and last month the rollup charges the
+// subscription for when it covers months through until, or false when it
+// charges none
+func Months(subscription models.Subscription, until time.Time) (time.Time, time.Time, bool) {
+	from := monthOf(subscription.StartDate)
+	to := monthOf(until)
+	if subscription.EndDate != nil && monthOf(*subscription.EndDate).Before(to) {
+		to = monthOf(*subscription.EndDate)
+	}
+	return from, to, !to.Before(from)
+}
+
+// add adds the price of every month of the subscription through the until
+// month, multiplied by sign
+func add(q database.Querier, subscription models.Subscription, sign int64, until time.Time) error {
+	from, to, ok := Months(subscription, until)
+	if !ok {
+		return nil
+	}
+
+	_, err := q.Exec(`
+		INSERT INTO monthly_spend (tenant_id, month, user_id, service_id, amount)
+		SELECT s.tenant_id, m.month::date, $2::uuid, $3::uuid, $4::bigint * `+monthPrice+`
+		FROM (SELECT $1::uuid AS tenant_id, $5::uuid AS id, $6::date AS start_date, $7::integer AS price) s
+		CROSS JOIN generate_series($8::timestamp, $9::timestamp, interval '1 month') AS m(month)
+		ON CONFLICT (tenant_id, month, user_id, service_id)
+		DO UPDATE SET amount = monthly_spend.amount + EXCLUDED.amount`,
+		subscription.TenantID, subscription.UserID, subscription.ServiceID, sign,
+		subscription.ID, subscription.StartDate, subscription.Price, from, to)
+	return err
+}
+
+// Rebuild recomputes the rollup of the tenant from its subscriptions,
+// counting open-ended ones through the until month. Run it in a
+// transaction: it locks the tenant against concurrent Apply calls. Changes
+// committed during the first rebuild of a tenant, before it has a lock to
+// take, are picked up by the next one.
+func Rebuild(q database.Querier, tenantID uuid.UUID, until time.Time) error {
+	_, err := q.Exec(`
+		INSERT INTO monthly_spend_state (tenant_id, until_month) VALUES ($1, $2)
+		ON CONFLICT (tenant_id) DO UPDATE SET until_month = EXCLUDED.until_month, rebuilt_at = NOW()`,
+		tenantID, monthOf(until))
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.Exec(`DELETE FROM monthly_spend WHERE tenant_id = $1`, tenantID); err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO monthly_spend (tenant_id, month, user_id, service_id, amount)
+		SELECT $1::uuid, month, user_id, service_id, amount FROM (`+spendQuery+`) spend`,
+		tenantID, monthOf(until))
+	return err
+}
+
+// Check compares the rollup of the tenant with its subscriptions, returning
+// the months that differ. Run it in a transaction so rebuilds wait for it.
+func Check(q database.Querier, tenantID uuid.UUID) ([]Mismatch, error) {
+	month, ok, err := until(q, tenantID, " FOR SHARE")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	mismatches := []Mismatch{}
+	err = q.Select(&mismatches, `
+		WITH expected AS (
+			SELECT * FROM (`+spendQuery+`) spend WHERE amount <> 0
+		),
+		actual AS (
+			SELECT month, user_id, service_id, amount FROM monthly_spend
+			WHERE tenant_id = $1 AND amount <> 0
+		)
+		SELECT month, user_id, service_id,
+			COALESCE(expected.amount, 0) AS expected, COALESCE(actual.amount, 0) AS actual
+		FROM expected FULL JOIN actual USING (month, user_id, service_id)
+		WHERE expected.amount IS DISTINCT FROM actual.amount
+		ORDER BY month, user_id, service_id`, tenantID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	return mismatches, nil
+}
+
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
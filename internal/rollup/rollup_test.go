@@ -0,0 +1,90 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMonths(t *testing.T) {
+	end := date(2025, 4, 10)
+	early := date(2024, 12, 31)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      *time.Time
+		until    time.Time
+		wantFrom time.Time
+		wantTo   time.Time
+		wantOK   bool
+	}{
+		{"open-ended runs through until", date(2025, 1, 15), nil, date(2025, 6, 1), date(2025, 1, 1), date(2025, 6, 1), true},
+		{"ended before until", date(2025, 1, 15), &end, date(2025, 6, 1), date(2025, 1, 1), date(2025, 4, 1), true},
+		{"ends after until", date(2025, 1, 15), &end, date(2025, 2, 1), date(2025, 1, 1), date(2025, 2, 1), true},
+		{"single month", date(2025, 4, 1), &end, date(2025, 6, 1), date(2025, 4, 1), date(2025, 4, 1), true},
+		{"starts after until", date(2025, 7, 1), nil, date(2025, 6, 1), time.Time{}, time.Time{}, false},
+		{"ends before it starts", date(2025, 1, 15), &early, date(2025, 6, 1), time.Time{}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, ok := Months(models.Subscription{StartDate: tt.start, EndDate: tt.end}, tt.until)
+			if ok != tt.wantOK {
+				t.Fatalf("Months() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (!from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo)) {
+				t.Fatalf("Months() = %v..%v, want %v..%v", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestSameCost(t *testing.T) {
+	household := uuid.New()
+	category := "video"
+	end := date(2025, 6, 30)
+	base := models.Subscription{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		ServiceID: uuid.New(),
+		Price:     599,
+		StartDate: date(2025, 1, 1),
+		SplitType: models.SplitNone,
+	}
+
+	tests := []struct {
+		name   string
+		change func(s *models.Subscription)
+		want   bool
+	}{
+		// Updates of cost sharing never call Apply; the rollup holds full
+		// prices by owner, so they must not change it
+		{"split type", func(s *models.Subscription) { s.SplitType = models.SplitEqual }, true},
+		{"household", func(s *models.Subscription) { s.HouseholdID = &household }, true},
+		{"category", func(s *models.Subscription) { s.Category = &category }, true},
+		{"tags", func(s *models.Subscription) { s.Tags = []string{"family"} }, true},
+		{"service name", func(s *models.Subscription) { s.ServiceName = "Netflix" }, true},
+		{"price", func(s *models.Subscription) { s.Price = 699 }, false},
+		{"owner", func(s *models.Subscription) { s.UserID = uuid.New() }, false},
+		{"service", func(s *models.Subscription) { s.ServiceID = uuid.New() }, false},
+		{"start date", func(s *models.Subscription) { s.StartDate = date(2025, 2, 1) }, false},
+		{"end date set", func(s *models.Subscription) { s.EndDate = &end }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			tt.change(&after)
+			if got := sameCost(base, after); got != tt.want {
+				t.Fatalf("sameCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
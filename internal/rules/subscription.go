@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/pkg/models"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Violation is a business rule broken by an otherwise well-formed
+// subscription; handlers answer 422
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// OverlapError reports an existing subscription of the same user to the
+// same service whose period overlaps; handlers answer 409
+type OverlapError struct {
+	Existing models.Subscription
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("subscription overlaps with subscription %s to %s for the same user (%s)",
+		e.Existing.ID, e.Existing.ServiceName, period(e.Existing.StartDate, e.Existing.EndDate))
+}
+
+// CheckSubscription validates the lifecycle of a subscription as it would
+// be stored, i.e. after merging a partial update into the stored row.
+// shares are the current cost shares of the subscription.
+func CheckSubscription(subscription models.Subscription, shares []models.SubscriptionShare) error {
+	if subscription.EndDate != nil && subscription.EndDate.Before(subscription.StartDate) {
+		return &Violation{
+			Rule: "period",
+			Message: fmt.Sprintf("end date %s is before start date %s",
+				subscription.EndDate.Format(dateLayout), subscription.StartDate.Format(dateLayout)),
+		}
+	}
+
+	if subscription.Price < 0 {
+		return &Violation{Rule: "price", Message: "price cannot be negative"}
+	}
+
+	if subscription.SplitType == models.SplitFixed {
+		total := 0
+		for _, share := range shares {
+			if share.Amount != nil {
+				total += *share.Amount
+			}
+		}
+		if total > subscription.Price {
+			return &Violation{
+				Rule:    "shares",
+				Message: fmt.Sprintf("fixed shares total %d exceeds price %d", total, subscription.Price),
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckPriceChange validates a price the subscription is to switch to on
+// the date: the date must come after today and within the subscription,
+// and the new price must still cover the shares
+func CheckPriceChange(subscription models.Subscription, shares []models.SubscriptionShare, date time.Time, price int, today time.Time) error {
+	if !date.After(today) {
+		return &Violation{Rule: "price_change", Message: "price change must take effect after today"}
+	}
+
+	if date.Before(subscription.StartDate) || (subscription.EndDate != nil && date.After(*subscription.EndDate)) {
+		return &Violation{
+			Rule: "price_change",
+			Message: fmt.Sprintf("price change on %s is outside the subscription (%s)",
+				date.Format(dateLayout), period(subscription.StartDate, subscription.EndDate)),
+		}
+	}
+
+	subscription.Price = price
+	return CheckSubscription(subscription, shares)
+}
+
+// CheckOverlap rejects the subscription when the same user already has a
+// subscription to the same service in an overlapping period. Call it inside
+// a transaction: it takes a transaction-scoped advisory lock so concurrent
+// writes for the same user and service cannot both pass the check.
+func CheckOverlap(db database.Querier, subscription models.Subscription) error {
+	lockKey := fmt.Sprintf("subscriptions:%s:%s:%s", subscription.TenantID, subscription.UserID, subscription.ServiceID)
+	if _, err := db.Exec(`SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, lockKey); err != nil {
+		return err
+	}
+
+	var existing records.Subscription
+	query := `
+		SELECT * FROM subscriptions
+		WHERE tenant_id = $1 AND user_id = $2 AND service_id = $3 AND id <> $4
+		  AND start_date <= COALESCE($5::date, 'infinity'::date)
+		  AND COALESCE(end_date, 'infinity'::date) >= $6
+		ORDER BY start_date
+		LIMIT 1`
+
+	err := db.Get(&existing, query, subscription.TenantID, subscription.UserID, subscription.ServiceID,
+		subscription.ID, subscription.EndDate, subscription.StartDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return &OverlapError{Existing: existing.Model()}
+}
+
+func period(start time.Time, end *time.Time) string {
+	if end == nil {
+		return start.Format(dateLayout) + " onwards"
+	}
+	return start.Format(dateLayout) + " to " + end.Format(dateLayout)
+}
@@ -0,0 +1,109 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func amount(v int) *int {
+	return &v
+}
+
+func TestCheckSubscription(t *testing.T) {
+	end := date(2025, 12, 31)
+	early := date(2025, 6, 30)
+	sameDay := date(2025, 7, 1)
+
+	tests := []struct {
+		name         string
+		subscription models.Subscription
+		shares       []models.SubscriptionShare
+		wantRule     string // Empty when the subscription is valid
+	}{
+		{name: "open-ended", subscription: models.Subscription{Price: 400, StartDate: date(2025, 7, 1)}},
+		{name: "ends on the start date", subscription: models.Subscription{Price: 400, StartDate: date(2025, 7, 1), EndDate: &sameDay}},
+		{name: "ends before the start", subscription: models.Subscription{Price: 400, StartDate: date(2025, 7, 1), EndDate: &early}, wantRule: "period"},
+		{name: "free", subscription: models.Subscription{Price: 0, StartDate: date(2025, 7, 1), EndDate: &end}},
+		{name: "negative price", subscription: models.Subscription{Price: -1, StartDate: date(2025, 7, 1)}, wantRule: "price"},
+		{
+			name:         "fixed shares within the price",
+			subscription: models.Subscription{Price: 400, StartDate: date(2025, 7, 1), SplitType: models.SplitFixed},
+			shares:       []models.SubscriptionShare{{Amount: amount(150)}, {Amount: amount(250)}},
+		},
+		{
+			name:         "fixed shares over the price",
+			subscription: models.Subscription{Price: 400, StartDate: date(2025, 7, 1), SplitType: models.SplitFixed},
+			shares:       []models.SubscriptionShare{{Amount: amount(150)}, {Amount: amount(251)}},
+			wantRule:     "shares",
+		},
+		{
+			name:         "fixed shares of a free subscription",
+			subscription: models.Subscription{Price: 0, StartDate: date(2025, 7, 1), SplitType: models.SplitFixed},
+			shares:       []models.SubscriptionShare{{Amount: amount(1)}},
+			wantRule:     "shares",
+		},
+		{
+			name:         "shares of other split types are not summed",
+			subscription: models.Subscription{Price: 0, StartDate: date(2025, 7, 1), SplitType: models.SplitEqual},
+			shares:       []models.SubscriptionShare{{Amount: amount(100)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkViolation(t, CheckSubscription(tt.subscription, tt.shares), tt.wantRule)
+		})
+	}
+}
+
+func TestCheckPriceChange(t *testing.T) {
+	end := date(2025, 12, 31)
+	subscription := models.Subscription{Price: 400, StartDate: date(2025, 7, 1), EndDate: &end, SplitType: models.SplitFixed}
+	shares := []models.SubscriptionShare{{Amount: amount(300)}}
+	today := date(2025, 8, 15)
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		price    int
+		wantRule string
+	}{
+		{name: "tomorrow", date: date(2025, 8, 16), price: 500},
+		{name: "on the end date", date: end, price: 300},
+		{name: "today", date: today, price: 500, wantRule: "price_change"},
+		{name: "after the end", date: date(2026, 1, 1), price: 500, wantRule: "price_change"},
+		{name: "below the shares", date: date(2025, 9, 1), price: 299, wantRule: "shares"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkViolation(t, CheckPriceChange(subscription, shares, tt.date, tt.price, today), tt.wantRule)
+		})
+	}
+
+	if subscription.Price != 400 {
+		t.Errorf("CheckPriceChange() changed the price of the subscription to %d", subscription.Price)
+	}
+}
+
+func checkViolation(t *testing.T, err error, rule string) {
+	t.Helper()
+
+	if rule == "" {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+
+	var violation *Violation
+	if !errors.As(err, &violation) || violation.Rule != rule {
+		t.Fatalf("error = %v, want a violation of %s", err, rule)
+	}
+}
@@ -0,0 +1,393 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/billing"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/rollup"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Aggregate computes the cost of subscriptions over the period of the
+// request. The monthly mode charges every subscription overlapping the
+// period for each month it was active; otherwise only subscriptions lying
+// within the period count, once. Regular users aggregate their own costs.
+func (s *Service) Aggregate(ctx context.Context, req models.AggregationRequest, monthly bool) (*models.AggregationResponse, error) {
+	if err := validation.ValidateAggregationRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.UserID == nil {
+		req.UserID = s.OwnerScope(ctx)
+	}
+
+	if err := s.Authorize(ctx, authz.ActionAggregate, req.UserID); err != nil {
+		return nil, err
+	}
+
+	startDate, err := validation.ParseDate(req.StartDate)
+	if err != nil {
+		return nil, &InputError{Message: "Invalid start date format (use MM-YYYY or YYYY-MM-DD)", Err: err}
+	}
+
+	endDate, err := validation.ParseEndDate(req.EndDate)
+	if err != nil {
+		return nil, &InputError{Message: "Invalid end date format (use MM-YYYY or YYYY-MM-DD)", Err: err}
+	}
+
+	db := s.Tenant(ctx)
+
+	// Shares of split subscriptions and proration are computed per row
+	var totalCost int64
+	var groups []models.AggregationGroup
+	rolledUp := false
+	if monthly && usesRollup(req, startDate, endDate) {
+		totalCost, groups, rolledUp, err = aggregateRollup(db, req, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !rolledUp {
+		if req.Prorate || req.UserID != nil || monthly {
+			totalCost, groups, err = aggregateRows(db, req, startDate, endDate, monthly)
+		} else {
+			totalCost, groups, err = aggregateTotal(db, req, startDate, endDate)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := models.AggregationResponse{
+		TotalCost: totalCost,
+		Period:    fmt.Sprintf("%s to %s", req.StartDate, req.EndDate),
+		UserID:    req.UserID,
+		Prorated:  req.Prorate,
+		GroupBy:   req.GroupBy,
+		Groups:    groups,
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"total_cost": totalCost,
+		"period":     response.Period,
+		"group_by":   req.GroupBy,
+		"monthly":    monthly,
+		"rolled_up":  rolledUp,
+	}).Info("Subscription aggregation completed")
+
+	return &response, nil
+}
+
+// catalogServiceName is the current catalog name of the service of a
+// subscription. Services are grouped by it on every path, since the rollup
+// only knows service IDs.
+const catalogServiceName = `(SELECT name FROM services
+	WHERE services.tenant_id = subscriptions.tenant_id AND services.id = subscriptions.service_id)`
+
+// aggregationGroupKeys maps group_by values to the SQL expression of the
+// group key. Groups costing nothing are left out on every path, as the
+// rollup cannot tell them from months whose cost moved elsewhere.
+var aggregationGroupKeys = map[string]string{
+	"service":  catalogServiceName,
+	"category": "COALESCE(category, '')",
+	"tag":      "COALESCE(tag, '')",
+}
+
+// aggregationFilters builds the tenant condition and conditions for the
+// optional aggregation filters, numbering placeholders after the already bound args
+func aggregationFilters(tenantID uuid.UUID, req models.AggregationRequest, args []interface{}) (string, []interface{}) {
+	argCount := len(args) + 1
+	tenantArg := argCount
+	conditions := fmt.Sprintf(" AND tenant_id = $%d", tenantArg)
+	args = append(args, tenantID)
+
+	// Subscriptions the user owns or shares the cost of
+	if req.UserID != nil {
+		argCount++
+		conditions += fmt.Sprintf(` AND (user_id = $%[2]d
+			OR id IN (SELECT subscription_id FROM subscription_shares WHERE tenant_id = $%[1]d AND user_id = $%[2]d)
+			OR (split_type = 'equal' AND household_id IN (
+				SELECT household_id FROM household_members WHERE tenant_id = $%[1]d AND user_id = $%[2]d)))`,
+			tenantArg, argCount)
+		args = append(args, req.UserID)
+	}
+
+	if req.ServiceID != nil {
+		argCount++
+		conditions += fmt.Sprintf(" AND service_id = $%d", argCount)
+		args = append(args, req.ServiceID)
+	}
+
+	if req.ServiceName != nil {
+		argCount++
+		conditions += " AND " + serviceNameCondition(tenantArg, argCount)
+		args = append(args, validation.NormalizeServiceName(*req.ServiceName))
+	}
+
+	if req.Category != nil {
+		argCount++
+		conditions += fmt.Sprintf(" AND category = $%d", argCount)
+		args = append(args, *req.Category)
+	}
+
+	if len(req.Tags) > 0 {
+		argCount++
+		conditions += fmt.Sprintf(" AND tags @> $%d", argCount)
+		args = append(args, pq.StringArray(validation.NormalizeTags(req.Tags)))
+	}
+
+	return conditions, args
+}
+
+// aggregateTotal sums prices of subscriptions lying within the period
+func aggregateTotal(db *database.TenantDB, req models.AggregationRequest, startDate, endDate time.Time) (int64, []models.AggregationGroup, error) {
+	filters, args := aggregationFilters(db.TenantID(), req, []interface{}{startDate, endDate})
+	where := "WHERE start_date >= $1 AND (end_date IS NULL OR end_date <= $2)" + filters
+
+	var result struct {
+		TotalCost int64 `db:"total_cost"`
+	}
+
+	query := "SELECT COALESCE(SUM(price), 0) as total_cost FROM subscriptions " + where
+	if err := db.Get(&result, query, args...); err != nil {
+		return 0, nil, err
+	}
+
+	if req.GroupBy == "" {
+		return result.TotalCost, nil, nil
+	}
+
+	from := "subscriptions"
+	if req.GroupBy == "tag" {
+		from = "subscriptions LEFT JOIN LATERAL unnest(tags) AS tag ON true"
+	}
+
+	groups := []records.AggregationGroup{}
+	query = fmt.Sprintf(`
+		SELECT %s as key, COALESCE(SUM(price), 0) as total_cost
+		FROM %s
+		%s
+		GROUP BY 1
+		HAVING SUM(price) <> 0
+		ORDER BY 1 COLLATE "C"`, aggregationGroupKeys[req.GroupBy], from, where)
+
+	if err := db.Select(&groups, query, args...); err != nil {
+		return 0, nil, err
+	}
+
+	return result.TotalCost, records.Models[models.AggregationGroup](groups), nil
+}
+
+// usesRollup reports whether the monthly spend rollup can answer the
+// monthly aggregation: it holds whole months of full prices by owner and
+// service, so shares, proration, category and tag filters and grouping
+// other than by service need the subscriptions
+func usesRollup(req models.AggregationRequest, startDate, endDate time.Time) bool {
+	if req.Prorate || req.UserID != nil || req.Category != nil || len(req.Tags) > 0 {
+		return false
+	}
+	if req.GroupBy != "" && req.GroupBy != "service" {
+		return false
+	}
+	return startDate.Day() == 1 && endDate.AddDate(0, 0, 1).Day() == 1
+}
+
+// aggregateRollup sums the monthly spend rollup over the months of the
+// period, returning false when the rollup of the tenant is not built or
+// does not reach the end of the period
+func aggregateRollup(db *database.TenantDB, req models.AggregationRequest, startDate, endDate time.Time) (int64, []models.AggregationGroup, bool, error) {
+	until, ok, err := rollup.Until(db, db.TenantID())
+	if err != nil || !ok || endDate.After(until.AddDate(0, 1, -1)) {
+		return 0, nil, false, err
+	}
+
+	filters, args := aggregationFilters(db.TenantID(), req, []interface{}{startDate, endDate})
+	where := "WHERE month >= $1 AND month <= $2" + filters
+
+	var result struct {
+		TotalCost int64 `db:"total_cost"`
+	}
+
+	query := "SELECT COALESCE(SUM(amount), 0) as total_cost FROM monthly_spend " + where
+	if err := db.Get(&result, query, args...); err != nil {
+		return 0, nil, false, err
+	}
+
+	if req.GroupBy == "" {
+		return result.TotalCost, nil, true, nil
+	}
+
+	groups := []records.AggregationGroup{}
+	query = `
+		SELECT services.name as key, COALESCE(SUM(spend.amount), 0) as total_cost
+		FROM (SELECT tenant_id, service_id, amount FROM monthly_spend ` + where + `) spend
+		JOIN services ON services.tenant_id = spend.tenant_id AND services.id = spend.service_id
+		GROUP BY 1
+		HAVING SUM(spend.amount) <> 0
+		ORDER BY 1 COLLATE "C"`
+
+	if err := db.Select(&groups, query, args...); err != nil {
+		return 0, nil, false, err
+	}
+
+	return result.TotalCost, records.Models[models.AggregationGroup](groups), true, nil
+}
+
+// aggregateRows computes costs subscription by subscription. With prorate
+// every subscription overlapping the period is charged by the share of days
+// it was active in each month, in the monthly mode by the months it was
+// active; with user_id only the user's share counts.
+func aggregateRows(db *database.TenantDB, req models.AggregationRequest, startDate, endDate time.Time, monthly bool) (int64, []models.AggregationGroup, error) {
+	period := "start_date >= $1 AND (end_date IS NULL OR end_date <= $2)"
+	if req.Prorate || monthly {
+		period = "start_date <= $2 AND (end_date IS NULL OR end_date >= $1)"
+	}
+
+	filters, args := aggregationFilters(db.TenantID(), req, []interface{}{startDate, endDate})
+	query := `
+		SELECT id, user_id, price, start_date, end_date, ` + catalogServiceName + ` AS service_name,
+			category, tags, split_type, household_id
+		FROM subscriptions
+		WHERE ` + period + filters
+
+	var rows []costRow
+	if err := db.Select(&rows, query, args...); err != nil {
+		return 0, nil, err
+	}
+
+	// Months are charged at the prices they had, subscriptions lying
+	// within the period once at the current price
+	var history map[uuid.UUID][]models.PricePeriod
+	if req.Prorate || monthly {
+		ids := make([]uuid.UUID, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+
+		var err error
+		if history, err = PriceHistory(db, db.TenantID(), ids); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Load sharing rules of split subscriptions in bulk
+	var shares map[uuid.UUID][]models.SubscriptionShare
+	var members map[uuid.UUID][]uuid.UUID
+	if req.UserID != nil {
+		subscriptionIDs := []uuid.UUID{}
+		householdIDs := []uuid.UUID{}
+		for _, row := range rows {
+			if row.SplitType != models.SplitNone {
+				subscriptionIDs = append(subscriptionIDs, row.ID)
+			}
+			if row.HouseholdID != nil {
+				householdIDs = append(householdIDs, *row.HouseholdID)
+			}
+		}
+
+		var err error
+		if shares, err = Shares(db, db.TenantID(), subscriptionIDs); err != nil {
+			return 0, nil, err
+		}
+		if members, err = HouseholdMembers(db, db.TenantID(), householdIDs); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	total, groupCosts := rowCosts(rows, history, req, startDate, endDate, monthly, shares, members)
+	if req.GroupBy == "" {
+		return billing.RoundCost(total), nil, nil
+	}
+
+	return billing.RoundCost(total), aggregationGroups(groupCosts), nil
+}
+
+// costRow is a subscription as aggregateRows charges it
+type costRow struct {
+	ID          uuid.UUID      `db:"id"`
+	UserID      uuid.UUID      `db:"user_id"`
+	Price       int            `db:"price"`
+	StartDate   time.Time      `db:"start_date"`
+	EndDate     *time.Time     `db:"end_date"`
+	ServiceName string         `db:"service_name"` // Current catalog name
+	Category    *string        `db:"category"`
+	Tags        pq.StringArray `db:"tags"`
+	SplitType   string         `db:"split_type"`
+	HouseholdID *uuid.UUID     `db:"household_id"`
+}
+
+// rowCosts charges the rows for the period and returns the total and the
+// costs by group key. Months before applied price changes are charged at
+// the past prices from history. Shares and household members are only
+// needed with user_id.
+func rowCosts(rows []costRow, history map[uuid.UUID][]models.PricePeriod, req models.AggregationRequest, startDate, endDate time.Time, monthly bool,
+	shares map[uuid.UUID][]models.SubscriptionShare, members map[uuid.UUID][]uuid.UUID) (float64, map[string]float64) {
+	var total float64
+	groupCosts := make(map[string]float64)
+	for _, row := range rows {
+		var cost float64
+		for _, span := range billing.PriceSpans(row.Price, history[row.ID], row.StartDate, startDate, endDate) {
+			spanCost := float64(span.Price)
+			switch {
+			case req.Prorate:
+				spanCost = billing.ProratedCost(span.Price, row.StartDate, row.EndDate, span.From, span.To)
+			case monthly:
+				spanCost = billing.MonthlyCost(span.Price, row.StartDate, row.EndDate, span.From, span.To)
+			}
+
+			if req.UserID != nil {
+				var participants []uuid.UUID
+				if row.HouseholdID != nil {
+					participants = members[*row.HouseholdID]
+				}
+				spanCost *= billing.ShareFraction(span.Price, row.UserID, row.SplitType, shares[row.ID], participants, *req.UserID)
+			}
+			cost += spanCost
+		}
+
+		total += cost
+
+		switch req.GroupBy {
+		case "service":
+			groupCosts[row.ServiceName] += cost
+		case "category":
+			key := ""
+			if row.Category != nil {
+				key = *row.Category
+			}
+			groupCosts[key] += cost
+		case "tag":
+			if len(row.Tags) == 0 {
+				groupCosts[""] += cost
+			}
+			for _, tag := range row.Tags {
+				groupCosts[tag] += cost
+			}
+		}
+	}
+
+	return total, groupCosts
+}
+
+// aggregationGroups rounds the group costs and orders the groups by key,
+// leaving out groups costing nothing like the SQL paths do
+func aggregationGroups(groupCosts map[string]float64) []models.AggregationGroup {
+	groups := []models.AggregationGroup{}
+	for key, cost := range groupCosts {
+		if rounded := billing.RoundCost(cost); rounded != 0 {
+			groups = append(groups, models.AggregationGroup{Key: key, TotalCost: rounded})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
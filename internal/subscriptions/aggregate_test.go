@@ -0,0 +1,133 @@
+package subscriptions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"subscription-aggregator/internal/billing"
+	"subscription-aggregator/internal/rollup"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// rollupGroups charges the rows the way the monthly spend rollup built up
+// to until and summed by aggregateRollup does: the full price in effect on
+// the first active day for each month of the period the subscription was
+// active
+func rollupGroups(rows []costRow, history map[uuid.UUID][]models.PricePeriod, startDate, endDate, until time.Time) (int64, []models.AggregationGroup) {
+	var total int64
+	groupCosts := make(map[string]float64)
+	for _, row := range rows {
+		from, to, ok := rollup.Months(models.Subscription{StartDate: row.StartDate, EndDate: row.EndDate}, until)
+		if !ok {
+			continue
+		}
+		for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+			if month.Before(startDate) || month.After(endDate) {
+				continue
+			}
+			firstDay := month
+			if row.StartDate.After(firstDay) {
+				firstDay = row.StartDate
+			}
+			price := billing.PastPriceOn(row.Price, history[row.ID], firstDay)
+			total += int64(price)
+			groupCosts[row.ServiceName] += float64(price)
+		}
+	}
+	return total, aggregationGroups(groupCosts)
+}
+
+func TestAggregateRollupParity(t *testing.T) {
+	ended := date(2025, 3, 14)
+	endedBefore := date(2024, 12, 20)
+	row := func(service string, price int, start time.Time, end *time.Time) costRow {
+		return costRow{ID: uuid.New(), UserID: uuid.New(), Price: price, StartDate: start, EndDate: end, ServiceName: service}
+	}
+
+	repriced := row("Netflix", 799, date(2024, 11, 1), nil)
+	repricedLate := row("Okko", 399, date(2025, 2, 20), nil)
+	history := map[uuid.UUID][]models.PricePeriod{
+		repriced.ID: {
+			{ValidUntil: date(2025, 2, 1), Price: 499},
+			{ValidUntil: date(2025, 4, 15), Price: 599},
+		},
+		// The first active day of February is the 20th
+		repricedLate.ID: {{ValidUntil: date(2025, 2, 25), Price: 299}},
+	}
+
+	tests := []struct {
+		name string
+		rows []costRow
+	}{
+		{"open-ended", []costRow{row("Netflix", 599, date(2024, 11, 1), nil)}},
+		{"started mid-month", []costRow{row("Netflix", 599, date(2025, 2, 17), nil)}},
+		{"ended mid-period", []costRow{row("Spotify", 299, date(2025, 1, 1), &ended)}},
+		{"ended before the period", []costRow{row("Spotify", 299, date(2024, 6, 1), &endedBefore)}},
+		{"starts after the period", []costRow{row("Spotify", 299, date(2025, 8, 1), nil)}},
+		{"free subscription", []costRow{
+			row("Netflix", 599, date(2025, 1, 1), nil),
+			row("Trial", 0, date(2025, 1, 1), nil),
+		}},
+		{"same service twice", []costRow{
+			row("Netflix", 599, date(2025, 1, 1), nil),
+			row("Netflix", 899, date(2025, 2, 1), &ended),
+		}},
+		{"repriced", []costRow{repriced, repricedLate}},
+		{"keys in byte order", []costRow{
+			row("netflix", 100, date(2025, 1, 1), nil),
+			row("Okko", 200, date(2025, 1, 1), nil),
+			row("Apple TV", 300, date(2025, 1, 1), nil),
+		}},
+	}
+
+	startDate, endDate, until := date(2025, 1, 1), date(2025, 6, 30), date(2025, 7, 1)
+	req := models.AggregationRequest{GroupBy: "service"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !usesRollup(req, startDate, endDate) {
+				t.Fatal("usesRollup() = false, want true")
+			}
+
+			rawTotal, groupCosts := rowCosts(tt.rows, history, req, startDate, endDate, true, nil, nil)
+			rawGroups := aggregationGroups(groupCosts)
+			total, groups := rollupGroups(tt.rows, history, startDate, endDate, until)
+
+			if got := int64(rawTotal); got != total {
+				t.Errorf("raw total = %d, rollup total = %d", got, total)
+			}
+			if !reflect.DeepEqual(rawGroups, groups) {
+				t.Errorf("raw groups = %v, rollup groups = %v", rawGroups, groups)
+			}
+		})
+	}
+}
+
+func TestAggregationGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		groupCosts map[string]float64
+		want       []models.AggregationGroup
+	}{
+		{"empty", map[string]float64{}, []models.AggregationGroup{}},
+		{"zero groups left out", map[string]float64{"Trial": 0, "Netflix": 599, "Free": 0.4},
+			[]models.AggregationGroup{{Key: "Netflix", TotalCost: 599}}},
+		{"rounded", map[string]float64{"Netflix": 199.5, "Okko": 100.49},
+			[]models.AggregationGroup{{Key: "Netflix", TotalCost: 200}, {Key: "Okko", TotalCost: 100}}},
+		{"byte order", map[string]float64{"netflix": 1, "Okko": 2, "": 3},
+			[]models.AggregationGroup{{Key: "", TotalCost: 3}, {Key: "Okko", TotalCost: 2}, {Key: "netflix", TotalCost: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregationGroups(tt.groupCosts); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("aggregationGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
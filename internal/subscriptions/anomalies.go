@@ -0,0 +1,222 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// anomalyPercent is how much pricier than before or than the catalog a
+// subscription must be to count as an anomaly
+const anomalyPercent = 50
+
+// planWords are plan names dropped from service names when looking for the
+// same product under different services
+var planWords = map[string]bool{
+	"premium": true, "plus": true, "pro": true, "basic": true, "standard": true, "family": true,
+	"individual": true, "duo": true, "student": true, "personal": true, "hd": true, "ultra": true,
+	"monthly": true, "subscription": true, "премиум": true, "плюс": true, "подписка": true,
+}
+
+// Anomalies reports likely problems with subscriptions of the user, or of
+// every user when userID is nil: the same product paid under different
+// services or twice for the same service at the same time, renewals and
+// scheduled changes much pricier than before, and prices far above the
+// catalog. Only subscriptions active today or later are reported on.
+// Regular users check their own subscriptions.
+func (s *Service) Anomalies(ctx context.Context, userID *uuid.UUID) (*models.AnomalyReport, error) {
+	if err := s.Authorize(ctx, authz.ActionList, nil); err != nil {
+		return nil, err
+	}
+
+	ownerScope := s.OwnerScope(ctx)
+	if userID == nil {
+		userID = ownerScope
+	} else if ownerScope != nil {
+		if err := s.Authorize(ctx, authz.ActionRead, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	db := s.Tenant(ctx)
+
+	query := `SELECT * FROM subscriptions WHERE tenant_id = $1`
+	args := []interface{}{db.TenantID()}
+	if userID != nil {
+		query += " AND user_id = $2"
+		args = append(args, *userID)
+	}
+	query += " ORDER BY user_id, start_date, id"
+
+	var rows []records.Subscription
+	if err := db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	subscriptionIDs := make([]uuid.UUID, 0, len(rows))
+	serviceIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		subscriptionIDs = append(subscriptionIDs, row.ID)
+		serviceIDs = append(serviceIDs, row.ServiceID)
+	}
+
+	changes, err := PriceChanges(db, db.TenantID(), subscriptionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []records.Service
+	err = db.Select(&services, `SELECT * FROM services WHERE tenant_id = $1 AND id = ANY($2)`, db.TenantID(), pq.Array(serviceIDs))
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(map[uuid.UUID]models.Service, len(services))
+	for _, service := range services {
+		catalog[service.ID] = service.Model()
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	byUser := make(map[uuid.UUID][]models.Subscription)
+	var users []uuid.UUID
+	for _, row := range rows {
+		if _, ok := byUser[row.UserID]; !ok {
+			users = append(users, row.UserID)
+		}
+		byUser[row.UserID] = append(byUser[row.UserID], row.Model())
+	}
+
+	report := &models.AnomalyReport{
+		UserID:      userID,
+		GeneratedAt: now,
+		Anomalies:   []models.Anomaly{},
+	}
+	for _, user := range users {
+		report.Anomalies = append(report.Anomalies, findAnomalies(byUser[user], changes, catalog, today)...)
+	}
+
+	return report, nil
+}
+
+// findAnomalies checks the subscriptions of one user, ordered by start date
+func findAnomalies(list []models.Subscription, changes map[uuid.UUID][]models.PriceChange, catalog map[uuid.UUID]models.Service, today time.Time) []models.Anomaly {
+	var anomalies []models.Anomaly
+	report := func(kind string, excess int, message string, ids ...uuid.UUID) {
+		anomalies = append(anomalies, models.Anomaly{
+			Kind:            kind,
+			Message:         message,
+			UserID:          list[0].UserID,
+			SubscriptionIDs: ids,
+			MonthlyExcess:   excess,
+		})
+	}
+
+	for i, a := range list {
+		for _, b := range list[i+1:] {
+			if !concurrentFrom(a, b, today) {
+				continue
+			}
+
+			switch {
+			case a.ServiceID == b.ServiceID:
+				report(models.AnomalyOverlappingPeriod, min(a.Price, b.Price),
+					fmt.Sprintf("Две подписки на %s действуют одновременно", a.ServiceName), a.ID, b.ID)
+			case productKey(a.ServiceName) != "" && productKey(a.ServiceName) == productKey(b.ServiceName):
+				report(models.AnomalyDuplicateService, min(a.Price, b.Price),
+					fmt.Sprintf("%s и %s похожи на один и тот же сервис, оплачиваемый дважды", a.ServiceName, b.ServiceName), a.ID, b.ID)
+			}
+		}
+	}
+
+	// Renewals: the next subscription to the same service after the previous
+	previous := make(map[uuid.UUID]models.Subscription)
+	for _, subscription := range list {
+		if prev, ok := previous[subscription.ServiceID]; ok && isActiveFrom(subscription, today) && isJump(prev.Price, subscription.Price) {
+			report(models.AnomalyPriceJump, subscription.Price-prev.Price,
+				fmt.Sprintf("Цена %s выросла с %d до %d", subscription.ServiceName, prev.Price, subscription.Price),
+				prev.ID, subscription.ID)
+		}
+		previous[subscription.ServiceID] = subscription
+
+		if !isActiveFrom(subscription, today) {
+			continue
+		}
+
+		price := subscription.Price
+		for _, change := range changes[subscription.ID] {
+			if isJump(price, change.Price) {
+				report(models.AnomalyPriceJump, change.Price-price,
+					fmt.Sprintf("Цена %s вырастет с %d до %d с %s", subscription.ServiceName, price, change.Price,
+						change.EffectiveDate.Format("2006-01-02")),
+					subscription.ID)
+			}
+			price = change.Price
+		}
+
+		service, ok := catalog[subscription.ServiceID]
+		if ok && service.DefaultPrice != nil && isJump(*service.DefaultPrice, subscription.Price) {
+			report(models.AnomalyAboveCatalogPrice, subscription.Price-*service.DefaultPrice,
+				fmt.Sprintf("%s стоит %d при цене каталога %d", subscription.ServiceName, subscription.Price, *service.DefaultPrice),
+				subscription.ID)
+		}
+	}
+
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].MonthlyExcess > anomalies[j].MonthlyExcess })
+	return anomalies
+}
+
+// isJump reports whether the price grew by more than anomalyPercent
+func isJump(from, to int) bool {
+	return from > 0 && to*100 > from*(100+anomalyPercent)
+}
+
+// isActiveFrom reports whether the subscription is active today or later
+func isActiveFrom(subscription models.Subscription, today time.Time) bool {
+	return subscription.EndDate == nil || !subscription.EndDate.Before(today)
+}
+
+// concurrentFrom reports whether both subscriptions are active on some day
+// from today on
+func concurrentFrom(a, b models.Subscription, today time.Time) bool {
+	start := a.StartDate
+	if b.StartDate.After(start) {
+		start = b.StartDate
+	}
+	if start.Before(today) {
+		start = today
+	}
+
+	for _, end := range []*time.Time{a.EndDate, b.EndDate} {
+		if end != nil && end.Before(start) {
+			return false
+		}
+	}
+	return true
+}
+
+// productKey reduces a service name to the product it names: lower-case
+// letters and digits without plan names, so "Netflix Premium" and
+// "netflix" share a key
+func productKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var key strings.Builder
+	for _, word := range words {
+		if !planWords[word] {
+			key.WriteString(word)
+		}
+	}
+	return key.String()
+}
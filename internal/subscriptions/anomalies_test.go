@@ -0,0 +1,207 @@
+package subscriptions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestProductKey(t *testing.T) {
+	tests := map[string]string{
+		"Netflix":               "netflix",
+		"Netflix Premium HD":    "netflix",
+		"YouTube Premium":       "youtube",
+		"Spotify Duo (Monthly)": "spotify",
+		"Яндекс Плюс":           "яндекс",
+		"Disney+":               "disney",
+		"Office 365":            "office365",
+		"Premium Plus":          "",
+	}
+	for name, want := range tests {
+		if got := productKey(name); got != want {
+			t.Errorf("productKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestIsJump(t *testing.T) {
+	tests := []struct {
+		from, to int
+		want     bool
+	}{
+		{100, 150, false},
+		{100, 151, true},
+		{599, 999, true},
+		{599, 299, false},
+		{0, 500, false},
+		{100, 0, false},
+	}
+	for _, tt := range tests {
+		if got := isJump(tt.from, tt.to); got != tt.want {
+			t.Errorf("isJump(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestConcurrentFrom(t *testing.T) {
+	today := date(2025, 3, 10)
+	at := func(t time.Time) *time.Time { return &t }
+	sub := func(start time.Time, end *time.Time) models.Subscription {
+		return models.Subscription{StartDate: start, EndDate: end}
+	}
+
+	tests := []struct {
+		name string
+		a, b models.Subscription
+		want bool
+	}{
+		{"both open", sub(date(2024, 1, 1), nil), sub(date(2025, 1, 1), nil), true},
+		{"overlap ahead", sub(date(2025, 1, 1), at(date(2025, 6, 30))), sub(date(2025, 6, 1), nil), true},
+		{"one after the other", sub(date(2025, 1, 1), at(date(2025, 5, 31))), sub(date(2025, 6, 1), nil), false},
+		{"overlapped in the past", sub(date(2024, 1, 1), at(date(2025, 3, 9))), sub(date(2024, 6, 1), nil), false},
+		{"meet today", sub(date(2024, 1, 1), at(today)), sub(date(2024, 6, 1), nil), true},
+		{"share the last day", sub(date(2025, 4, 1), at(date(2025, 4, 30))), sub(date(2025, 4, 30), nil), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := concurrentFrom(tt.a, tt.b, today); got != tt.want {
+				t.Fatalf("concurrentFrom() = %v, want %v", got, tt.want)
+			}
+			if got := concurrentFrom(tt.b, tt.a, today); got != tt.want {
+				t.Fatalf("concurrentFrom() swapped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindAnomalies(t *testing.T) {
+	today := date(2025, 3, 10)
+	userID := uuid.New()
+	netflix, youtube, youtubeFamily, spotify := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	at := func(t time.Time) *time.Time { return &t }
+	price := func(p int) *int { return &p }
+
+	sub := func(serviceID uuid.UUID, name string, p int, start time.Time, end *time.Time) models.Subscription {
+		return models.Subscription{ID: uuid.New(), UserID: userID, ServiceID: serviceID, ServiceName: name, Price: p, StartDate: start, EndDate: end}
+	}
+
+	type want struct {
+		kind   string
+		excess int
+		ids    []int // Indexes into the list
+	}
+
+	tests := []struct {
+		name    string
+		list    []models.Subscription
+		changes map[int][]models.PriceChange // Keyed by index into the list
+		catalog map[uuid.UUID]models.Service
+		want    []want
+	}{
+		{
+			name: "nothing wrong",
+			list: []models.Subscription{
+				sub(netflix, "Netflix", 599, date(2024, 1, 1), at(date(2024, 12, 31))),
+				sub(netflix, "Netflix", 699, date(2025, 1, 1), nil),
+				sub(spotify, "Spotify", 299, date(2025, 1, 1), nil),
+			},
+		},
+		{
+			name: "same service twice",
+			list: []models.Subscription{
+				sub(netflix, "Netflix", 599, date(2024, 1, 1), nil),
+				sub(netflix, "Netflix", 499, date(2025, 1, 1), nil),
+			},
+			want: []want{{models.AnomalyOverlappingPeriod, 499, []int{0, 1}}},
+		},
+		{
+			name: "same product under different services",
+			list: []models.Subscription{
+				sub(youtube, "YouTube Premium", 299, date(2024, 1, 1), nil),
+				sub(youtubeFamily, "YouTube Family", 499, date(2025, 1, 1), nil),
+			},
+			want: []want{{models.AnomalyDuplicateService, 299, []int{0, 1}}},
+		},
+		{
+			name: "overlap over before today",
+			list: []models.Subscription{
+				sub(netflix, "Netflix", 599, date(2024, 1, 1), at(date(2025, 1, 31))),
+				sub(netflix, "Netflix", 599, date(2025, 1, 1), nil),
+			},
+		},
+		{
+			name: "renewal much pricier",
+			list: []models.Subscription{
+				sub(netflix, "Netflix", 400, date(2024, 1, 1), at(date(2024, 12, 31))),
+				sub(netflix, "Netflix", 999, date(2025, 1, 1), nil),
+			},
+			want: []want{{models.AnomalyPriceJump, 599, []int{0, 1}}},
+		},
+		{
+			name: "pricier renewal already over",
+			list: []models.Subscription{
+				sub(netflix, "Netflix", 400, date(2023, 1, 1), at(date(2023, 12, 31))),
+				sub(netflix, "Netflix", 999, date(2024, 1, 1), at(date(2024, 12, 31))),
+			},
+		},
+		{
+			name: "scheduled changes",
+			list: []models.Subscription{sub(netflix, "Netflix", 400, date(2025, 1, 1), nil)},
+			changes: map[int][]models.PriceChange{0: {
+				{EffectiveDate: date(2025, 4, 1), Price: 500},
+				{EffectiveDate: date(2025, 7, 1), Price: 800},
+			}},
+			want: []want{{models.AnomalyPriceJump, 300, []int{0}}},
+		},
+		{
+			name:    "above the catalog price",
+			list:    []models.Subscription{sub(spotify, "Spotify", 999, date(2025, 1, 1), nil), sub(netflix, "Netflix", 699, date(2025, 1, 1), nil)},
+			catalog: map[uuid.UUID]models.Service{spotify: {DefaultPrice: price(299)}, netflix: {DefaultPrice: price(599)}},
+			want:    []want{{models.AnomalyAboveCatalogPrice, 700, []int{0}}},
+		},
+		{
+			name: "costliest first",
+			list: []models.Subscription{
+				sub(netflix, "Netflix", 599, date(2024, 1, 1), nil),
+				sub(netflix, "Netflix", 199, date(2025, 1, 1), nil),
+				sub(spotify, "Spotify", 999, date(2025, 1, 1), nil),
+			},
+			catalog: map[uuid.UUID]models.Service{spotify: {DefaultPrice: price(299)}},
+			want: []want{
+				{models.AnomalyAboveCatalogPrice, 700, []int{2}},
+				{models.AnomalyOverlappingPeriod, 199, []int{0, 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := make(map[uuid.UUID][]models.PriceChange)
+			for i, list := range tt.changes {
+				changes[tt.list[i].ID] = list
+			}
+
+			got := findAnomalies(tt.list, changes, tt.catalog, today)
+			if len(got) != len(tt.want) {
+				t.Fatalf("findAnomalies() = %+v, want %d anomalies", got, len(tt.want))
+			}
+			for i, anomaly := range got {
+				var ids []uuid.UUID
+				for _, index := range tt.want[i].ids {
+					ids = append(ids, tt.list[index].ID)
+				}
+				if anomaly.Kind != tt.want[i].kind || anomaly.MonthlyExcess != tt.want[i].excess || !reflect.DeepEqual(anomaly.SubscriptionIDs, ids) {
+					t.Errorf("anomaly %d = %s of %d for %v, want %s of %d for %v", i, anomaly.Kind, anomaly.MonthlyExcess,
+						anomaly.SubscriptionIDs, tt.want[i].kind, tt.want[i].excess, ids)
+				}
+				if anomaly.UserID != userID || anomaly.Message == "" {
+					t.Errorf("anomaly %d = user %v, message %q", i, anomaly.UserID, anomaly.Message)
+				}
+			}
+		})
+	}
+}
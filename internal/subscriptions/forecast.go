@@ -0,0 +1,240 @@
+package subscriptions
+
+import (
+	"context"
+	"sort"
+	"time"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/billing"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultForecastMonths is the length of a forecast without months
+const DefaultForecastMonths = 12
+
+// Forecast projects the monthly cost of subscriptions from the start month
+// on. Like the monthly aggregation, a subscription costs its full price in
+// every month it is active on at least one day; the price is the one in
+// effect on its first active day of the month, following scheduled price
+// changes. Subscriptions stop at their end dates, open-ended ones run on.
+// Regular users forecast their own costs.
+func (s *Service) Forecast(ctx context.Context, req models.ForecastRequest) (*models.ForecastResponse, error) {
+	if err := validation.ValidateForecastRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.UserID == nil {
+		req.UserID = s.OwnerScope(ctx)
+	}
+
+	if err := s.Authorize(ctx, authz.ActionAggregate, req.UserID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if req.StartMonth != "" {
+		var err error
+		if start, err = validation.ParseMonthYear(req.StartMonth); err != nil {
+			return nil, &InputError{Message: "Invalid start month format (use MM-YYYY)", Err: err}
+		}
+	}
+
+	months := req.Months
+	if months <= 0 {
+		months = DefaultForecastMonths
+	}
+	end := start.AddDate(0, months, -1)
+
+	db := s.Tenant(ctx)
+
+	filter := models.AggregationRequest{
+		UserID:      req.UserID,
+		ServiceID:   req.ServiceID,
+		ServiceName: req.ServiceName,
+		Category:    req.Category,
+		Tags:        req.Tags,
+	}
+	filters, args := aggregationFilters(db.TenantID(), filter, []interface{}{start, end})
+
+	var rows []records.Subscription
+	query := `SELECT * FROM subscriptions WHERE start_date <= $2 AND (end_date IS NULL OR end_date >= $1)` + filters
+	if err := db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	subscriptionIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		subscriptionIDs = append(subscriptionIDs, row.ID)
+	}
+
+	changes, err := PriceChanges(db, db.TenantID(), subscriptionIDs)
+	if err != nil {
+		return nil, err
+	}
+	history, err := PriceHistory(db, db.TenantID(), subscriptionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load sharing rules of split subscriptions in bulk
+	var shares map[uuid.UUID][]models.SubscriptionShare
+	var members map[uuid.UUID][]uuid.UUID
+	if req.UserID != nil {
+		splitIDs := []uuid.UUID{}
+		householdIDs := []uuid.UUID{}
+		for _, row := range rows {
+			if row.SplitType != models.SplitNone {
+				splitIDs = append(splitIDs, row.ID)
+			}
+			if row.HouseholdID != nil {
+				householdIDs = append(householdIDs, *row.HouseholdID)
+			}
+		}
+
+		if shares, err = Shares(db, db.TenantID(), splitIDs); err != nil {
+			return nil, err
+		}
+		if members, err = HouseholdMembers(db, db.TenantID(), householdIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	response := models.ForecastResponse{
+		StartMonth: start.Format("01-2006"),
+		EndMonth:   end.Format("01-2006"),
+		UserID:     req.UserID,
+	}
+
+	var total float64
+	response.Months, total = forecastMonths(rows, history, changes, start, months, req.UserID, shares, members)
+	response.TotalCost = billing.RoundCost(total)
+
+	s.logger.WithFields(logrus.Fields{
+		"total_cost":  response.TotalCost,
+		"start_month": response.StartMonth,
+		"months":      months,
+	}).Info("Subscription forecast completed")
+
+	return &response, nil
+}
+
+// forecastMonths charges the rows for each of the months from start on and
+// returns the months and the unrounded total. Months before applied price
+// changes take the past prices. Shares and household members are only
+// needed with userID.
+func forecastMonths(rows []records.Subscription, history map[uuid.UUID][]models.PricePeriod, changes map[uuid.UUID][]models.PriceChange, start time.Time, months int,
+	userID *uuid.UUID, shares map[uuid.UUID][]models.SubscriptionShare, members map[uuid.UUID][]uuid.UUID) ([]models.ForecastMonth, float64) {
+	forecast := make([]models.ForecastMonth, 0, months)
+	var total float64
+	for i := 0; i < months; i++ {
+		monthStart := start.AddDate(0, i, 0)
+		monthEnd := monthStart.AddDate(0, 1, -1)
+
+		var monthTotal float64
+		costs := make(map[uuid.UUID]float64)
+		names := make(map[uuid.UUID]string)
+		for _, row := range rows {
+			if row.StartDate.After(monthEnd) || (row.EndDate != nil && row.EndDate.Before(monthStart)) {
+				continue
+			}
+
+			firstDay := monthStart
+			if row.StartDate.After(firstDay) {
+				firstDay = row.StartDate
+			}
+			price := billing.PriceOn(billing.PastPriceOn(row.Price, history[row.ID], firstDay), changes[row.ID], firstDay)
+
+			cost := float64(price)
+			if userID != nil {
+				var participants []uuid.UUID
+				if row.HouseholdID != nil {
+					participants = members[*row.HouseholdID]
+				}
+				cost *= billing.ShareFraction(price, row.UserID, row.SplitType, shares[row.ID], participants, *userID)
+			}
+
+			monthTotal += cost
+			costs[row.ServiceID] += cost
+			names[row.ServiceID] = row.ServiceName
+		}
+
+		month := models.ForecastMonth{
+			Month:     monthStart.Format("01-2006"),
+			TotalCost: billing.RoundCost(monthTotal),
+			Services:  []models.ServiceCost{},
+		}
+		for serviceID, cost := range costs {
+			month.Services = append(month.Services, models.ServiceCost{
+				ServiceID:   serviceID,
+				ServiceName: names[serviceID],
+				Cost:        billing.RoundCost(cost),
+			})
+		}
+		sort.Slice(month.Services, func(i, j int) bool {
+			return month.Services[i].ServiceName < month.Services[j].ServiceName
+		})
+
+		forecast = append(forecast, month)
+		total += monthTotal
+	}
+
+	return forecast, total
+}
+
+// PriceChanges loads scheduled price changes of the given subscriptions in
+// one query, ordered by effective date
+func PriceChanges(db database.Querier, tenantID uuid.UUID, subscriptionIDs []uuid.UUID) (map[uuid.UUID][]models.PriceChange, error) {
+	changes := make(map[uuid.UUID][]models.PriceChange)
+	if len(subscriptionIDs) == 0 {
+		return changes, nil
+	}
+
+	var rows []records.PriceChange
+	query := `
+		SELECT * FROM subscription_price_changes
+		WHERE tenant_id = $1 AND subscription_id = ANY($2)
+		ORDER BY effective_date`
+
+	if err := db.Select(&rows, query, tenantID, pq.Array(subscriptionIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		changes[row.SubscriptionID] = append(changes[row.SubscriptionID], row.Model())
+	}
+
+	return changes, nil
+}
+
+// PriceHistory loads the past prices of the given subscriptions in one
+// query, ordered by the end of their validity
+func PriceHistory(db database.Querier, tenantID uuid.UUID, subscriptionIDs []uuid.UUID) (map[uuid.UUID][]models.PricePeriod, error) {
+	history := make(map[uuid.UUID][]models.PricePeriod)
+	if len(subscriptionIDs) == 0 {
+		return history, nil
+	}
+
+	var rows []records.PricePeriod
+	query := `
+		SELECT * FROM subscription_prices
+		WHERE tenant_id = $1 AND subscription_id = ANY($2)
+		ORDER BY valid_until`
+
+	if err := db.Select(&rows, query, tenantID, pq.Array(subscriptionIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		history[row.SubscriptionID] = append(history[row.SubscriptionID], row.Model())
+	}
+
+	return history, nil
+}
@@ -0,0 +1,115 @@
+package subscriptions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestForecastMonths(t *testing.T) {
+	netflix, spotify, okko := uuid.New(), uuid.New(), uuid.New()
+	owner, member := uuid.New(), uuid.New()
+	household := uuid.New()
+	end := date(2025, 2, 14)
+
+	subscription := func(serviceID uuid.UUID, name string, price int, start time.Time, end *time.Time) records.Subscription {
+		return records.Subscription{ID: uuid.New(), UserID: owner, ServiceID: serviceID, ServiceName: name, Price: price,
+			StartDate: start, EndDate: end, SplitType: models.SplitNone}
+	}
+	raised := subscription(netflix, "Netflix", 599, date(2024, 6, 10), nil)
+	ending := subscription(spotify, "Spotify", 299, date(2024, 1, 1), &end)
+	later := subscription(spotify, "Spotify", 199, date(2025, 3, 20), nil)
+	shared := subscription(netflix, "Netflix", 900, date(2024, 1, 1), nil)
+	shared.SplitType, shared.HouseholdID = models.SplitEqual, &household
+	repriced := subscription(okko, "Okko", 499, date(2024, 1, 1), nil)
+
+	// Applied mid-February, so February keeps the old price
+	history := map[uuid.UUID][]models.PricePeriod{
+		repriced.ID: {{SubscriptionID: repriced.ID, ValidUntil: date(2025, 2, 10), Price: 399}},
+	}
+
+	changes := map[uuid.UUID][]models.PriceChange{
+		// Takes effect mid-February, so March is the first month at the new price
+		raised.ID: {{SubscriptionID: raised.ID, EffectiveDate: date(2025, 2, 15), Price: 699}},
+		// Takes effect on the first active day of the subscription
+		later.ID: {{SubscriptionID: later.ID, EffectiveDate: date(2025, 3, 20), Price: 249}},
+	}
+
+	costs := func(month string, total int64, services ...models.ServiceCost) models.ForecastMonth {
+		if services == nil {
+			services = []models.ServiceCost{}
+		}
+		return models.ForecastMonth{Month: month, TotalCost: total, Services: services}
+	}
+
+	tests := []struct {
+		name      string
+		rows      []records.Subscription
+		userID    *uuid.UUID
+		want      []models.ForecastMonth
+		wantTotal float64
+	}{
+		{
+			name: "price changes, ends and starts",
+			rows: []records.Subscription{raised, ending, later},
+			want: []models.ForecastMonth{
+				costs("01-2025", 898, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 599}, models.ServiceCost{ServiceID: spotify, ServiceName: "Spotify", Cost: 299}),
+				costs("02-2025", 898, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 599}, models.ServiceCost{ServiceID: spotify, ServiceName: "Spotify", Cost: 299}),
+				costs("03-2025", 948, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 699}, models.ServiceCost{ServiceID: spotify, ServiceName: "Spotify", Cost: 249}),
+			},
+			wantTotal: 2744,
+		},
+		{
+			name: "subscriptions of a service add up",
+			rows: []records.Subscription{raised, shared},
+			want: []models.ForecastMonth{
+				costs("01-2025", 1499, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 1499}),
+				costs("02-2025", 1499, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 1499}),
+				costs("03-2025", 1599, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 1599}),
+			},
+			wantTotal: 4597,
+		},
+		{
+			name:   "share of a household member",
+			rows:   []records.Subscription{raised, shared},
+			userID: &member,
+			want: []models.ForecastMonth{
+				costs("01-2025", 300, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 300}),
+				costs("02-2025", 300, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 300}),
+				costs("03-2025", 300, models.ServiceCost{ServiceID: netflix, ServiceName: "Netflix", Cost: 300}),
+			},
+			wantTotal: 900,
+		},
+		{
+			name: "applied price change",
+			rows: []records.Subscription{repriced},
+			want: []models.ForecastMonth{
+				costs("01-2025", 399, models.ServiceCost{ServiceID: okko, ServiceName: "Okko", Cost: 399}),
+				costs("02-2025", 399, models.ServiceCost{ServiceID: okko, ServiceName: "Okko", Cost: 399}),
+				costs("03-2025", 499, models.ServiceCost{ServiceID: okko, ServiceName: "Okko", Cost: 499}),
+			},
+			wantTotal: 1297,
+		},
+		{
+			name: "nothing active",
+			want: []models.ForecastMonth{costs("01-2025", 0), costs("02-2025", 0), costs("03-2025", 0)},
+		},
+	}
+
+	members := map[uuid.UUID][]uuid.UUID{household: {owner, member, uuid.New()}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			months, total := forecastMonths(tt.rows, history, changes, date(2025, 1, 1), 3, tt.userID, nil, members)
+			if !reflect.DeepEqual(months, tt.want) {
+				t.Fatalf("forecastMonths() = %+v, want %+v", months, tt.want)
+			}
+			if total != tt.wantTotal {
+				t.Fatalf("forecastMonths() total = %v, want %v", total, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"subscription-aggregator/internal/auth"
+	"subscription-aggregator/internal/authz"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/internal/tenant"
+	"subscription-aggregator/internal/validation"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Page sizes of List
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// streamPageSize is the number of rows Stream loads per query
+const streamPageSize = 500
+
+// Service implements the subscription queries shared by the HTTP and gRPC
+// APIs. The tenant and the principal are taken from the context.
+//
+// Errors are *authz.DeniedError when the policy rejects the call,
+// validation.ValidationErrors or *InputError for malformed input and
+// sql.ErrNoRows for unknown subscriptions.
+type Service struct {
+	db     *database.DB
+	policy *authz.Policy
+	logger *logrus.Logger
+}
+
+func NewService(db *database.DB, policy *authz.Policy, logger *logrus.Logger) *Service {
+	return &Service{
+		db:     db,
+		policy: policy,
+		logger: logger,
+	}
+}
+
+// InputError is a malformed value of a request
+type InputError struct {
+	Message string
+	Err     error
+}
+
+func (e *InputError) Error() string {
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// Tenant returns the database handle bound to the tenant of the context.
+// Calls without a resolved tenant get the nil tenant, which owns no rows.
+func (s *Service) Tenant(ctx context.Context) *database.TenantDB {
+	tenantID, _ := tenant.FromContext(ctx)
+	return s.db.Tenant(tenantID)
+}
+
+// Authorize consults the policy for the principal of the context
+func (s *Service) Authorize(ctx context.Context, action authz.Action, owner *uuid.UUID) error {
+	principal, _ := auth.FromContext(ctx)
+	return s.denied(principal, action, s.policy.Authorize(principal, action, owner))
+}
+
+// AuthorizeAdmin consults the policy for an action only admins may take
+func (s *Service) AuthorizeAdmin(ctx context.Context, action authz.Action) error {
+	principal, _ := auth.FromContext(ctx)
+	return s.denied(principal, action, s.policy.AuthorizeAdmin(principal, action))
+}
+
+// AuthorizeUser consults the policy for an action on the user with the ID
+func (s *Service) AuthorizeUser(ctx context.Context, action authz.Action, userID *uuid.UUID) error {
+	principal, _ := auth.FromContext(ctx)
+	return s.denied(principal, action, s.policy.AuthorizeUser(principal, action, userID))
+}
+
+// AuthorizeHousehold consults the policy for an action on a household
+// with the members
+func (s *Service) AuthorizeHousehold(ctx context.Context, action authz.Action, members []uuid.UUID) error {
+	principal, _ := auth.FromContext(ctx)
+	return s.denied(principal, action, s.policy.AuthorizeHousehold(principal, action, members))
+}
+
+// denied logs a decision of the policy rejecting the principal
+func (s *Service) denied(principal *auth.Principal, action authz.Action, err error) error {
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"subject": principal.Subject,
+			"action":  action,
+		}).Warn("Authorization denied")
+	}
+	return err
+}
+
+// OwnerScope returns the user the principal of the context is limited to
+func (s *Service) OwnerScope(ctx context.Context) *uuid.UUID {
+	principal, _ := auth.FromContext(ctx)
+	return s.policy.OwnerScope(principal)
+}
+
+// Get returns the subscription if the principal may read it
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	db := s.Tenant(ctx)
+
+	var row records.Subscription
+	query := `SELECT * FROM subscriptions WHERE tenant_id = $1 AND id = $2`
+	if err := db.Get(&row, query, db.TenantID(), id); err != nil {
+		return nil, err
+	}
+	subscription := row.Model()
+
+	if err := s.Authorize(ctx, authz.ActionRead, &subscription.UserID); err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// List returns subscriptions matching the filter, newest first. Limits
+// outside 1..MaxLimit fall back to DefaultLimit. Regular users only list
+// their own subscriptions.
+func (s *Service) List(ctx context.Context, filter models.SubscriptionFilter, limit, offset int) ([]models.Subscription, error) {
+	if err := s.Authorize(ctx, authz.ActionList, nil); err != nil {
+		return nil, err
+	}
+
+	ownerScope := s.OwnerScope(ctx)
+	if filter.UserID == nil {
+		filter.UserID = ownerScope
+	} else if ownerScope != nil {
+		if err := s.Authorize(ctx, authz.ActionRead, filter.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if limit <= 0 || limit > MaxLimit {
+		limit = DefaultLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.list(s.Tenant(ctx), filter, limit, offset)
+}
+
+// Stream passes every subscription matching the filter to fn, newest
+// first, loading them page by page. It stops at the first error of fn or
+// when the context is done.
+func (s *Service) Stream(ctx context.Context, filter models.SubscriptionFilter, fn func(models.Subscription) error) error {
+	for offset := 0; ; offset += streamPageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, err := s.List(ctx, filter, streamPageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, subscription := range page {
+			if err := fn(subscription); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < streamPageSize {
+			return nil
+		}
+	}
+}
+
+// ByUsers returns the subscriptions of each user, newest first, loading
+// them in one query. Regular users only get their own subscriptions.
+func (s *Service) ByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.Subscription, error) {
+	return s.byColumn(ctx, "user_id", userIDs, func(subscription models.Subscription) uuid.UUID {
+		return subscription.UserID
+	})
+}
+
+// ByServices returns the subscriptions of each catalog service like ByUsers
+func (s *Service) ByServices(ctx context.Context, serviceIDs []uuid.UUID) (map[uuid.UUID][]models.Subscription, error) {
+	return s.byColumn(ctx, "service_id", serviceIDs, func(subscription models.Subscription) uuid.UUID {
+		return subscription.ServiceID
+	})
+}
+
+func (s *Service) byColumn(ctx context.Context, column string, ids []uuid.UUID, key func(models.Subscription) uuid.UUID) (map[uuid.UUID][]models.Subscription, error) {
+	if err := s.Authorize(ctx, authz.ActionList, nil); err != nil {
+		return nil, err
+	}
+
+	db := s.Tenant(ctx)
+	query := "SELECT * FROM subscriptions WHERE tenant_id = $1 AND " + column + " = ANY($2)"
+	args := []interface{}{db.TenantID(), pq.Array(ids)}
+
+	if owner := s.OwnerScope(ctx); owner != nil {
+		query += " AND user_id = $3"
+		args = append(args, *owner)
+	}
+	query += " ORDER BY created_at DESC, id"
+
+	var rows []records.Subscription
+	if err := db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	result := make(map[uuid.UUID][]models.Subscription)
+	for _, row := range rows {
+		subscription := row.Model()
+		result[key(subscription)] = append(result[key(subscription)], subscription)
+	}
+
+	return result, nil
+}
+
+func (s *Service) list(db *database.TenantDB, filter models.SubscriptionFilter, limit, offset int) ([]models.Subscription, error) {
+	query := "SELECT * FROM subscriptions"
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{db.TenantID()}
+	argCount := 1
+
+	if filter.UserID != nil {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argCount))
+		args = append(args, *filter.UserID)
+	}
+
+	if filter.ServiceID != nil {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf("service_id = $%d", argCount))
+		args = append(args, *filter.ServiceID)
+	}
+
+	if filter.ServiceName != nil {
+		argCount++
+		conditions = append(conditions, serviceNameCondition(1, argCount))
+		args = append(args, validation.NormalizeServiceName(*filter.ServiceName))
+	}
+
+	if filter.Category != nil {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf("category = $%d", argCount))
+		args = append(args, *filter.Category)
+	}
+
+	if len(filter.Tags) > 0 {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf("tags @> $%d", argCount))
+		args = append(args, pq.StringArray(validation.NormalizeTags(filter.Tags)))
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += " ORDER BY created_at DESC, id"
+	query += fmt.Sprintf(" LIMIT %d", limit)
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", offset)
+	}
+
+	var rows []records.Subscription
+	if err := db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	return records.Models[models.Subscription](rows), nil
+}
+
+// serviceNameCondition matches subscriptions whose catalog service of the
+// tenant has the name or alias bound to the given placeholder
+func serviceNameCondition(tenantArg, arg int) string {
+	return fmt.Sprintf(`service_id IN (
+		SELECT id FROM services
+		WHERE tenant_id = $%[1]d
+		  AND (lower(name) = lower($%[2]d)
+		       OR EXISTS (SELECT 1 FROM unnest(aliases) a WHERE lower(a) = lower($%[2]d))))`, tenantArg, arg)
+}
@@ -0,0 +1,62 @@
+package subscriptions
+
+import (
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/records"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Shares loads cost shares of the given subscriptions in one query
+func Shares(db database.Querier, tenantID uuid.UUID, subscriptionIDs []uuid.UUID) (map[uuid.UUID][]models.SubscriptionShare, error) {
+	shares := make(map[uuid.UUID][]models.SubscriptionShare)
+	if len(subscriptionIDs) == 0 {
+		return shares, nil
+	}
+
+	var rows []records.SubscriptionShare
+	query := `
+		SELECT subscription_id, user_id, percent, amount FROM subscription_shares
+		WHERE tenant_id = $1 AND subscription_id = ANY($2)
+		ORDER BY user_id`
+
+	if err := db.Select(&rows, query, tenantID, pq.Array(subscriptionIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		shares[row.SubscriptionID] = append(shares[row.SubscriptionID], row.Model())
+	}
+
+	return shares, nil
+}
+
+// HouseholdMembers loads member IDs of the given households in one query
+func HouseholdMembers(db database.Querier, tenantID uuid.UUID, householdIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	members := make(map[uuid.UUID][]uuid.UUID)
+	if len(householdIDs) == 0 {
+		return members, nil
+	}
+
+	var rows []struct {
+		HouseholdID uuid.UUID `db:"household_id"`
+		UserID      uuid.UUID `db:"user_id"`
+	}
+
+	query := `
+		SELECT household_id, user_id FROM household_members
+		WHERE tenant_id = $1 AND household_id = ANY($2)
+		ORDER BY created_at`
+
+	if err := db.Select(&rows, query, tenantID, pq.Array(householdIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		members[row.HouseholdID] = append(members[row.HouseholdID], row.UserID)
+	}
+
+	return members, nil
+}
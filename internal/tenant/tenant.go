@@ -0,0 +1,68 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidID     = errors.New("invalid tenant ID")
+	ErrRequired      = errors.New("tenant ID is required")
+	ErrForeignTenant = errors.New("credentials do not belong to this tenant")
+
+	ErrUnboundCredentials = errors.New("credentials are not bound to a tenant")
+)
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the tenant ID
+func WithID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ID stored in ctx
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(contextKey{}).(uuid.UUID)
+	return id, ok
+}
+
+// Resolve returns the tenant of a request. Authenticated requests belong
+// to the tenant of their credentials, or to the fallback when those are not
+// bound to one and a tenant is not required; a requested tenant naming
+// another one is rejected, so the request can never widen the scope of the
+// credentials. Anonymous requests use the requested tenant, and without one
+// the fallback unless a tenant is required.
+func Resolve(authenticated bool, credentialTenant uuid.UUID, requested string, required bool, fallback uuid.UUID) (uuid.UUID, error) {
+	if authenticated {
+		bound := credentialTenant
+		if bound == uuid.Nil {
+			if required || fallback == uuid.Nil {
+				return uuid.Nil, ErrUnboundCredentials
+			}
+			bound = fallback
+		}
+
+		if requested != "" {
+			parsed, err := uuid.Parse(requested)
+			if err != nil || parsed != bound {
+				return uuid.Nil, ErrForeignTenant
+			}
+		}
+		return bound, nil
+	}
+
+	if requested != "" {
+		parsed, err := uuid.Parse(requested)
+		if err != nil {
+			return uuid.Nil, ErrInvalidID
+		}
+		return parsed, nil
+	}
+
+	if required || fallback == uuid.Nil {
+		return uuid.Nil, ErrRequired
+	}
+	return fallback, nil
+}
@@ -0,0 +1,53 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestResolve(t *testing.T) {
+	bound := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	other := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	fallback := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+	tests := []struct {
+		name             string
+		authenticated    bool
+		credentialTenant uuid.UUID
+		requested        string
+		required         bool
+		fallback         uuid.UUID
+		want             uuid.UUID
+		wantErr          error
+	}{
+		{name: "credentials bound", authenticated: true, credentialTenant: bound, required: true, want: bound},
+		{name: "credentials bound and same header", authenticated: true, credentialTenant: bound, requested: bound.String(), required: true, want: bound},
+		{name: "credentials bound and braced header", authenticated: true, credentialTenant: bound, requested: "{" + bound.String() + "}", want: bound},
+		{name: "credentials bound and foreign header", authenticated: true, credentialTenant: bound, requested: other.String(), wantErr: ErrForeignTenant},
+		{name: "credentials bound and invalid header", authenticated: true, credentialTenant: bound, requested: "nope", wantErr: ErrForeignTenant},
+		{name: "unbound credentials and required tenant", authenticated: true, requested: other.String(), required: true, fallback: fallback, wantErr: ErrUnboundCredentials},
+		{name: "unbound credentials without fallback", authenticated: true, wantErr: ErrUnboundCredentials},
+		{name: "unbound credentials fall back", authenticated: true, fallback: fallback, want: fallback},
+		{name: "unbound credentials and fallback header", authenticated: true, requested: fallback.String(), fallback: fallback, want: fallback},
+		{name: "unbound credentials and foreign header", authenticated: true, requested: other.String(), fallback: fallback, wantErr: ErrForeignTenant},
+		{name: "anonymous header", requested: other.String(), required: true, want: other},
+		{name: "anonymous invalid header", requested: "nope", wantErr: ErrInvalidID},
+		{name: "anonymous without header and required tenant", required: true, fallback: fallback, wantErr: ErrRequired},
+		{name: "anonymous without header falls back", fallback: fallback, want: fallback},
+		{name: "anonymous without header or fallback", wantErr: ErrRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.authenticated, tt.credentialTenant, tt.requested, tt.required, tt.fallback)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
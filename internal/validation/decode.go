@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrBodyTooLarge is returned when the body exceeds the limit set with
+// http.MaxBytesReader
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// DecodeJSON strictly decodes a JSON object into dst. Unknown fields,
+// duplicate keys, trailing data and values of the wrong type are rejected.
+// Field problems are returned as ValidationErrors, anything else as a plain
+// error.
+func DecodeJSON(body io.Reader, dst interface{}) error {
+	data, err := ReadBody(body)
+	if err != nil {
+		return err
+	}
+
+	// Unmarshal also rejects trailing data after the object
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("invalid JSON object: %w", err)
+	}
+
+	if err := checkDuplicateKeys(json.NewDecoder(bytes.NewReader(data)), ""); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+
+	return nil
+}
+
+// ParseJSON decodes a single JSON value with numbers kept as json.Number,
+// rejecting duplicate keys and trailing data
+func ParseJSON(data []byte) (interface{}, error) {
+	if err := checkDuplicateKeys(json.NewDecoder(bytes.NewReader(data)), ""); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: trailing data after the value")
+	}
+
+	return value, nil
+}
+
+// ReadBody reads a request body, returning ErrBodyTooLarge when it exceeds
+// the limit set with http.MaxBytesReader
+func ReadBody(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrBodyTooLarge
+		}
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return data, nil
+}
+
+// decodeError turns field-level decoding errors into ValidationErrors
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return ValidationErrors{{Field: typeErr.Field, Message: "неверный тип значения"}}
+	}
+
+	// encoding/json has no typed error for unknown fields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return ValidationErrors{{Field: strings.Trim(field, `"`), Message: "поле не разрешено"}}
+	}
+
+	return err
+}
+
+// checkDuplicateKeys walks the next JSON value and rejects objects that
+// repeat a key, which encoding/json silently resolves to the last value
+func checkDuplicateKeys(decoder *json.Decoder, path string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+
+			key, _ := keyToken.(string)
+			if seen[key] {
+				return ValidationErrors{{Field: path + key, Message: "поле указано несколько раз"}}
+			}
+			seen[key] = true
+
+			if err := checkDuplicateKeys(decoder, path+key+"."); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+		return err
+
+	case json.Delim('['):
+		for decoder.More() {
+			if err := checkDuplicateKeys(decoder, path); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type decodeTarget struct {
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+	Meta  struct {
+		Note string `json:"note"`
+	} `json:"meta"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField string // Field of the ValidationErrors, empty for plain errors
+		wantErr   bool
+	}{
+		{name: "valid", body: `{"name": "Netflix", "price": 400, "meta": {"note": "x"}}`},
+		{name: "unknown field", body: `{"name": "Netflix", "cost": 400}`, wantErr: true, wantField: "cost"},
+		{name: "wrong type", body: `{"price": "400"}`, wantErr: true, wantField: "price"},
+		{name: "duplicate key", body: `{"price": 1, "price": 400}`, wantErr: true, wantField: "price"},
+		{name: "nested duplicate key", body: `{"meta": {"note": "a", "note": "b"}}`, wantErr: true, wantField: "meta.note"},
+		{name: "trailing data", body: `{"price": 400} {"price": 1}`, wantErr: true},
+		{name: "not an object", body: `[1, 2]`, wantErr: true},
+		{name: "malformed", body: `{"price": 400`, wantErr: true},
+		{name: "empty", body: ``, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst decodeTarget
+			err := DecodeJSON(strings.NewReader(tt.body), &dst)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("DecodeJSON() error = %v", err)
+				}
+				if dst.Name != "Netflix" || dst.Price != 400 || dst.Meta.Note != "x" {
+					t.Errorf("DecodeJSON() decoded %+v", dst)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("DecodeJSON() error = nil, want an error")
+			}
+			var validationErrs ValidationErrors
+			isValidation := errors.As(err, &validationErrs)
+			if tt.wantField == "" {
+				if isValidation {
+					t.Errorf("DecodeJSON() error = %v, want a plain error", err)
+				}
+				return
+			}
+			if !isValidation || validationErrs[0].Field != tt.wantField {
+				t.Errorf("DecodeJSON() error = %v, want a validation error of %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONBodyTooLarge(t *testing.T) {
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`{"name": "`+strings.Repeat("x", 64)+`"}`)), 16)
+
+	var dst decodeTarget
+	if err := DecodeJSON(body, &dst); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("DecodeJSON() error = %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	value, err := ParseJSON([]byte(`{"price": 400, "tags": ["a"]}`))
+	if err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok || object["price"] != json.Number("400") {
+		t.Errorf("ParseJSON() = %#v, want numbers as json.Number", value)
+	}
+
+	for _, body := range []string{`{"a": 1, "a": 2}`, `1 2`, `{`} {
+		if _, err := ParseJSON([]byte(body)); err == nil {
+			t.Errorf("ParseJSON(%s) error = nil, want an error", body)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Named value lists usable as enum=<name> in validate tags
+var enums = map[string]func() []string{
+	"categories":     GetAllowedCategories,
+	"group_by":       GetAllowedGroupBy,
+	"split_types":    GetAllowedSplitTypes,
+	"scopes":         GetAllowedScopes,
+	"roles":          GetAllowedRoles,
+	"events":         GetAllowedEventTypes,
+	"budget_periods": GetAllowedBudgetPeriods,
+}
+
+// Enum returns the values of the named list usable as enum=<name>
+func Enum(name string) ([]string, bool) {
+	list, ok := enums[name]
+	if !ok {
+		return nil, false
+	}
+	return list(), true
+}
+
+// ValidateStruct checks a request struct against its validate tags and
+// returns nil when every field passes. Fields are reported by their JSON
+// names; nested structs and slices of structs are checked recursively.
+//
+// Supported rules, separated by commas:
+//
+//	required       strings must not be blank, pointers non-nil, slices non-empty
+//	               (plain numbers cannot tell zero from absent and are not checked)
+//	min=N, max=N   bounds of numbers, rune length of strings, length of slices
+//	len=N          exact length of strings and slices
+//	uuid           UUID string
+//	date           YYYY-MM-DD or MM-YYYY
+//	monthyear      MM-YYYY
+//	email          e-mail address
+//	url            absolute http or https URL
+//	enum=X         one of the values of the named list X, or of "a|b|c"
+//	gtefield=F     not before field F: dates compare the end of this
+//	               period with the start of F, numbers compare by value
+//	nullable       the literal string "null" skips the remaining rules
+//	dive           the remaining rules apply to each slice element
+//
+// Apart from required, rules skip empty strings, nil pointers and nil slices.
+func ValidateStruct(v interface{}) ValidationErrors {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	return validateStruct(value, "")
+}
+
+func validateStruct(value reflect.Value, prefix string) ValidationErrors {
+	var errors ValidationErrors
+	structType := value.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		rules := splitRules(field.Tag.Get("validate"))
+
+		if err := validateValue(value, fieldValue, prefix+name, rules); err != nil {
+			errors = append(errors, *err)
+			continue
+		}
+
+		errors = append(errors, validateNested(fieldValue, prefix+name)...)
+	}
+
+	return errors
+}
+
+// validateNested descends into struct fields and slices of structs
+func validateNested(value reflect.Value, path string) ValidationErrors {
+	value = reflect.Indirect(value)
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type().PkgPath() == "time" || value.Type() == reflect.TypeOf(uuid.UUID{}) {
+			return nil
+		}
+		return validateStruct(value, path+".")
+
+	case reflect.Slice:
+		var errors ValidationErrors
+		for i := 0; i < value.Len(); i++ {
+			element := reflect.Indirect(value.Index(i))
+			if element.Kind() == reflect.Struct {
+				errors = append(errors, validateStruct(element, fmt.Sprintf("%s[%d].", path, i))...)
+			}
+		}
+		return errors
+	}
+
+	return nil
+}
+
+// validateValue applies rules to a single value and returns the first failure
+func validateValue(parent, value reflect.Value, path string, rules []string) *ValidationError {
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+
+		if name == "dive" {
+			value = reflect.Indirect(value)
+			for j := 0; j < value.Len(); j++ {
+				if err := validateValue(parent, value.Index(j), fmt.Sprintf("%s[%d]", path, j), rules[i+1:]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if name == "required" {
+			if isEmpty(value, true) {
+				return &ValidationError{Field: path, Message: fmt.Sprintf("поле %s обязательно", path)}
+			}
+			continue
+		}
+
+		if isEmpty(value, false) {
+			return nil
+		}
+
+		if name == "nullable" {
+			if s, ok := stringValue(value); ok && s == "null" {
+				return nil
+			}
+			continue
+		}
+
+		if message := checkRule(parent, reflect.Indirect(value), path, name, param); message != "" {
+			return &ValidationError{Field: path, Message: message}
+		}
+	}
+
+	return nil
+}
+
+// checkRule returns the failure message of the rule, or "" when it passes
+func checkRule(parent, value reflect.Value, path, name, param string) string {
+	switch name {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validation: invalid %s parameter %q on %s", name, param, path))
+		}
+		return checkBound(value, path, name, limit, param)
+
+	case "uuid":
+		if s, _ := stringValue(value); !isUUID(s) {
+			return fmt.Sprintf("поле %s должно быть в формате UUID", path)
+		}
+
+	case "date":
+		if s, _ := stringValue(value); s != "" {
+			if _, err := ParseDate(s); err != nil {
+				return fmt.Sprintf("поле %s должно быть в формате MM-YYYY или YYYY-MM-DD", path)
+			}
+		}
+
+	case "monthyear":
+		if s, _ := stringValue(value); s != "" {
+			if _, err := ParseMonthYear(s); err != nil {
+				return fmt.Sprintf("поле %s должно быть в формате MM-YYYY", path)
+			}
+		}
+
+	case "email":
+		s, _ := stringValue(value)
+		if at := strings.Index(s, "@"); at <= 0 || at == len(s)-1 {
+			return fmt.Sprintf("поле %s должно быть адресом электронной почты", path)
+		}
+
+	case "url":
+		s, _ := stringValue(value)
+		if parsed, err := url.Parse(s); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Sprintf("поле %s должно быть адресом http или https", path)
+		}
+
+	case "enum":
+		allowed := strings.Split(param, "|")
+		if list, ok := enums[param]; ok {
+			allowed = list()
+		}
+		if s, _ := stringValue(value); !contains(allowed, s) {
+			return fmt.Sprintf("поле %s должно быть одним из: %s", path, strings.Join(allowed, ", "))
+		}
+
+	case "gtefield":
+		return checkGteField(parent, value, path, param)
+
+	default:
+		panic(fmt.Sprintf("validation: unknown rule %q on %s", name, path))
+	}
+
+	return ""
+}
+
+func checkBound(value reflect.Value, path, name string, limit float64, param string) string {
+	var actual float64
+	isLength := true
+
+	switch value.Kind() {
+	case reflect.String:
+		actual = float64(utf8.RuneCountInString(value.String()))
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual = float64(value.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual, isLength = float64(value.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual, isLength = float64(value.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		actual, isLength = value.Float(), false
+	default:
+		return ""
+	}
+
+	subject := "поле " + path + " должно"
+	if isLength {
+		subject = "длина поля " + path + " должна"
+	}
+
+	switch {
+	case name == "min" && actual < limit:
+		return fmt.Sprintf("%s быть не меньше %s", subject, param)
+	case name == "max" && actual > limit:
+		return fmt.Sprintf("%s быть не больше %s", subject, param)
+	case name == "len" && isLength && actual != limit:
+		return fmt.Sprintf("%s быть равной %s", subject, param)
+	}
+	return ""
+}
+
+func checkGteField(parent, value reflect.Value, path, param string) string {
+	field, ok := parent.Type().FieldByName(param)
+	if !ok {
+		panic(fmt.Sprintf("validation: unknown field %q in gtefield on %s", param, path))
+	}
+
+	other := parent.FieldByIndex(field.Index)
+	if isEmpty(other, false) {
+		return ""
+	}
+	other = reflect.Indirect(other)
+
+	message := fmt.Sprintf("поле %s не может быть меньше поля %s", path, jsonName(field))
+
+	if s, ok := stringValue(value); ok {
+		o, _ := stringValue(other)
+		end, err := ParseEndDate(s)
+		if err != nil {
+			return ""
+		}
+		start, err := ParseDate(o)
+		if err != nil {
+			return ""
+		}
+		if end.Before(start) {
+			return fmt.Sprintf("поле %s не может быть раньше поля %s", path, jsonName(field))
+		}
+		return ""
+	}
+
+	if value.CanInt() && other.CanInt() && value.Int() < other.Int() {
+		return message
+	}
+	if value.CanFloat() && other.CanFloat() && value.Float() < other.Float() {
+		return message
+	}
+	return ""
+}
+
+// isEmpty reports whether the value is absent. Blank strings count as empty
+// only for required.
+func isEmpty(value reflect.Value, blank bool) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return true
+		}
+		return blank && isEmpty(value.Elem(), blank)
+	case reflect.Slice, reflect.Map:
+		if blank {
+			return value.Len() == 0
+		}
+		return value.IsNil()
+	case reflect.String:
+		if blank {
+			return strings.TrimSpace(value.String()) == ""
+		}
+		return value.String() == ""
+	}
+	return false
+}
+
+func stringValue(value reflect.Value) (string, bool) {
+	value = reflect.Indirect(value)
+	if value.Kind() != reflect.String {
+		return "", false
+	}
+	return value.String(), true
+}
+
+func splitRules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+type engineItem struct {
+	Name string `json:"name" validate:"required,max=5"`
+}
+
+type engineRequest struct {
+	Name      string       `json:"name" validate:"required,max=10"`
+	Price     int          `json:"price" validate:"min=0,max=1000"`
+	MinPrice  *int         `json:"min_price" validate:"min=1"`
+	UserID    string       `json:"user_id" validate:"uuid"`
+	StartDate string       `json:"start_date" validate:"required,date"`
+	EndDate   *string      `json:"end_date" validate:"nullable,date,gtefield=StartDate"`
+	Month     string       `json:"month" validate:"monthyear"`
+	Email     string       `json:"email" validate:"email"`
+	URL       string       `json:"url" validate:"url"`
+	Category  string       `json:"category" validate:"enum=categories"`
+	Mode      string       `json:"mode" validate:"enum=fast|slow"`
+	Code      string       `json:"code" validate:"len=3"`
+	Tags      []string     `json:"tags" validate:"max=2,dive,required,max=4"`
+	Items     []engineItem `json:"items"`
+	Nested    *engineItem  `json:"nested"`
+	Ignored   string       `json:"-" validate:"required"`
+	internal  string       `validate:"required"`
+}
+
+func validEngineRequest() engineRequest {
+	return engineRequest{Name: "Netflix", StartDate: "07-2025"}
+}
+
+func TestValidateStruct(t *testing.T) {
+	one, zero := 1, 0
+	end, before, null, bad := "2025-08-31", "06-2025", "null", "31-2025"
+	category := GetAllowedCategories()[0]
+
+	tests := []struct {
+		name      string
+		change    func(r *engineRequest)
+		wantField string // Empty when the request is valid
+	}{
+		{name: "minimal", change: func(r *engineRequest) {}},
+		{name: "every field", change: func(r *engineRequest) {
+			r.Price, r.MinPrice, r.UserID = 1000, &one, "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e"
+			r.EndDate, r.Month, r.Email, r.URL = &end, "12-2025", "a@b.c", "https://example.com/hook"
+			r.Category, r.Mode, r.Code, r.Tags = category, "slow", "abc", []string{"a", "bcde"}
+			r.Items, r.Nested = []engineItem{{Name: "x"}}, &engineItem{Name: "y"}
+		}},
+		{name: "required blank", change: func(r *engineRequest) { r.Name = "  " }, wantField: "name"},
+		{name: "max runes", change: func(r *engineRequest) { r.Name = strings.Repeat("я", 11) }, wantField: "name"},
+		{name: "max runes at the bound", change: func(r *engineRequest) { r.Name = strings.Repeat("я", 10) }},
+		{name: "min number", change: func(r *engineRequest) { r.Price = -1 }, wantField: "price"},
+		{name: "max number", change: func(r *engineRequest) { r.Price = 1001 }, wantField: "price"},
+		{name: "min pointer", change: func(r *engineRequest) { r.MinPrice = &zero }, wantField: "min_price"},
+		{name: "uuid", change: func(r *engineRequest) { r.UserID = "nope" }, wantField: "user_id"},
+		{name: "date", change: func(r *engineRequest) { r.StartDate = "2025/07/01" }, wantField: "start_date"},
+		{name: "nullable", change: func(r *engineRequest) { r.EndDate = &null }},
+		{name: "date of pointer", change: func(r *engineRequest) { r.EndDate = &bad }, wantField: "end_date"},
+		{name: "gtefield", change: func(r *engineRequest) { r.EndDate = &before }, wantField: "end_date"},
+		{name: "gtefield same month", change: func(r *engineRequest) { same := "07-2025"; r.EndDate = &same }},
+		{name: "monthyear", change: func(r *engineRequest) { r.Month = "2025-07-01" }, wantField: "month"},
+		{name: "email", change: func(r *engineRequest) { r.Email = "a@" }, wantField: "email"},
+		{name: "url scheme", change: func(r *engineRequest) { r.URL = "ftp://example.com" }, wantField: "url"},
+		{name: "url host", change: func(r *engineRequest) { r.URL = "https://" }, wantField: "url"},
+		{name: "named enum", change: func(r *engineRequest) { r.Category = "nope" }, wantField: "category"},
+		{name: "inline enum", change: func(r *engineRequest) { r.Mode = "medium" }, wantField: "mode"},
+		{name: "len", change: func(r *engineRequest) { r.Code = "ab" }, wantField: "code"},
+		{name: "slice max", change: func(r *engineRequest) { r.Tags = []string{"a", "b", "c"} }, wantField: "tags"},
+		{name: "dive required", change: func(r *engineRequest) { r.Tags = []string{"a", " "} }, wantField: "tags[1]"},
+		{name: "dive max", change: func(r *engineRequest) { r.Tags = []string{"abcde"} }, wantField: "tags[0]"},
+		{name: "slice of structs", change: func(r *engineRequest) { r.Items = []engineItem{{Name: "x"}, {}} }, wantField: "items[1].name"},
+		{name: "nested struct", change: func(r *engineRequest) { r.Nested = &engineItem{Name: "toolong"} }, wantField: "nested.name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validEngineRequest()
+			tt.change(&r)
+
+			errs := ValidateStruct(r)
+			if tt.wantField == "" {
+				if errs != nil {
+					t.Fatalf("ValidateStruct() = %v, want nil", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField {
+				t.Fatalf("ValidateStruct() = %v, want a single error of %s", errs, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidateStructReportsEveryField(t *testing.T) {
+	errs := ValidateStruct(&engineRequest{Price: -1})
+
+	fields := make([]string, 0, len(errs))
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	if got := strings.Join(fields, ","); got != "name,price,start_date" {
+		t.Errorf("ValidateStruct() fields = %s, want name,price,start_date", got)
+	}
+}
+
+func TestValidateStructPanicsOnUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("ValidateStruct() did not panic on an unknown rule")
+		}
+	}()
+
+	ValidateStruct(struct {
+		Name string `json:"name" validate:"shiny"`
+	}{Name: "x"})
+}
+
+func TestEnum(t *testing.T) {
+	if values, ok := Enum("categories"); !ok || len(values) == 0 {
+		t.Errorf("Enum(categories) = %v, %v, want the categories", values, ok)
+	}
+	if _, ok := Enum("nope"); ok {
+		t.Error("Enum(nope) found a list")
+	}
+}
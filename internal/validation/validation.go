@@ -5,11 +5,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
-	"subscription-aggregator/internal/models"
+	"subscription-aggregator/pkg/models"
 
 	"github.com/google/uuid"
 )
 
+const isoDateLayout = "2006-01-02"
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
@@ -33,113 +35,220 @@ func (e ValidationErrors) Error() string {
 
 // ValidateCreateSubscription validates CreateSubscriptionRequest
 func ValidateCreateSubscription(req models.CreateSubscriptionRequest) error {
-	var errors ValidationErrors
+	errors := ValidateStruct(req)
 
-	// Validate service_name
-	if req.ServiceName == "" {
+	// Validate service_name or service_id
+	if req.ServiceID == "" && req.ServiceName == "" {
 		errors = append(errors, ValidationError{Field: "service_name", Message: "название сервиса обязательно"})
-	} else if len(req.ServiceName) > 255 {
-		errors = append(errors, ValidationError{Field: "service_name", Message: "название сервиса не должно превышать 255 символов"})
 	}
 
-	// Validate price
-	if req.Price < 0 {
-		errors = append(errors, ValidationError{Field: "price", Message: "стоимость не может быть отрицательной"})
+	if len(errors) > 0 {
+		return errors
 	}
 
-	// Validate user_id
-	if req.UserID == "" {
-		errors = append(errors, ValidationError{Field: "user_id", Message: "ID пользователя обязателен"})
-	} else if _, err := uuid.Parse(req.UserID); err != nil {
-		errors = append(errors, ValidationError{Field: "user_id", Message: "ID пользователя должен быть в формате UUID"})
-	}
+	return nil
+}
 
-	// Validate start_date
-	if req.StartDate == "" {
-		errors = append(errors, ValidationError{Field: "start_date", Message: "дата начала обязательна"})
-	} else if _, err := ParseMonthYear(req.StartDate); err != nil {
-		errors = append(errors, ValidationError{Field: "start_date", Message: "дата начала должна быть в формате MM-YYYY"})
+// ValidateUpdateSubscription validates UpdateSubscriptionRequest
+func ValidateUpdateSubscription(req models.UpdateSubscriptionRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
 	}
 
-	// Validate end_date if provided
-	if req.EndDate != "" {
-		if _, err := ParseMonthYear(req.EndDate); err != nil {
-			errors = append(errors, ValidationError{Field: "end_date", Message: "дата окончания должна быть в формате MM-YYYY"})
-		}
+	return nil
+}
+
+// ValidateAggregationRequest validates AggregationRequest
+func ValidateAggregationRequest(req models.AggregationRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
 	}
 
-	if len(errors) > 0 {
+	return nil
+}
+
+// ValidateForecastRequest validates ForecastRequest
+func ValidateForecastRequest(req models.ForecastRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
 		return errors
 	}
 
 	return nil
 }
 
-// ValidateUpdateSubscription validates UpdateSubscriptionRequest
-func ValidateUpdateSubscription(req models.UpdateSubscriptionRequest) error {
-	var errors ValidationErrors
+// ValidateSchedulePriceChange validates SchedulePriceChangeRequest
+func ValidateSchedulePriceChange(req models.SchedulePriceChangeRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
+	}
 
-	// Validate service_name if provided
-	if req.ServiceName != "" && len(req.ServiceName) > 255 {
-		errors = append(errors, ValidationError{Field: "service_name", Message: "название сервиса не должно превышать 255 символов"})
+	return nil
+}
+
+// ValidateCreateService validates CreateServiceRequest
+func ValidateCreateService(req models.CreateServiceRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
 	}
 
-	// Validate price if provided
-	if req.Price < 0 {
-		errors = append(errors, ValidationError{Field: "price", Message: "стоимость не может быть отрицательной"})
+	return nil
+}
+
+// ValidateUpdateService validates UpdateServiceRequest
+func ValidateUpdateService(req models.UpdateServiceRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
 	}
 
-	// Validate start_date if provided
-	if req.StartDate != "" {
-		if _, err := ParseMonthYear(req.StartDate); err != nil {
-			errors = append(errors, ValidationError{Field: "start_date", Message: "дата начала должна быть в формате MM-YYYY"})
+	return nil
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty entries and duplicates
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
 		}
+		seen[tag] = true
+		result = append(result, tag)
 	}
+	return result
+}
 
-	// Validate end_date if provided
-	if req.EndDate != "" {
-		if req.EndDate == "null" {
-			// Allow null value for clearing end_date
-		} else if _, err := ParseMonthYear(req.EndDate); err != nil {
-			errors = append(errors, ValidationError{Field: "end_date", Message: "дата окончания должна быть в формате MM-YYYY"})
+// NormalizeServiceName trims the name and collapses inner whitespace
+func NormalizeServiceName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
 		}
 	}
+	return false
+}
 
-	if len(errors) > 0 {
+// ValidateCreateUser validates CreateUserRequest
+func ValidateCreateUser(req models.CreateUserRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
 		return errors
 	}
 
 	return nil
 }
 
-// ValidateAggregationRequest validates AggregationRequest
-func ValidateAggregationRequest(req models.AggregationRequest) error {
-	var errors ValidationErrors
+// ValidateUpdateUser validates UpdateUserRequest
+func ValidateUpdateUser(req models.UpdateUserRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
 
-	// Validate start_date
-	if req.StartDate == "" {
-		errors = append(errors, ValidationError{Field: "start_date", Message: "дата начала обязательна"})
-	} else if _, err := ParseMonthYear(req.StartDate); err != nil {
-		errors = append(errors, ValidationError{Field: "start_date", Message: "дата начала должна быть в формате MM-YYYY"})
+// ValidateGraphQLRequest validates GraphQLRequest
+func ValidateGraphQLRequest(req models.GraphQLRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
 	}
 
-	// Validate end_date
-	if req.EndDate == "" {
-		errors = append(errors, ValidationError{Field: "end_date", Message: "дата окончания обязательна"})
-	} else if _, err := ParseMonthYear(req.EndDate); err != nil {
-		errors = append(errors, ValidationError{Field: "end_date", Message: "дата окончания должна быть в формате MM-YYYY"})
+	return nil
+}
+
+// ValidateCreateHousehold validates CreateHouseholdRequest
+func ValidateCreateHousehold(req models.CreateHouseholdRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+// ValidateAddHouseholdMember validates AddHouseholdMemberRequest
+func ValidateAddHouseholdMember(req models.AddHouseholdMemberRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+// ValidateSharing validates UpdateSharingRequest against the subscription price
+func ValidateSharing(req models.UpdateSharingRequest, price int) error {
+	errors := ValidateStruct(req)
+	if len(errors) > 0 {
+		return errors
 	}
 
-	// Validate user_id if provided
-	if req.UserID != nil {
-		if req.UserID.String() == "" {
-			errors = append(errors, ValidationError{Field: "user_id", Message: "ID пользователя не может быть пустым"})
+	// Validate shares against the split type
+	seen := make(map[string]bool)
+	var percentSum float64
+	var amountSum int
+	for _, share := range req.Shares {
+		if seen[share.UserID] {
+			errors = append(errors, ValidationError{Field: "shares", Message: "участник указан несколько раз"})
 		}
+		seen[share.UserID] = true
+
+		switch req.SplitType {
+		case models.SplitEqual:
+			if share.Percent != nil || share.Amount != nil {
+				errors = append(errors, ValidationError{Field: "shares", Message: "при равном разделении доли не указываются"})
+			}
+		case models.SplitPercent:
+			if share.Percent == nil || *share.Percent <= 0 || *share.Percent > 100 || share.Amount != nil {
+				errors = append(errors, ValidationError{Field: "shares", Message: "доля должна быть указана в процентах от 0 до 100"})
+			} else {
+				percentSum += *share.Percent
+			}
+		case models.SplitFixed:
+			if share.Amount == nil || *share.Amount < 0 || share.Percent != nil {
+				errors = append(errors, ValidationError{Field: "shares", Message: "доля должна быть указана неотрицательной суммой"})
+			} else {
+				amountSum += *share.Amount
+			}
+		}
+	}
+
+	if req.SplitType == models.SplitNone && len(req.Shares) > 0 {
+		errors = append(errors, ValidationError{Field: "shares", Message: "без разделения доли не указываются"})
+	}
+	if (req.SplitType == models.SplitPercent || req.SplitType == models.SplitFixed) && len(req.Shares) == 0 {
+		errors = append(errors, ValidationError{Field: "shares", Message: "необходимо указать доли участников"})
+	}
+	if req.SplitType == models.SplitEqual && len(req.Shares) == 0 && req.HouseholdID == nil {
+		errors = append(errors, ValidationError{Field: "shares", Message: "необходимо указать участников или группу"})
+	}
+	if percentSum > 100 {
+		errors = append(errors, ValidationError{Field: "shares", Message: "сумма долей не может превышать 100%"})
+	}
+	if amountSum > price {
+		errors = append(errors, ValidationError{Field: "shares", Message: "сумма долей не может превышать стоимость подписки"})
 	}
 
-	// Validate service_name if provided
-	if req.ServiceName != nil && len(*req.ServiceName) > 255 {
-		errors = append(errors, ValidationError{Field: "service_name", Message: "название сервиса не должно превышать 255 символов"})
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+// ValidateCreateAPIKey validates CreateAPIKeyRequest
+func ValidateCreateAPIKey(req models.CreateAPIKeyRequest) error {
+	errors := ValidateStruct(req)
+
+	// Validate expires_at if provided
+	if req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			errors = append(errors, ValidationError{Field: "expires_at", Message: "Срок действия должен быть в формате RFC 3339"})
+		} else if expiresAt.Before(time.Now()) {
+			errors = append(errors, ValidationError{Field: "expires_at", Message: "Срок действия должен быть в будущем"})
+		}
 	}
 
 	if len(errors) > 0 {
@@ -149,26 +258,36 @@ func ValidateAggregationRequest(req models.AggregationRequest) error {
 	return nil
 }
 
-// ValidateUUID validates UUID format
-func ValidateUUID(id string) error {
-	if _, err := uuid.Parse(id); err != nil {
-		return ValidationError{Field: "id", Message: "ID должен быть в формате UUID"}
+// ValidateCreateWebhook validates CreateWebhookRequest
+func ValidateCreateWebhook(req models.CreateWebhookRequest) error {
+	if errors := ValidateStruct(req); len(errors) > 0 {
+		return errors
 	}
+
 	return nil
 }
 
-// ValidateJSONFields validates that only allowed fields are present in JSON
-func ValidateJSONFields(data map[string]interface{}, allowedFields []string) error {
-	allowedFieldsMap := make(map[string]bool)
-	for _, field := range allowedFields {
-		allowedFieldsMap[field] = true
+// ValidateUpdateWebhook validates UpdateWebhookRequest
+func ValidateUpdateWebhook(req models.UpdateWebhookRequest) error {
+	errors := ValidateStruct(req)
+
+	if req.EventTypes != nil && len(req.EventTypes) == 0 {
+		errors = append(errors, ValidationError{Field: "event_types", Message: "необходимо указать хотя бы одно событие"})
 	}
 
-	var errors ValidationErrors
-	for field := range data {
-		if !allowedFieldsMap[field] {
-			errors = append(errors, ValidationError{Field: field, Message: "поле не разрешено"})
-		}
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+// ValidateCreateBudget validates CreateBudgetRequest
+func ValidateCreateBudget(req models.CreateBudgetRequest) error {
+	errors := ValidateStruct(req)
+
+	if req.Category != "" && req.ServiceID != "" {
+		errors = append(errors, ValidationError{Field: "service_id", Message: "бюджет ограничивается либо категорией, либо сервисом"})
 	}
 
 	if len(errors) > 0 {
@@ -178,6 +297,29 @@ func ValidateJSONFields(data map[string]interface{}, allowedFields []string) err
 	return nil
 }
 
+// ValidateUpdateBudget validates UpdateBudgetRequest
+func ValidateUpdateBudget(req models.UpdateBudgetRequest) error {
+	errors := ValidateStruct(req)
+
+	if req.Thresholds != nil && len(req.Thresholds) == 0 {
+		errors = append(errors, ValidationError{Field: "thresholds", Message: "необходимо указать хотя бы один порог"})
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+// ValidateUUID validates UUID format
+func ValidateUUID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ValidationError{Field: "id", Message: "ID должен быть в формате UUID"}
+	}
+	return nil
+}
+
 // ParseMonthYear parses date in MM-YYYY format
 func ParseMonthYear(dateStr string) (time.Time, error) {
 	// Expected format: "MM-YYYY"
@@ -199,17 +341,70 @@ func ParseMonthYear(dateStr string) (time.Time, error) {
 	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), nil
 }
 
-// GetAllowedFieldsForCreate returns allowed fields for creating subscription
-func GetAllowedFieldsForCreate() []string {
-	return []string{"service_name", "price", "user_id", "start_date", "end_date"}
+// ParseDate parses date in YYYY-MM-DD format or MM-YYYY shorthand.
+// The shorthand resolves to the first day of the month.
+func ParseDate(dateStr string) (time.Time, error) {
+	if len(dateStr) == len(isoDateLayout) {
+		date, err := time.Parse(isoDateLayout, dateStr)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date")
+		}
+		if date.Year() < 1900 || date.Year() > 2100 {
+			return time.Time{}, fmt.Errorf("invalid year")
+		}
+		return date, nil
+	}
+
+	return ParseMonthYear(dateStr)
+}
+
+// ParseEndDate parses an inclusive end date in YYYY-MM-DD format or
+// MM-YYYY shorthand. The shorthand resolves to the last day of the month.
+func ParseEndDate(dateStr string) (time.Time, error) {
+	if len(dateStr) == len(isoDateLayout) {
+		return ParseDate(dateStr)
+	}
+
+	date, err := ParseMonthYear(dateStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return date.AddDate(0, 1, -1), nil
+}
+
+// GetAllowedCategories returns known subscription categories
+func GetAllowedCategories() []string {
+	return []string{"streaming", "music", "video", "gaming", "entertainment", "cloud", "productivity", "education", "news", "fitness", "software", "other"}
+}
+
+// GetAllowedGroupBy returns dimensions aggregation can be grouped by
+func GetAllowedGroupBy() []string {
+	return []string{"service", "category", "tag"}
+}
+
+// GetAllowedSplitTypes returns supported cost-sharing split types
+func GetAllowedSplitTypes() []string {
+	return []string{models.SplitNone, models.SplitEqual, models.SplitPercent, models.SplitFixed}
+}
+
+// GetAllowedScopes returns scopes that can be granted to API keys
+func GetAllowedScopes() []string {
+	return []string{"read", "write", "admin", "*"}
+}
+
+// GetAllowedRoles returns roles that can be granted to API keys
+func GetAllowedRoles() []string {
+	return []string{"user", "admin", "finance"}
 }
 
-// GetAllowedFieldsForUpdate returns allowed fields for updating subscription
-func GetAllowedFieldsForUpdate() []string {
-	return []string{"service_name", "price", "start_date", "end_date"}
+// GetAllowedEventTypes returns events webhooks can subscribe to
+func GetAllowedEventTypes() []string {
+	return []string{models.EventSubscriptionCreated, models.EventSubscriptionUpdated, models.EventSubscriptionDeleted, models.EventSubscriptionEnded,
+		models.EventBudgetThresholdCrossed}
 }
 
-// GetAllowedFieldsForAggregation returns allowed fields for aggregation
-func GetAllowedFieldsForAggregation() []string {
-	return []string{"user_id", "service_name", "start_date", "end_date"}
+// GetAllowedBudgetPeriods returns periods budgets can limit
+func GetAllowedBudgetPeriods() []string {
+	return []string{models.BudgetMonthly, models.BudgetYearly}
 }
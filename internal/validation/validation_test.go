@@ -0,0 +1,185 @@
+package validation
+
+import (
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2025-03-20", want: time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)},
+		{in: "03-2025", want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "2024-02-29", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{in: "2025-02-29", wantErr: true},
+		{in: "2025-13-01", wantErr: true},
+		{in: "13-2025", wantErr: true},
+		{in: "1899-12-31", wantErr: true},
+		{in: "2101-01-01", wantErr: true},
+		{in: "12-1899", wantErr: true},
+		{in: "01-2101", wantErr: true},
+		{in: "2025/03/20", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDate(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDate(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseEndDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2025-03-15", want: time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{in: "03-2025", want: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{in: "02-2024", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{in: "02-2025", want: time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{in: "2101-01-31", wantErr: true},
+		{in: "00-2025", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEndDate(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseEndDate(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEndDate(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseEndDate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeServiceName(t *testing.T) {
+	tests := map[string]string{
+		"Yandex Plus":          "Yandex Plus",
+		"  Yandex   Plus \t":   "Yandex Plus",
+		"Яндекс  Плюс":         "Яндекс Плюс",
+		"":                     "",
+		"   ":                  "",
+		"Netflix\nPremium  HD": "Netflix Premium HD",
+	}
+	for in, want := range tests {
+		if got := NormalizeServiceName(in); got != want {
+			t.Errorf("NormalizeServiceName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags([]string{" Family ", "family", "", "WORK", "work ", "kids"})
+	want := []string{"family", "work", "kids"}
+	if len(got) != len(want) {
+		t.Fatalf("NormalizeTags() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("NormalizeTags() = %q, want %q", got, want)
+		}
+	}
+
+	if got := NormalizeTags(nil); got == nil || len(got) != 0 {
+		t.Errorf("NormalizeTags(nil) = %#v, want an empty slice", got)
+	}
+}
+
+func TestValidateCreateBudget(t *testing.T) {
+	valid := func() models.CreateBudgetRequest {
+		return models.CreateBudgetRequest{Name: "Развлечения", Period: models.BudgetMonthly, Amount: 3000}
+	}
+
+	tests := []struct {
+		name      string
+		change    func(r *models.CreateBudgetRequest)
+		wantField string // Empty when the request is valid
+	}{
+		{name: "minimal", change: func(r *models.CreateBudgetRequest) {}},
+		{name: "yearly with service", change: func(r *models.CreateBudgetRequest) {
+			r.Period, r.ServiceID, r.Thresholds = models.BudgetYearly, "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e", []int64{50, 1000}
+		}},
+		{name: "no name", change: func(r *models.CreateBudgetRequest) { r.Name = "" }, wantField: "name"},
+		{name: "unknown period", change: func(r *models.CreateBudgetRequest) { r.Period = "weekly" }, wantField: "period"},
+		{name: "zero amount", change: func(r *models.CreateBudgetRequest) { r.Amount = 0 }, wantField: "amount"},
+		{name: "threshold too high", change: func(r *models.CreateBudgetRequest) { r.Thresholds = []int64{1001} }, wantField: "thresholds[0]"},
+		{name: "category and service", change: func(r *models.CreateBudgetRequest) {
+			r.Category, r.ServiceID = GetAllowedCategories()[0], "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e"
+		}, wantField: "service_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.change(&req)
+			checkField(t, ValidateCreateBudget(req), tt.wantField)
+		})
+	}
+}
+
+func TestValidateUpdateBudget(t *testing.T) {
+	zero := int64(0)
+
+	tests := []struct {
+		name      string
+		req       models.UpdateBudgetRequest
+		wantField string
+	}{
+		{name: "empty", req: models.UpdateBudgetRequest{}},
+		{name: "thresholds", req: models.UpdateBudgetRequest{Thresholds: []int64{90}}},
+		{name: "no thresholds", req: models.UpdateBudgetRequest{Thresholds: []int64{}}, wantField: "thresholds"},
+		{name: "zero amount", req: models.UpdateBudgetRequest{Amount: &zero}, wantField: "amount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkField(t, ValidateUpdateBudget(tt.req), tt.wantField)
+		})
+	}
+}
+
+// checkField fails unless err is nil for an empty field or reports the field
+func checkField(t *testing.T, err error, field string) {
+	t.Helper()
+	if field == "" {
+		if err != nil {
+			t.Fatalf("error = %v, want none", err)
+		}
+		return
+	}
+
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("error = %v, want validation errors for %s", err, field)
+	}
+	for _, e := range errs {
+		if e.Field == field {
+			return
+		}
+	}
+	t.Fatalf("error = %v, want one for %s", err, field)
+}
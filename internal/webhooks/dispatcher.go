@@ -0,0 +1,229 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Options configures the dispatcher; zero values take the defaults
+type Options struct {
+	HTTPClient   *http.Client
+	PollInterval time.Duration // 5s
+	BatchSize    int           // Deliveries sent concurrently, 50
+	MaxAttempts  int           // Attempts before a delivery fails for good, 8
+	MinBackoff   time.Duration // Delay after the first failure, doubled after each next one, 30s
+	MaxBackoff   time.Duration // 6h
+	Timeout      time.Duration // Per request, 10s
+}
+
+// Dispatcher sends pending deliveries. It works on deliveries of every tenant, replicas share the work through
+// row locks.
+type Dispatcher struct {
+	db     *sqlx.DB
+	opts   Options
+	logger *logrus.Logger
+}
+
+func NewDispatcher(db *sqlx.DB, opts Options, logger *logrus.Logger) *Dispatcher {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{
+			// Endpoints answer themselves, redirects count as failures
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 6 * time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	return &Dispatcher{
+		db:     db,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Run delivers events until the context is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches are due
+		for {
+			sent, err := d.deliverDue(ctx)
+			if err != nil {
+				d.logger.WithError(err).Error("Failed to deliver webhooks")
+			}
+			if err != nil || sent < d.opts.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim is a delivery leased for sending together with its endpoint
+type claim struct {
+	ID        uuid.UUID `db:"id"`
+	EventType string    `db:"event_type"`
+	Payload   []byte    `db:"payload"`
+	Attempts  int       `db:"attempts"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+}
+
+// deliverDue sends a batch of due deliveries and returns how many it sent.
+// Claimed deliveries are postponed beyond the request timeout first, so a
+// crashed dispatcher leaves them to be retried rather than lost.
+func (d *Dispatcher) deliverDue(ctx context.Context) (int, error) {
+	var claims []claim
+	err := d.db.Select(&claims, `
+		WITH due AS (
+			SELECT dl.id FROM webhook_deliveries dl
+			JOIN webhooks w ON w.tenant_id = dl.tenant_id AND w.id = dl.webhook_id
+			WHERE dl.status = 'pending' AND dl.next_attempt_at <= NOW() AND w.active
+			ORDER BY dl.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF dl SKIP LOCKED
+		)
+		UPDATE webhook_deliveries dl SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM due, webhooks w
+		WHERE dl.id = due.id AND w.tenant_id = dl.tenant_id AND w.id = dl.webhook_id
+		RETURNING dl.id, dl.event_type, dl.payload, dl.attempts, w.url, w.secret`,
+		d.opts.BatchSize, (2 * d.opts.Timeout).Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range claims {
+		wg.Add(1)
+		go func(c claim) {
+			defer wg.Done()
+			d.deliver(ctx, c)
+		}(c)
+	}
+	wg.Wait()
+
+	return len(claims), nil
+}
+
+// deliver posts the event and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, c claim) {
+	status, sendErr := d.send(ctx, c)
+
+	attempts := c.Attempts + 1
+	logger := d.logger.WithFields(logrus.Fields{
+		"delivery_id": c.ID,
+		"event_type":  c.EventType,
+		"attempts":    attempts,
+	})
+
+	var lastStatus *int
+	if status != 0 {
+		lastStatus = &status
+	}
+
+	if sendErr == nil {
+		_, err := d.db.Exec(`
+			UPDATE webhook_deliveries
+			SET status = $2, attempts = $3, last_status = $4, last_error = NULL, delivered_at = NOW()
+			WHERE id = $1`, c.ID, models.DeliveryDelivered, attempts, lastStatus)
+		if err != nil {
+			logger.WithError(err).Error("Failed to record webhook delivery")
+		}
+		return
+	}
+
+	deliveryStatus := models.DeliveryPending
+	nextAttempt := time.Now().Add(d.backoff(attempts))
+	if attempts >= d.opts.MaxAttempts {
+		deliveryStatus = models.DeliveryFailed
+		logger.WithError(sendErr).Warn("Webhook delivery failed for good")
+	} else {
+		logger.WithError(sendErr).Info("Webhook delivery failed, will retry")
+	}
+
+	_, err := d.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status = $5, last_error = $6
+		WHERE id = $1`, c.ID, deliveryStatus, attempts, nextAttempt, lastStatus, sendErr.Error())
+	if err != nil {
+		logger.WithError(err).Error("Failed to record webhook delivery")
+	}
+}
+
+// send posts the signed payload and returns the response status; responses
+// other than 2xx are errors
+func (d *Dispatcher) send(ctx context.Context, c claim) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(c.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "subscription-aggregator-webhooks")
+	req.Header.Set(EventHeader, c.EventType)
+	req.Header.Set(DeliveryHeader, c.ID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(c.Secret, now, c.Payload))
+
+	resp, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the attempt following the given number
+// of failed ones
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.MinBackoff
+	for i := 1; i < attempts && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.opts.MaxBackoff)
+}
@@ -0,0 +1,123 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func testDispatcher(opts Options) *Dispatcher {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewDispatcher(nil, opts, logger)
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := testDispatcher(Options{MinBackoff: 30 * time.Second, MaxBackoff: 10 * time.Minute})
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDispatcherDefaults(t *testing.T) {
+	d := testDispatcher(Options{})
+
+	if d.opts.PollInterval != 5*time.Second || d.opts.BatchSize != 50 || d.opts.MaxAttempts != 8 ||
+		d.opts.MinBackoff != 30*time.Second || d.opts.MaxBackoff != 6*time.Hour || d.opts.Timeout != 10*time.Second {
+		t.Fatalf("NewDispatcher() options = %+v, want the defaults", d.opts)
+	}
+	if d.opts.HTTPClient == nil {
+		t.Fatal("NewDispatcher() left the HTTP client unset")
+	}
+}
+
+func TestDispatcherSend(t *testing.T) {
+	payload := []byte(`{"event":"subscription.created"}`)
+
+	tests := []struct {
+		name       string
+		status     int
+		location   string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "ok", status: http.StatusOK, wantStatus: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "server error", status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError, wantErr: true},
+		{name: "redirect not followed", status: http.StatusFound, location: "/elsewhere", wantStatus: http.StatusFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := claim{ID: uuid.New(), EventType: "subscription.created", Payload: payload, Secret: "whsec_test"}
+
+			var got *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/elsewhere" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				got = r
+				body, _ = io.ReadAll(r.Body)
+				if tt.location != "" {
+					w.Header().Set("Location", tt.location)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			c.URL = server.URL + "/hook"
+
+			status, err := testDispatcher(Options{}).send(context.Background(), c)
+			if status != tt.wantStatus || (err != nil) != tt.wantErr {
+				t.Fatalf("send() = %d, %v; want %d, error %v", status, err, tt.wantStatus, tt.wantErr)
+			}
+
+			if got.Method != http.MethodPost || string(body) != string(payload) {
+				t.Fatalf("request = %s %q, want POST of the payload", got.Method, body)
+			}
+			if got.Header.Get(EventHeader) != c.EventType || got.Header.Get(DeliveryHeader) != c.ID.String() {
+				t.Errorf("event headers = %q, %q", got.Header.Get(EventHeader), got.Header.Get(DeliveryHeader))
+			}
+			unix, err := strconv.ParseInt(got.Header.Get(TimestampHeader), 10, 64)
+			if err != nil {
+				t.Fatalf("invalid timestamp header %q", got.Header.Get(TimestampHeader))
+			}
+			if signature := got.Header.Get(SignatureHeader); signature != Sign(c.Secret, time.Unix(unix, 0), payload) {
+				t.Errorf("signature %q does not match the timestamp and body", signature)
+			}
+		})
+	}
+}
+
+func TestDispatcherSendUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c := claim{ID: uuid.New(), EventType: "subscription.created", URL: server.URL, Secret: "whsec_test"}
+	if status, err := testDispatcher(Options{Timeout: time.Second}).send(context.Background(), c); status != 0 || err == nil {
+		t.Fatalf("send() = %d, %v; want 0 and an error", status, err)
+	}
+}
@@ -0,0 +1,77 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+	"subscription-aggregator/internal/database"
+	"subscription-aggregator/internal/outbox"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Headers of delivery requests
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Enqueue records a delivery of the outbox message to every active webhook
+// of its tenant that listens to the event type. Messages published again
+// are not delivered twice.
+func Enqueue(q database.Querier, msg outbox.Message) error {
+	_, err := q.Exec(`
+		INSERT INTO webhook_deliveries (tenant_id, webhook_id, event_id, event_type, payload)
+		SELECT tenant_id, id, $2, $3, $4 FROM webhooks
+		WHERE tenant_id = $1 AND active AND $3::text = ANY(event_types)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		msg.TenantID, msg.EventID, msg.EventType, string(msg.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s event: %w", msg.EventType, err)
+	}
+
+	return nil
+}
+
+// Sink is the outbox sink turning messages into webhook deliveries
+type Sink struct {
+	db *sqlx.DB
+}
+
+func NewSink(db *sqlx.DB) *Sink {
+	return &Sink{db: db}
+}
+
+func (s *Sink) Publish(ctx context.Context, msg outbox.Message) error {
+	return Enqueue(s.db, msg)
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
+
+// Sign returns the signature header value of a body sent at the timestamp:
+// the hex HMAC-SHA256 of "<unix seconds>.<body>" keyed with the secret
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret returns a random signing secret
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,46 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	timestamp := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	body := []byte(`{"event":"subscription.created"}`)
+
+	// HMAC-SHA256 of "1735689600.<body>" keyed with whsec_test
+	want := "sha256=a1fb482460768c81b815324470100f854c25a05db9528b45f67a7f4db6a9a951"
+	if got := Sign("whsec_test", timestamp, body); got != want {
+		t.Fatalf("Sign() = %q, want %q", got, want)
+	}
+
+	for name, got := range map[string]string{
+		"secret":    Sign("whsec_other", timestamp, body),
+		"timestamp": Sign("whsec_test", timestamp.Add(time.Second), body),
+		"body":      Sign("whsec_test", timestamp, []byte(`{"event":"subscription.deleted"}`)),
+	} {
+		if got == want {
+			t.Errorf("signature does not depend on the %s", name)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	first, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(first, "whsec_") || len(first) != len("whsec_")+48 {
+		t.Fatalf("GenerateSecret() = %q, want whsec_ and 48 hex digits", first)
+	}
+	if first == second {
+		t.Fatalf("GenerateSecret() returned %q twice", first)
+	}
+}
@@ -0,0 +1,4 @@
+-- Intentionally empty. The up migration moved month-precision end dates to
+-- the last day of their month, after which they cannot be told apart from
+-- ISO end dates entered on the last day of a month; moving them back would
+-- rewrite those too. Rolling back leaves end dates on the last day.
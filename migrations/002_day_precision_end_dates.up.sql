@@ -0,0 +1,5 @@
+-- MM-YYYY end dates used to be stored as the first day of the month.
+-- End dates are inclusive days now, so move them to the last day of the month.
+UPDATE subscriptions
+SET end_date = (date_trunc('month', end_date) + INTERVAL '1 month - 1 day')::date
+WHERE end_date IS NOT NULL AND end_date = date_trunc('month', end_date)::date;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_service_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_id;
+DROP TABLE IF EXISTS services;
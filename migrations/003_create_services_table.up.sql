@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category VARCHAR(64),
+    default_price INTEGER CHECK (default_price >= 0),
+    website VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_name_lower ON services(lower(name));
+
+-- Register every distinct existing service name in the catalog
+INSERT INTO services (name)
+SELECT DISTINCT ON (lower(btrim(service_name))) btrim(service_name)
+FROM subscriptions
+ORDER BY lower(btrim(service_name)), created_at;
+
+-- Link subscriptions to the catalog and normalize their names
+ALTER TABLE subscriptions ADD COLUMN service_id UUID REFERENCES services(id);
+
+UPDATE subscriptions s
+SET service_id = sv.id, service_name = sv.name
+FROM services sv
+WHERE lower(btrim(s.service_name)) = lower(sv.name);
+
+ALTER TABLE subscriptions ALTER COLUMN service_id SET NOT NULL;
+
+CREATE INDEX idx_subscriptions_service_id ON subscriptions(service_id);
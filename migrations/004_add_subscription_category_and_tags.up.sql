@@ -0,0 +1,11 @@
+ALTER TABLE subscriptions ADD COLUMN category VARCHAR(64);
+ALTER TABLE subscriptions ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Existing subscriptions inherit the category of their catalog service
+UPDATE subscriptions s
+SET category = sv.category
+FROM services sv
+WHERE s.service_id = sv.id AND sv.category IS NOT NULL;
+
+CREATE INDEX idx_subscriptions_category ON subscriptions(category);
+CREATE INDEX idx_subscriptions_tags ON subscriptions USING GIN (tags);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS subscription_shares;
+DROP INDEX IF EXISTS idx_subscriptions_household_id;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN IF EXISTS split_type;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN IF EXISTS household_id;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS fk_subscriptions_user;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255),
+    email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email));
+
+-- Back every existing user_id with a user row
+INSERT INTO users (id)
+SELECT DISTINCT user_id FROM subscriptions
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT fk_subscriptions_user FOREIGN KEY (user_id) REFERENCES users(id);
+
+CREATE TABLE IF NOT EXISTS households (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id UUID NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_members_user_id ON household_members(user_id);
+
+-- Cost sharing: the owner (user_id) pays unless the subscription is split
+ALTER TABLE subscriptions ADD COLUMN household_id UUID REFERENCES households(id) ON DELETE SET NULL;
+ALTER TABLE subscriptions ADD COLUMN split_type VARCHAR(16) NOT NULL DEFAULT 'none'
+    CHECK (split_type IN ('none', 'equal', 'percent', 'fixed'));
+
+CREATE TABLE IF NOT EXISTS subscription_shares (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    percent NUMERIC(5, 2) CHECK (percent > 0 AND percent <= 100),
+    amount INTEGER CHECK (amount >= 0),
+    PRIMARY KEY (subscription_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_shares_user_id ON subscription_shares(user_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_household_id ON subscriptions(household_id);
@@ -0,0 +1,60 @@
+DROP POLICY IF EXISTS tenant_isolation ON subscription_shares;
+DROP POLICY IF EXISTS tenant_isolation ON household_members;
+DROP POLICY IF EXISTS tenant_isolation ON households;
+DROP POLICY IF EXISTS tenant_isolation ON users;
+DROP POLICY IF EXISTS tenant_isolation ON services;
+DROP POLICY IF EXISTS tenant_isolation ON subscriptions;
+
+ALTER TABLE IF EXISTS subscription_shares DISABLE ROW LEVEL SECURITY;
+ALTER TABLE IF EXISTS household_members DISABLE ROW LEVEL SECURITY;
+ALTER TABLE IF EXISTS households DISABLE ROW LEVEL SECURITY;
+ALTER TABLE IF EXISTS users DISABLE ROW LEVEL SECURITY;
+ALTER TABLE IF EXISTS services DISABLE ROW LEVEL SECURITY;
+ALTER TABLE IF EXISTS subscriptions DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_households_tenant_id;
+DROP INDEX IF EXISTS idx_services_tenant_id;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_user;
+
+ALTER TABLE IF EXISTS subscription_shares DROP CONSTRAINT IF EXISTS fk_subscription_shares_user;
+ALTER TABLE IF EXISTS subscription_shares DROP CONSTRAINT IF EXISTS fk_subscription_shares_subscription;
+ALTER TABLE IF EXISTS household_members DROP CONSTRAINT IF EXISTS fk_household_members_user;
+ALTER TABLE IF EXISTS household_members DROP CONSTRAINT IF EXISTS fk_household_members_household;
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS fk_subscriptions_household;
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS fk_subscriptions_service;
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS fk_subscriptions_user;
+
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS uq_subscriptions_tenant_id;
+ALTER TABLE IF EXISTS households DROP CONSTRAINT IF EXISTS uq_households_tenant_id;
+ALTER TABLE IF EXISTS services DROP CONSTRAINT IF EXISTS uq_services_tenant_id;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'tenant_id') THEN
+        DROP INDEX IF EXISTS idx_services_name_lower;
+        CREATE UNIQUE INDEX idx_services_name_lower ON services(lower(name));
+        DROP INDEX IF EXISTS idx_users_email_lower;
+        CREATE UNIQUE INDEX idx_users_email_lower ON users(lower(email));
+
+        ALTER TABLE users DROP CONSTRAINT users_pkey;
+        ALTER TABLE users ADD PRIMARY KEY (id);
+
+        ALTER TABLE subscriptions
+            ADD CONSTRAINT fk_subscriptions_user FOREIGN KEY (user_id) REFERENCES users(id),
+            ADD CONSTRAINT subscriptions_service_id_fkey FOREIGN KEY (service_id) REFERENCES services(id),
+            ADD CONSTRAINT subscriptions_household_id_fkey FOREIGN KEY (household_id) REFERENCES households(id) ON DELETE SET NULL;
+        ALTER TABLE household_members
+            ADD CONSTRAINT household_members_household_id_fkey FOREIGN KEY (household_id) REFERENCES households(id) ON DELETE CASCADE,
+            ADD CONSTRAINT household_members_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+        ALTER TABLE subscription_shares
+            ADD CONSTRAINT subscription_shares_subscription_id_fkey FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+            ADD CONSTRAINT subscription_shares_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+    END IF;
+END $$;
+
+ALTER TABLE IF EXISTS subscription_shares DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE IF EXISTS household_members DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE IF EXISTS households DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE IF EXISTS services DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,95 @@
+-- Every row belongs to a tenant. Existing data moves to the default tenant.
+ALTER TABLE subscriptions ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE services ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE users ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE households ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE household_members ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE subscription_shares ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+
+ALTER TABLE subscriptions ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE services ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE households ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE household_members ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE subscription_shares ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Drop single-column foreign keys, they are replaced by tenant-aware ones below
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS fk_subscriptions_user;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_service_id_fkey;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_household_id_fkey;
+ALTER TABLE household_members DROP CONSTRAINT IF EXISTS household_members_household_id_fkey;
+ALTER TABLE household_members DROP CONSTRAINT IF EXISTS household_members_user_id_fkey;
+ALTER TABLE subscription_shares DROP CONSTRAINT IF EXISTS subscription_shares_subscription_id_fkey;
+ALTER TABLE subscription_shares DROP CONSTRAINT IF EXISTS subscription_shares_user_id_fkey;
+
+-- User IDs are unique within a tenant only
+ALTER TABLE users DROP CONSTRAINT users_pkey;
+ALTER TABLE users ADD PRIMARY KEY (tenant_id, id);
+DROP INDEX IF EXISTS idx_users_email_lower;
+CREATE UNIQUE INDEX idx_users_email_lower ON users(tenant_id, lower(email));
+
+ALTER TABLE services ADD CONSTRAINT uq_services_tenant_id UNIQUE (tenant_id, id);
+DROP INDEX IF EXISTS idx_services_name_lower;
+CREATE UNIQUE INDEX idx_services_name_lower ON services(tenant_id, lower(name));
+
+ALTER TABLE households ADD CONSTRAINT uq_households_tenant_id UNIQUE (tenant_id, id);
+ALTER TABLE subscriptions ADD CONSTRAINT uq_subscriptions_tenant_id UNIQUE (tenant_id, id);
+
+-- References can never cross tenants
+ALTER TABLE subscriptions
+    ADD CONSTRAINT fk_subscriptions_user FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, id),
+    ADD CONSTRAINT fk_subscriptions_service FOREIGN KEY (tenant_id, service_id) REFERENCES services(tenant_id, id),
+    ADD CONSTRAINT fk_subscriptions_household FOREIGN KEY (tenant_id, household_id)
+        REFERENCES households(tenant_id, id) ON DELETE SET NULL (household_id);
+
+ALTER TABLE household_members
+    ADD CONSTRAINT fk_household_members_household FOREIGN KEY (tenant_id, household_id)
+        REFERENCES households(tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_household_members_user FOREIGN KEY (tenant_id, user_id)
+        REFERENCES users(tenant_id, id) ON DELETE CASCADE;
+
+ALTER TABLE subscription_shares
+    ADD CONSTRAINT fk_subscription_shares_subscription FOREIGN KEY (tenant_id, subscription_id)
+        REFERENCES subscriptions(tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_subscription_shares_user FOREIGN KEY (tenant_id, user_id)
+        REFERENCES users(tenant_id, id) ON DELETE CASCADE;
+
+CREATE INDEX idx_subscriptions_tenant_user ON subscriptions(tenant_id, user_id);
+CREATE INDEX idx_services_tenant_id ON services(tenant_id);
+CREATE INDEX idx_households_tenant_id ON households(tenant_id);
+
+-- Second line of defense: when the application sets app.tenant_id
+-- (database.row_level_security), rows of other tenants are invisible.
+-- Policies do not apply to superusers, so connect as a regular role.
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE services ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE households ENABLE ROW LEVEL SECURITY;
+ALTER TABLE household_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_shares ENABLE ROW LEVEL SECURITY;
+
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+ALTER TABLE services FORCE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+ALTER TABLE households FORCE ROW LEVEL SECURITY;
+ALTER TABLE household_members FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_shares FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON subscriptions
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON services
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON users
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON households
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON household_members
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON subscription_shares
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_user_service;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS chk_subscriptions_period;
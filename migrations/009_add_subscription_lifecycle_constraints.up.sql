@@ -0,0 +1,8 @@
+-- Periods must not be inverted. NOT VALID keeps existing rows loadable;
+-- the constraint applies to every insert and update from now on, and
+-- VALIDATE CONSTRAINT can be run once legacy rows are fixed.
+ALTER TABLE subscriptions
+    ADD CONSTRAINT chk_subscriptions_period CHECK (end_date IS NULL OR end_date >= start_date) NOT VALID;
+
+-- Overlap lookups by user and service
+CREATE INDEX idx_subscriptions_user_service ON subscriptions(tenant_id, user_id, service_id, start_date);
@@ -0,0 +1,11 @@
+-- Responses of POST and PATCH requests sent with an Idempotency-Key header
+CREATE TABLE idempotency_keys (
+    key VARCHAR(512) PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    status INTEGER, -- NULL while the request is in progress
+    content_type VARCHAR(255),
+    body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
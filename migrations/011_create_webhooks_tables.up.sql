@@ -0,0 +1,64 @@
+-- Endpoints receiving subscription lifecycle events
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (tenant_id, id)
+);
+
+CREATE INDEX idx_webhooks_tenant_id ON webhooks(tenant_id);
+
+-- One row per event and endpoint. Failed rows exhausted their attempts
+-- and form the dead-letter list until they are redelivered.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL,
+    webhook_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_status INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (tenant_id, webhook_id)
+        REFERENCES webhooks(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries(tenant_id, webhook_id, created_at);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- Subscriptions whose end date passed and were announced as ended, so
+-- every end date is announced once
+CREATE TABLE subscription_end_events (
+    tenant_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, subscription_id, end_date)
+);
+
+-- Subscriptions that ended before webhooks existed are not announced
+INSERT INTO subscription_end_events (tenant_id, subscription_id, end_date)
+SELECT tenant_id, id, end_date FROM subscriptions WHERE end_date < CURRENT_DATE;
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON webhooks
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON webhook_deliveries
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
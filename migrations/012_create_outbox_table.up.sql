@@ -0,0 +1,28 @@
+-- Events written in the transaction of the change that caused them. The
+-- relay publishes them in id order, one subscription (aggregate) at a time.
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL,
+    event_id UUID NOT NULL UNIQUE,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_unpublished ON outbox(aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_published_at ON outbox(published_at) WHERE published_at IS NOT NULL;
+
+-- Events published again after a relay crash are delivered to a webhook once
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id);
+
+ALTER TABLE outbox ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON outbox
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
@@ -0,0 +1,13 @@
+-- Reminders sent through each notifier, so every renewal and end date is
+-- announced once per channel
+CREATE TABLE reminders_sent (
+    tenant_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    due_date DATE NOT NULL,
+    channel VARCHAR(32) NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, subscription_id, kind, due_date, channel)
+);
+
+CREATE INDEX idx_reminders_sent_due_date ON reminders_sent(due_date);
@@ -0,0 +1,48 @@
+-- Spending limits per month or year, for the whole tenant or one user,
+-- optionally limited to a category or a catalog service
+CREATE TABLE budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL,
+    user_id UUID,
+    name VARCHAR(255) NOT NULL,
+    period VARCHAR(16) NOT NULL CHECK (period IN ('monthly', 'yearly')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    category VARCHAR(64),
+    service_id UUID,
+    thresholds BIGINT[] NOT NULL DEFAULT '{80,100}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (tenant_id, id),
+    CONSTRAINT chk_budgets_scope CHECK (category IS NULL OR service_id IS NULL),
+    CONSTRAINT fk_budgets_service FOREIGN KEY (tenant_id, service_id)
+        REFERENCES services(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_budgets_tenant_id ON budgets(tenant_id);
+CREATE INDEX idx_budgets_user_id ON budgets(tenant_id, user_id);
+
+-- Thresholds crossed in a budget period, so every crossing is announced once
+CREATE TABLE budget_alerts (
+    tenant_id UUID NOT NULL,
+    budget_id UUID NOT NULL,
+    period_start DATE NOT NULL,
+    threshold BIGINT NOT NULL,
+    spent BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, budget_id, period_start, threshold),
+    CONSTRAINT fk_budget_alerts_budget FOREIGN KEY (tenant_id, budget_id)
+        REFERENCES budgets(tenant_id, id) ON DELETE CASCADE
+);
+
+ALTER TABLE budgets ENABLE ROW LEVEL SECURITY;
+ALTER TABLE budget_alerts ENABLE ROW LEVEL SECURITY;
+
+ALTER TABLE budgets FORCE ROW LEVEL SECURITY;
+ALTER TABLE budget_alerts FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON budgets
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+CREATE POLICY tenant_isolation ON budget_alerts
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
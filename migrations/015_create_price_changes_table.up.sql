@@ -0,0 +1,21 @@
+-- Prices subscriptions will switch to; applied to the subscription and
+-- removed once the effective date comes
+CREATE TABLE subscription_price_changes (
+    tenant_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    effective_date DATE NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, subscription_id, effective_date),
+    CONSTRAINT fk_price_changes_subscription FOREIGN KEY (tenant_id, subscription_id)
+        REFERENCES subscriptions(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_price_changes_effective_date ON subscription_price_changes(effective_date);
+
+ALTER TABLE subscription_price_changes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_price_changes FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON subscription_price_changes
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
@@ -0,0 +1,33 @@
+-- Full prices of subscriptions active in each month by owner and service,
+-- the cost monthly aggregation charges; kept up to date on every write and
+-- rebuilt in the background
+CREATE TABLE monthly_spend (
+    tenant_id UUID NOT NULL,
+    month DATE NOT NULL,
+    user_id UUID NOT NULL,
+    service_id UUID NOT NULL,
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (tenant_id, month, user_id, service_id)
+);
+
+-- Tenants with a built rollup; open-ended subscriptions are counted up to
+-- until_month, later months are aggregated from subscriptions
+CREATE TABLE monthly_spend_state (
+    tenant_id UUID PRIMARY KEY,
+    until_month DATE NOT NULL,
+    rebuilt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE monthly_spend ENABLE ROW LEVEL SECURITY;
+ALTER TABLE monthly_spend_state ENABLE ROW LEVEL SECURITY;
+
+ALTER TABLE monthly_spend FORCE ROW LEVEL SECURITY;
+ALTER TABLE monthly_spend_state FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON monthly_spend
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+CREATE POLICY tenant_isolation ON monthly_spend_state
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL
+           OR tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
@@ -0,0 +1,60 @@
+-- Policies created so far let every row through when app.tenant_id is
+-- not set. Fail closed instead: without the setting current_setting raises
+-- an error. Background jobs and lookups made before the tenant is known
+-- connect as the system role (database.system_user), which needs BYPASSRLS.
+
+DROP POLICY IF EXISTS tenant_isolation ON subscriptions;
+CREATE POLICY tenant_isolation ON subscriptions
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON services;
+CREATE POLICY tenant_isolation ON services
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON users;
+CREATE POLICY tenant_isolation ON users
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON households;
+CREATE POLICY tenant_isolation ON households
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON household_members;
+CREATE POLICY tenant_isolation ON household_members
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON subscription_shares;
+CREATE POLICY tenant_isolation ON subscription_shares
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON webhooks;
+CREATE POLICY tenant_isolation ON webhooks
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON webhook_deliveries;
+CREATE POLICY tenant_isolation ON webhook_deliveries
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON outbox;
+CREATE POLICY tenant_isolation ON outbox
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON budgets;
+CREATE POLICY tenant_isolation ON budgets
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON budget_alerts;
+CREATE POLICY tenant_isolation ON budget_alerts
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON subscription_price_changes;
+CREATE POLICY tenant_isolation ON subscription_price_changes
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON monthly_spend;
+CREATE POLICY tenant_isolation ON monthly_spend
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
+
+DROP POLICY IF EXISTS tenant_isolation ON monthly_spend_state;
+CREATE POLICY tenant_isolation ON monthly_spend_state
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
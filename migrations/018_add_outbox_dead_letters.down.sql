@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_failed_at;
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+ALTER TABLE outbox DROP COLUMN IF EXISTS failed_at;
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(aggregate_id, id) WHERE published_at IS NULL;
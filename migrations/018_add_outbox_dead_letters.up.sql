@@ -0,0 +1,8 @@
+-- Messages the relay gave up on after too many attempts. They no longer
+-- hold up later messages of their aggregate and stay for inspection;
+-- clearing failed_at queues them again.
+ALTER TABLE outbox ADD COLUMN failed_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+CREATE INDEX idx_outbox_unpublished ON outbox(aggregate_id, id) WHERE published_at IS NULL AND failed_at IS NULL;
+CREATE INDEX idx_outbox_failed_at ON outbox(failed_at) WHERE failed_at IS NOT NULL;
@@ -0,0 +1 @@
+-- Seeded end events cannot be told apart from announced ones and are kept
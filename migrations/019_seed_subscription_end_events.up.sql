@@ -0,0 +1,6 @@
+-- Subscriptions that ended before the outbox relay took over announcing
+-- them are not announced: a relay enabled on an existing database would
+-- otherwise publish subscription.ended for every past end date
+INSERT INTO subscription_end_events (tenant_id, subscription_id, end_date)
+SELECT tenant_id, id, end_date FROM subscriptions WHERE end_date < CURRENT_DATE
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,19 @@
+-- Prices subscriptions had before applied price changes: price was in
+-- effect up to the day before valid_until. Months before a change keep
+-- the price they were charged at; later months take the next recorded
+-- price or the current one.
+CREATE TABLE subscription_prices (
+    tenant_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    valid_until DATE NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (tenant_id, subscription_id, valid_until),
+    CONSTRAINT fk_subscription_prices_subscription FOREIGN KEY (tenant_id, subscription_id)
+        REFERENCES subscriptions(tenant_id, id) ON DELETE CASCADE
+);
+
+ALTER TABLE subscription_prices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_prices FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON subscription_prices
+    USING (tenant_id = current_setting('app.tenant_id')::uuid);
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// ListAPIKeys returns the API keys of the tenant. Managing API keys needs
+// the admin scope.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := c.do(ctx, http.MethodGet, "/api-keys", nil, nil, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateAPIKey issues an API key. The plain key is only returned here.
+func (c *Client) CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error) {
+	var key models.CreatedAPIKey
+	if err := c.do(ctx, http.MethodPost, "/api-keys", nil, req, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey revokes the API key with the ID
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/api-keys/"+id.String(), nil, nil, nil)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// ListServices returns the catalog services, optionally those with the
+// name or alias and of the category
+func (c *Client) ListServices(ctx context.Context, name, category string) ([]models.Service, error) {
+	query := make(url.Values)
+	if name != "" {
+		query.Set("name", name)
+	}
+	if category != "" {
+		query.Set("category", category)
+	}
+
+	var services []models.Service
+	if err := c.do(ctx, http.MethodGet, "/services", query, nil, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// CreateService adds a service to the catalog
+func (c *Client) CreateService(ctx context.Context, req models.CreateServiceRequest) (*models.Service, error) {
+	var service models.Service
+	if err := c.do(ctx, http.MethodPost, "/services", nil, req, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// GetService returns the catalog service with the ID
+func (c *Client) GetService(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	var service models.Service
+	if err := c.do(ctx, http.MethodGet, "/services/"+id.String(), nil, nil, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// UpdateService changes the fields set in the request
+func (c *Client) UpdateService(ctx context.Context, id uuid.UUID, req models.UpdateServiceRequest) error {
+	return c.do(ctx, http.MethodPut, "/services/"+id.String(), nil, req, nil)
+}
+
+// DeleteService removes a service no subscription refers to
+func (c *Client) DeleteService(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/services/"+id.String(), nil, nil, nil)
+}
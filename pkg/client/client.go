@@ -0,0 +1,273 @@
+// Package client is a typed Go client of the v2 HTTP API of the
+// subscription aggregator.
+//
+// Failed requests are retried with exponential backoff on 429 and 5xx
+// responses and on network errors. GET, PUT and DELETE are retried as is;
+// POST and PATCH carry an Idempotency-Key, so the server answers a retry
+// with the response to the first attempt instead of running it twice.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Defaults of Options
+const (
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+// apiPrefix is the API version the client speaks
+const apiPrefix = "/v2"
+
+// Options configures a Client
+type Options struct {
+	HTTPClient *http.Client // http.DefaultClient when nil
+
+	APIKey   string    // Sent in X-API-Key
+	Token    string    // Sent as a bearer token, a JWT or an API key
+	TenantID uuid.UUID // Sent in X-Tenant-ID unless nil
+
+	MaxRetries int           // Retries after the first attempt, DefaultMaxRetries when zero, none when negative
+	MinBackoff time.Duration // Delay before the first retry, doubled for every next one
+	MaxBackoff time.Duration // Upper bound of the delay, also for Retry-After
+
+	UserAgent string
+}
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	opts    Options
+}
+
+// New returns a client of the service at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = DefaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+
+	return &Client{baseURL: u, opts: opts}, nil
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound reports whether the API answered 404
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey sets the Idempotency-Key of POST and PATCH requests
+// made with the context. Without it every call gets a random key, which
+// covers the retries of the call but not calls repeated by the caller.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// do calls an operation of the v2 API and decodes the data of the response
+// envelope into out, unless out is nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	data, err := c.send(ctx, method, apiPrefix+path, query, body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	var envelope models.Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send makes the request, retrying it while the failure is transient, and
+// returns the body of the successful response
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) ([]byte, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	header := c.header()
+	if body != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	if method == http.MethodPost || method == http.MethodPatch {
+		key, ok := ctx.Value(idempotencyKey{}).(string)
+		if !ok {
+			key = uuid.NewString()
+		}
+		header.Set("Idempotency-Key", key)
+	}
+
+	for attempt := 0; ; attempt++ {
+		data, retryAfter, err := c.attempt(ctx, method, u.String(), header, payload)
+		if err == nil {
+			return data, nil
+		}
+
+		if attempt >= c.opts.MaxRetries || !retryable(err) {
+			return nil, err
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter > delay {
+			delay = min(retryAfter, c.opts.MaxBackoff)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes the request once. It returns the Retry-After delay the
+// server asked for along with the error.
+func (c *Client) attempt(ctx context.Context, method, target string, header http.Header, payload []byte) ([]byte, time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header = header.Clone()
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, time.Duration(seconds) * time.Second, responseError(resp.StatusCode, data)
+	}
+
+	return data, 0, nil
+}
+
+func (c *Client) header() http.Header {
+	header := make(http.Header)
+	header.Set("Accept", "application/json")
+	if c.opts.UserAgent != "" {
+		header.Set("User-Agent", c.opts.UserAgent)
+	}
+	if c.opts.APIKey != "" {
+		header.Set("X-API-Key", c.opts.APIKey)
+	}
+	if c.opts.Token != "" {
+		header.Set("Authorization", "Bearer "+c.opts.Token)
+	}
+	if c.opts.TenantID != uuid.Nil {
+		header.Set("X-Tenant-ID", c.opts.TenantID.String())
+	}
+	return header
+}
+
+// backoff returns the delay before the retry after the attempt: the
+// exponential backoff with jitter, so that clients failing together do not
+// retry together
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.opts.MaxBackoff
+	if attempt < 30 {
+		delay = min(c.opts.MinBackoff<<attempt, c.opts.MaxBackoff)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryable reports whether the failure may go away on its own
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		// Network errors
+		return true
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// responseError reads the message of an error envelope, or of a plain text
+// error of routes outside the versioning
+func responseError(status int, data []byte) *Error {
+	var envelope models.ErrorEnvelope
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope.Error.Message != "" {
+		return &Error{StatusCode: status, Message: envelope.Error.Message}
+	}
+	return &Error{StatusCode: status, Message: strings.TrimSpace(string(data))}
+}
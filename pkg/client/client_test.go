@@ -0,0 +1,409 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// recorder answers requests with the responses in order, repeating the
+// last one, and keeps the requests it got
+type recorder struct {
+	mu        sync.Mutex
+	responses []response
+	requests  []recorded
+}
+
+type response struct {
+	status     int
+	body       string
+	retryAfter string
+}
+
+type recorded struct {
+	method string
+	path   string
+	query  string
+	header http.Header
+	body   string
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rec.mu.Lock()
+	n := len(rec.requests)
+	rec.requests = append(rec.requests, recorded{r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Clone(), string(body)})
+	resp := rec.responses[min(n, len(rec.responses)-1)]
+	rec.mu.Unlock()
+
+	if resp.retryAfter != "" {
+		w.Header().Set("Retry-After", resp.retryAfter)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.status)
+	io.WriteString(w, resp.body)
+}
+
+func (rec *recorder) calls() []recorded {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]recorded(nil), rec.requests...)
+}
+
+func testClient(t *testing.T, handler http.Handler, opts Options) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	if opts.MinBackoff == 0 {
+		opts.MinBackoff = time.Millisecond
+	}
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = 5 * time.Millisecond
+	}
+	c, err := New(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+const (
+	subscriptionID = "7c1e4f8a-4d64-4b57-9e39-3b9d6a6f1c2e"
+	subscription   = `{"data":{"id":"` + subscriptionID + `","service_name":"Netflix","price":400}}`
+	serverError    = `{"error":{"status":500,"message":"Internal server error"}}`
+)
+
+func TestClientRetries(t *testing.T) {
+	id := uuid.MustParse(subscriptionID)
+
+	tests := []struct {
+		name         string
+		maxRetries   int
+		responses    []response
+		wantAttempts int
+		wantStatus   int // Status of the returned error, 0 on success
+	}{
+		{"success", 0, []response{{200, subscription, ""}}, 1, 0},
+		{"recovers from 5xx", 0, []response{{503, serverError, ""}, {502, serverError, ""}, {200, subscription, ""}}, 3, 0},
+		{"recovers from 429", 0, []response{{429, `{"error":{"status":429,"message":"Rate limit exceeded"}}`, ""}, {200, subscription, ""}}, 2, 0},
+		{"gives up after max retries", 2, []response{{500, serverError, ""}}, 3, 500},
+		{"default max retries", 0, []response{{500, serverError, ""}}, DefaultMaxRetries + 1, 500},
+		{"no retries", -1, []response{{500, serverError, ""}}, 1, 500},
+		{"client errors are not retried", 0, []response{{404, `{"error":{"status":404,"message":"Subscription not found"}}`, ""}}, 1, 404},
+		{"501 is not retried", 0, []response{{501, serverError, ""}}, 1, 501},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{responses: tt.responses}
+			c := testClient(t, rec, Options{MaxRetries: tt.maxRetries})
+
+			_, err := c.GetSubscription(context.Background(), id)
+			if got := len(rec.calls()); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("GetSubscription() error = %v", err)
+				}
+				return
+			}
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+				t.Fatalf("GetSubscription() error = %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	rec := &recorder{responses: []response{{503, serverError, "1"}, {200, subscription, ""}}}
+	// Retry-After asks for a second, MaxBackoff bounds it to 50ms, while the
+	// backoff alone would wait at most a millisecond
+	c := testClient(t, rec, Options{MaxBackoff: 50 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := c.GetSubscription(context.Background(), uuid.MustParse(subscriptionID)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 900*time.Millisecond {
+		t.Errorf("retried after %v, want Retry-After bounded by MaxBackoff", elapsed)
+	}
+}
+
+func TestClientStopsRetryingOnCancel(t *testing.T) {
+	rec := &recorder{responses: []response{{503, serverError, ""}}}
+	c := testClient(t, rec, Options{MinBackoff: time.Hour, MaxBackoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.GetSubscription(ctx, uuid.MustParse(subscriptionID))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetSubscription() error = %v, want deadline exceeded", err)
+	}
+	if got := len(rec.calls()); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	c := &Client{opts: Options{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}}
+
+	for _, tt := range []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{100, time.Second},
+	} {
+		for i := 0; i < 20; i++ {
+			if delay := c.backoff(tt.attempt); delay < tt.max/2 || delay > tt.max {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, delay, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestClientIdempotencyKey(t *testing.T) {
+	failTwice := []response{{503, serverError, ""}, {503, serverError, ""}, {201, subscription, ""}}
+	req := models.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: uuid.NewString(), StartDate: "07-2025"}
+
+	t.Run("generated key is reused across retries", func(t *testing.T) {
+		rec := &recorder{responses: failTwice}
+		c := testClient(t, rec, Options{})
+		if _, err := c.CreateSubscription(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+
+		calls := rec.calls()
+		key := calls[0].header.Get("Idempotency-Key")
+		if _, err := uuid.Parse(key); err != nil {
+			t.Fatalf("Idempotency-Key = %q, want a UUID", key)
+		}
+		for i, call := range calls {
+			if got := call.header.Get("Idempotency-Key"); got != key {
+				t.Errorf("attempt %d key = %q, want %q", i, got, key)
+			}
+			if call.body != calls[0].body {
+				t.Errorf("attempt %d body = %q, want %q", i, call.body, calls[0].body)
+			}
+		}
+
+		// The next call is a new operation
+		if _, err := c.CreateSubscription(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.calls()[3].header.Get("Idempotency-Key"); got == key {
+			t.Error("next call reused the key")
+		}
+	})
+
+	t.Run("key of the context", func(t *testing.T) {
+		rec := &recorder{responses: failTwice}
+		c := testClient(t, rec, Options{})
+		ctx := WithIdempotencyKey(context.Background(), "import-42")
+		if _, err := c.CreateSubscription(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		for i, call := range rec.calls() {
+			if got := call.header.Get("Idempotency-Key"); got != "import-42" {
+				t.Errorf("attempt %d key = %q, want import-42", i, got)
+			}
+		}
+	})
+
+	t.Run("GET has no key", func(t *testing.T) {
+		rec := &recorder{responses: []response{{200, subscription, ""}}}
+		c := testClient(t, rec, Options{})
+		if _, err := c.GetSubscription(context.Background(), uuid.MustParse(subscriptionID)); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.calls()[0].header.Get("Idempotency-Key"); got != "" {
+			t.Errorf("Idempotency-Key = %q, want none", got)
+		}
+	})
+}
+
+func TestClientRequest(t *testing.T) {
+	rec := &recorder{responses: []response{{200, subscription, ""}}}
+	tenant := uuid.New()
+	c := testClient(t, rec, Options{APIKey: "key", Token: "token", TenantID: tenant, UserAgent: "test/1.0"})
+
+	got, err := c.GetSubscription(context.Background(), uuid.MustParse(subscriptionID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID.String() != subscriptionID || got.ServiceName != "Netflix" || got.Price != 400 {
+		t.Errorf("GetSubscription() = %+v, want the data of the envelope", got)
+	}
+
+	call := rec.calls()[0]
+	if call.method != "GET" || call.path != "/v2/subscriptions/"+subscriptionID {
+		t.Errorf("request = %s %s, want the v2 route", call.method, call.path)
+	}
+	for name, want := range map[string]string{
+		"Accept":        "application/json",
+		"X-API-Key":     "key",
+		"Authorization": "Bearer token",
+		"X-Tenant-ID":   tenant.String(),
+		"User-Agent":    "test/1.0",
+	} {
+		if got := call.header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+	}{
+		{"envelope", 404, `{"error":{"status":404,"message":"Subscription not found"}}`, "Subscription not found"},
+		{"plain text", 401, "Authentication required\n", "Authentication required"},
+		{"envelope without message", 400, `{"error":{}}`, `{"error":{}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testClient(t, &recorder{responses: []response{{tt.status, tt.body, ""}}}, Options{})
+
+			_, err := c.GetSubscription(context.Background(), uuid.New())
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetSubscription() error = %v, want *Error", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want %d %q", apiErr, tt.status, tt.wantMessage)
+			}
+			if IsNotFound(err) != (tt.status == 404) {
+				t.Errorf("IsNotFound() = %v", IsNotFound(err))
+			}
+		})
+	}
+
+	t.Run("body without envelope", func(t *testing.T) {
+		c := testClient(t, &recorder{responses: []response{{200, `[1, 2]`, ""}}}, Options{})
+		if _, err := c.GetSubscription(context.Background(), uuid.New()); err == nil {
+			t.Error("GetSubscription() error = nil, want a decoding error")
+		}
+	})
+}
+
+func TestUpdateSubscriptionBody(t *testing.T) {
+	price, end := 0, "12-2025"
+
+	tests := []struct {
+		name  string
+		patch models.SubscriptionPatch
+		want  string
+	}{
+		{"empty", models.SubscriptionPatch{}, `{}`},
+		{"values", models.SubscriptionPatch{Price: &price, EndDate: &end, Tags: []string{"work"}},
+			`{"price":0,"end_date":"12-2025","tags":["work"]}`},
+		{"clears", models.SubscriptionPatch{ServiceName: "Kion", ClearEndDate: true, ClearCategory: true, ClearTags: true},
+			`{"category":null,"end_date":null,"service_name":"Kion","tags":null}`},
+		{"clear overrides the value", models.SubscriptionPatch{EndDate: &end, ClearEndDate: true}, `{"end_date":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{responses: []response{{200, subscription, ""}}}
+			c := testClient(t, rec, Options{})
+			if _, err := c.UpdateSubscription(context.Background(), uuid.MustParse(subscriptionID), tt.patch); err != nil {
+				t.Fatal(err)
+			}
+
+			call := rec.calls()[0]
+			if call.method != "PATCH" || call.header.Get("Content-Type") != "application/json" {
+				t.Errorf("request = %s with %q, want PATCH with JSON", call.method, call.header.Get("Content-Type"))
+			}
+			if call.body != tt.want {
+				t.Errorf("body = %s, want %s", call.body, tt.want)
+			}
+		})
+	}
+}
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int // Subscriptions the server has
+		failAt       int // Offset answered with an error, none when negative
+		wantItems    int
+		wantRequests int
+		wantErr      bool
+	}{
+		{"empty", 0, -1, 0, 1, false},
+		{"one page", 42, -1, 42, 1, false},
+		{"full last page", 2 * PageSize, -1, 2 * PageSize, 3, false},
+		{"partial last page", 2*PageSize + 1, -1, 2*PageSize + 1, 3, false},
+		{"error on a later page", 3 * PageSize, PageSize, PageSize, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var offsets []int
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+				offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+				offsets = append(offsets, offset)
+
+				if offset == tt.failAt {
+					w.WriteHeader(http.StatusBadRequest)
+					io.WriteString(w, `{"error":{"status":400,"message":"Invalid offset"}}`)
+					return
+				}
+
+				page := []models.Subscription{}
+				for i := offset; i < min(offset+limit, tt.total); i++ {
+					page = append(page, models.Subscription{Price: i})
+				}
+				data, _ := json.Marshal(page)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(models.Envelope{Data: data})
+			})
+			c := testClient(t, handler, Options{})
+
+			it := c.Subscriptions(context.Background(), models.SubscriptionFilter{Tags: []string{"work"}})
+			items := 0
+			for it.Next() {
+				if got := it.Value().Price; got != items {
+					t.Fatalf("item %d = %d, want items in order", items, got)
+				}
+				items++
+			}
+
+			if items != tt.wantItems {
+				t.Errorf("items = %d, want %d", items, tt.wantItems)
+			}
+			if len(offsets) != tt.wantRequests {
+				t.Errorf("requests at offsets %v, want %d", offsets, tt.wantRequests)
+			}
+			if (it.Err() != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, want error %v", it.Err(), tt.wantErr)
+			}
+			if it.Next() {
+				t.Error("Next() = true after the end")
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"subscription-aggregator/pkg/models"
+)
+
+// GraphQL runs a GraphQL query. Errors of the query are returned in the
+// response, not as error.
+func (c *Client) GraphQL(ctx context.Context, req models.GraphQLRequest) (*models.GraphQLResponse, error) {
+	data, err := c.send(ctx, http.MethodPost, "/graphql", nil, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp models.GraphQLResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,67 @@
+package client
+
+// PageSize is the number of items iterators load per request
+const PageSize = 100
+
+// Iterator walks through a paginated list:
+//
+//	it := c.Subscriptions(ctx, filter)
+//	for it.Next() {
+//		subscription := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type Iterator[T any] struct {
+	fetch func(limit, offset int) ([]T, error)
+
+	page   []T
+	index  int
+	offset int
+	last   bool
+	value  T
+	err    error
+}
+
+func newIterator[T any](fetch func(limit, offset int) ([]T, error)) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch, index: -1}
+}
+
+// Next advances to the next item, loading the next page when needed. It
+// returns false at the end of the list or on error.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	if it.index >= len(it.page) {
+		if it.last {
+			return false
+		}
+
+		it.page, it.err = it.fetch(PageSize, it.offset)
+		if it.err != nil {
+			return false
+		}
+		it.index = 0
+		it.offset += len(it.page)
+		it.last = len(it.page) < PageSize
+
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.value = it.page[it.index]
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateSubscription creates a subscription
+func (c *Client) CreateSubscription(ctx context.Context, req models.CreateSubscriptionRequest) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := c.do(ctx, http.MethodPost, "/subscriptions", nil, req, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// GetSubscription returns the subscription with the ID
+func (c *Client) GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/"+id.String(), nil, nil, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// UpdateSubscription applies the patch and returns the updated
+// subscription. Zero fields of the patch are left alone; its Clear fields
+// remove the end date, category or tags.
+func (c *Client) UpdateSubscription(ctx context.Context, id uuid.UUID, patch models.SubscriptionPatch) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := c.do(ctx, http.MethodPatch, "/subscriptions/"+id.String(), nil, patch, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// DeleteSubscription deletes the subscription with the ID
+func (c *Client) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/subscriptions/"+id.String(), nil, nil, nil)
+}
+
+// ListSubscriptions returns a page of subscriptions matching the filter,
+// newest first. The server uses its default page size when limit is zero.
+func (c *Client) ListSubscriptions(ctx context.Context, filter models.SubscriptionFilter, limit, offset int) ([]models.Subscription, error) {
+	query := subscriptionQuery(filter)
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var subscriptions []models.Subscription
+	if err := c.do(ctx, http.MethodGet, "/subscriptions", query, nil, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// Subscriptions iterates over all subscriptions matching the filter, newest
+// first, loading them page by page. Subscriptions created while iterating
+// shift the pages, so some may be seen twice.
+func (c *Client) Subscriptions(ctx context.Context, filter models.SubscriptionFilter) *Iterator[models.Subscription] {
+	return newIterator(func(limit, offset int) ([]models.Subscription, error) {
+		return c.ListSubscriptions(ctx, filter, limit, offset)
+	})
+}
+
+// AggregateSubscriptions returns the cost of subscriptions over the period
+// of the request, charging every subscription overlapping the period for
+// each month it was active
+func (c *Client) AggregateSubscriptions(ctx context.Context, req models.AggregationRequest) (*models.AggregationResponse, error) {
+	var result models.AggregationResponse
+	if err := c.do(ctx, http.MethodPost, "/subscriptions/aggregate", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetSharing returns how the cost of the subscription is shared
+func (c *Client) GetSharing(ctx context.Context, id uuid.UUID) (*models.SubscriptionSharing, error) {
+	var sharing models.SubscriptionSharing
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/"+id.String()+"/sharing", nil, nil, &sharing); err != nil {
+		return nil, err
+	}
+	return &sharing, nil
+}
+
+// UpdateSharing replaces the cost-sharing rule of the subscription
+func (c *Client) UpdateSharing(ctx context.Context, id uuid.UUID, req models.UpdateSharingRequest) (*models.SubscriptionSharing, error) {
+	var sharing models.SubscriptionSharing
+	if err := c.do(ctx, http.MethodPut, "/subscriptions/"+id.String()+"/sharing", nil, req, &sharing); err != nil {
+		return nil, err
+	}
+	return &sharing, nil
+}
+
+func subscriptionQuery(filter models.SubscriptionFilter) url.Values {
+	query := make(url.Values)
+	if filter.UserID != nil {
+		query.Set("user_id", filter.UserID.String())
+	}
+	if filter.ServiceID != nil {
+		query.Set("service_id", filter.ServiceID.String())
+	}
+	if filter.ServiceName != nil {
+		query.Set("service_name", *filter.ServiceName)
+	}
+	if filter.Category != nil {
+		query.Set("category", *filter.Category)
+	}
+	for _, tag := range filter.Tags {
+		query.Add("tag", tag)
+	}
+	return query
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"subscription-aggregator/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// ListUsers returns the users of the tenant, newest first
+func (c *Client) ListUsers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	if err := c.do(ctx, http.MethodGet, "/users", nil, nil, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CreateUser creates a user
+func (c *Client) CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, http.MethodPost, "/users", nil, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns the user with the ID
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, http.MethodGet, "/users/"+id.String(), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the fields set in the request
+func (c *Client) UpdateUser(ctx context.Context, id uuid.UUID, req models.UpdateUserRequest) error {
+	return c.do(ctx, http.MethodPut, "/users/"+id.String(), nil, req, nil)
+}
+
+// DeleteUser deletes a user without subscriptions
+func (c *Client) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/users/"+id.String(), nil, nil, nil)
+}
+
+// ListHouseholds returns the households, only those of the user when
+// userID is set
+func (c *Client) ListHouseholds(ctx context.Context, userID *uuid.UUID) ([]models.Household, error) {
+	query := make(url.Values)
+	if userID != nil {
+		query.Set("user_id", userID.String())
+	}
+
+	var households []models.Household
+	if err := c.do(ctx, http.MethodGet, "/households", query, nil, &households); err != nil {
+		return nil, err
+	}
+	return households, nil
+}
+
+// CreateHousehold creates a household
+func (c *Client) CreateHousehold(ctx context.Context, req models.CreateHouseholdRequest) (*models.Household, error) {
+	var household models.Household
+	if err := c.do(ctx, http.MethodPost, "/households", nil, req, &household); err != nil {
+		return nil, err
+	}
+	return &household, nil
+}
+
+// GetHousehold returns the household with the ID
+func (c *Client) GetHousehold(ctx context.Context, id uuid.UUID) (*models.Household, error) {
+	var household models.Household
+	if err := c.do(ctx, http.MethodGet, "/households/"+id.String(), nil, nil, &household); err != nil {
+		return nil, err
+	}
+	return &household, nil
+}
+
+// DeleteHousehold deletes the household with the ID
+func (c *Client) DeleteHousehold(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/households/"+id.String(), nil, nil, nil)
+}
+
+// AddHouseholdMember adds the user to the household
+func (c *Client) AddHouseholdMember(ctx context.Context, id, userID uuid.UUID) error {
+	req := models.AddHouseholdMemberRequest{UserID: userID.String()}
+	return c.do(ctx, http.MethodPost, "/households/"+id.String()+"/members", nil, req, nil)
+}
+
+// RemoveHouseholdMember removes the user from the household
+func (c *Client) RemoveHouseholdMember(ctx context.Context, id, userID uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/households/"+id.String()+"/members/"+userID.String(), nil, nil, nil)
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of anomalies in subscriptions
+const (
+	AnomalyDuplicateService  = "duplicate_service"   // The same product under different catalog services
+	AnomalyOverlappingPeriod = "overlapping_period"  // Subscriptions to the same service at the same time
+	AnomalyPriceJump         = "price_jump"          // A renewal or scheduled change much pricier than before
+	AnomalyAboveCatalogPrice = "above_catalog_price" // A price far above the default price of the catalog
+)
+
+// AnomalyReport lists anomalies, the costliest first
+type AnomalyReport struct {
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Anomalies   []Anomaly  `json:"anomalies"`
+}
+
+// Anomaly is a likely problem with subscriptions of a user. MonthlyExcess
+// estimates how much it adds to the monthly cost.
+type Anomaly struct {
+	Kind            string      `json:"kind"`
+	Message         string      `json:"message"`
+	UserID          uuid.UUID   `json:"user_id"`
+	SubscriptionIDs []uuid.UUID `json:"subscription_ids"`
+	MonthlyExcess   int         `json:"monthly_excess"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	TenantID   uuid.UUID  `json:"-"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	Roles      []string   `json:"roles"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKey is returned once on creation; the plain key is not stored
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name" validate:"required,max=255"`
+	UserID    string   `json:"user_id,omitempty" validate:"uuid"` // Identity the key acts as
+	Scopes    []string `json:"scopes" validate:"required,dive,enum=scopes"`
+	Roles     []string `json:"roles,omitempty" validate:"dive,enum=roles"`
+	ExpiresAt string   `json:"expires_at,omitempty"` // RFC 3339
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget periods
+const (
+	BudgetMonthly = "monthly"
+	BudgetYearly  = "yearly"
+)
+
+// Budget limits the spending of a period. Without a user it covers the
+// whole tenant; with a category or a catalog service only their
+// subscriptions count.
+type Budget struct {
+	ID         uuid.UUID  `json:"id"`
+	TenantID   uuid.UUID  `json:"-"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	Name       string     `json:"name"`
+	Period     string     `json:"period"`
+	Amount     int64      `json:"amount"`
+	Category   *string    `json:"category,omitempty"`
+	ServiceID  *uuid.UUID `json:"service_id,omitempty"`
+	Thresholds []int64    `json:"thresholds"` // Percent of the amount
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type CreateBudgetRequest struct {
+	Name       string  `json:"name" validate:"required,max=255"`
+	UserID     string  `json:"user_id,omitempty" validate:"uuid"` // The whole tenant when empty; regular users budget for themselves
+	Period     string  `json:"period" validate:"required,enum=budget_periods"`
+	Amount     int64   `json:"amount" validate:"min=1"`
+	Category   string  `json:"category,omitempty" validate:"enum=categories"`
+	ServiceID  string  `json:"service_id,omitempty" validate:"uuid"`                       // Catalog service, not together with category
+	Thresholds []int64 `json:"thresholds,omitempty" validate:"max=10,dive,min=1,max=1000"` // 80 and 100 by default
+}
+
+// UpdateBudgetRequest changes the limits of a budget; the period and what
+// it covers are fixed at creation
+type UpdateBudgetRequest struct {
+	Name       *string `json:"name,omitempty" validate:"max=255"`
+	Amount     *int64  `json:"amount,omitempty" validate:"min=1"`
+	Thresholds []int64 `json:"thresholds,omitempty" validate:"max=10,dive,min=1,max=1000"`
+}
+
+// BudgetStatus is the spending of a budget period up to the evaluated month
+type BudgetStatus struct {
+	BudgetID          uuid.UUID `json:"budget_id"`
+	PeriodStart       time.Time `json:"period_start"`
+	PeriodEnd         time.Time `json:"period_end"`
+	Amount            int64     `json:"amount"`
+	Spent             int64     `json:"spent"`
+	Remaining         int64     `json:"remaining"` // Negative when overspent
+	Percent           float64   `json:"percent"`
+	CrossedThresholds []int64   `json:"crossed_thresholds"`
+	Exceeded          bool      `json:"exceeded"`
+}
+
+// BudgetAlert is the data of budget.threshold_crossed events
+type BudgetAlert struct {
+	Threshold int64        `json:"threshold"`
+	Budget    Budget       `json:"budget"`
+	Status    BudgetStatus `json:"status"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// Envelope wraps successful responses of the v2 API
+type Envelope struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// ErrorEnvelope wraps error responses of the v2 API
+type ErrorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+type APIError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
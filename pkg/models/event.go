@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription lifecycle events published through the outbox
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventSubscriptionUpdated = "subscription.updated"
+	EventSubscriptionDeleted = "subscription.deleted"
+	EventSubscriptionEnded   = "subscription.ended" // The end date has passed
+)
+
+// Budget events published through the outbox
+const (
+	EventBudgetThresholdCrossed = "budget.threshold_crossed"
+)
+
+// Event is an event as published to sinks and posted to webhooks. Data is
+// the Subscription for subscription events, deleted events carrying it as
+// it was before the deletion, and the BudgetAlert for budget events.
+type Event struct {
+	ID        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+type ForecastRequest struct {
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	ServiceName *string    `json:"service_name,omitempty" validate:"max=255"`
+	Category    *string    `json:"category,omitempty" validate:"enum=categories"`
+	Tags        []string   `json:"tags,omitempty" validate:"max=20,dive,required,max=64"` // Subscriptions must carry all tags
+	StartMonth  string     `json:"start_month,omitempty" validate:"monthyear"`            // Format: "MM-YYYY", the current month by default
+	Months      int        `json:"months,omitempty" validate:"min=0,max=60"`              // 12 by default
+}
+
+type ForecastResponse struct {
+	TotalCost  int64           `json:"total_cost"`
+	StartMonth string          `json:"start_month"` // MM-YYYY
+	EndMonth   string          `json:"end_month"`   // MM-YYYY
+	UserID     *uuid.UUID      `json:"user_id,omitempty"`
+	Months     []ForecastMonth `json:"months"`
+}
+
+// ForecastMonth is the projected cost of one month and the services it
+// comes from, ordered by name
+type ForecastMonth struct {
+	Month     string        `json:"month"` // MM-YYYY
+	TotalCost int64         `json:"total_cost"`
+	Services  []ServiceCost `json:"services"`
+}
+
+type ServiceCost struct {
+	ServiceID   uuid.UUID `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	Cost        int64     `json:"cost"`
+}
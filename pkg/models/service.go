@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"-"`
+	Name         string    `json:"name"`
+	Aliases      []string  `json:"aliases"`
+	Category     *string   `json:"category,omitempty"`
+	DefaultPrice *int      `json:"default_price,omitempty"`
+	Website      *string   `json:"website,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CreateServiceRequest struct {
+	Name         string   `json:"name" validate:"required,max=255"`
+	Aliases      []string `json:"aliases,omitempty" validate:"dive,required,max=255"`
+	Category     *string  `json:"category,omitempty" validate:"enum=categories"`
+	DefaultPrice *int     `json:"default_price,omitempty" validate:"min=0"`
+	Website      *string  `json:"website,omitempty" validate:"max=255"`
+}
+
+type UpdateServiceRequest struct {
+	Name         string   `json:"name,omitempty" validate:"max=255"`
+	Aliases      []string `json:"aliases,omitempty" validate:"dive,required,max=255"`
+	Category     *string  `json:"category,omitempty" validate:"enum=categories"`
+	DefaultPrice *int     `json:"default_price,omitempty" validate:"min=0"`
+	Website      *string  `json:"website,omitempty" validate:"max=255"`
+}
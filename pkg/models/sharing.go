@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// Split types of a subscription cost-sharing rule
+const (
+	SplitNone    = "none"    // The owner pays the full price
+	SplitEqual   = "equal"   // Participants pay equal parts
+	SplitPercent = "percent" // Participants pay percentages, the owner pays the rest
+	SplitFixed   = "fixed"   // Participants pay fixed amounts, the owner pays the rest
+)
+
+type SubscriptionShare struct {
+	SubscriptionID uuid.UUID `json:"-"`
+	UserID         uuid.UUID `json:"user_id"`
+	Percent        *float64  `json:"percent,omitempty"`
+	Amount         *int      `json:"amount,omitempty"`
+}
+
+type SubscriptionSharing struct {
+	SubscriptionID uuid.UUID           `json:"subscription_id"`
+	OwnerID        uuid.UUID           `json:"owner_id"`
+	SplitType      string              `json:"split_type"`
+	HouseholdID    *uuid.UUID          `json:"household_id,omitempty"`
+	Shares         []SubscriptionShare `json:"shares"`
+	Costs          []UserCost          `json:"costs"` // Monthly cost per participant
+}
+
+type UserCost struct {
+	UserID uuid.UUID `json:"user_id"`
+	Cost   float64   `json:"cost"`
+}
+
+type UpdateSharingRequest struct {
+	SplitType   string         `json:"split_type" validate:"required,enum=split_types"`
+	HouseholdID *string        `json:"household_id,omitempty" validate:"uuid"`
+	Shares      []ShareRequest `json:"shares,omitempty"`
+}
+
+type ShareRequest struct {
+	UserID  string   `json:"user_id" validate:"required,uuid"`
+	Percent *float64 `json:"percent,omitempty" validate:"min=0,max=100"`
+	Amount  *int     `json:"amount,omitempty" validate:"min=0"`
+}
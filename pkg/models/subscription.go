@@ -0,0 +1,146 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+	"github.com/google/uuid"
+)
+
+type Subscription struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"-"`
+	ServiceName string     `json:"service_name"`
+	ServiceID   uuid.UUID  `json:"service_id"`
+	Price       int        `json:"price"`
+	UserID      uuid.UUID  `json:"user_id"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	Category    *string    `json:"category,omitempty"`
+	Tags        []string   `json:"tags"`
+	HouseholdID *uuid.UUID `json:"household_id,omitempty"`
+	SplitType   string     `json:"split_type"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CreateSubscriptionRequest struct {
+	ServiceName string   `json:"service_name" validate:"max=255"`      // Required unless service_id is set
+	ServiceID   string   `json:"service_id,omitempty" validate:"uuid"` // Catalog service ID
+	Price       int      `json:"price" validate:"required,min=0"`
+	UserID      string   `json:"user_id" validate:"required,uuid"`
+	StartDate   string   `json:"start_date" validate:"required,date"`                   // Format: "YYYY-MM-DD" or "MM-YYYY"
+	EndDate     string   `json:"end_date,omitempty" validate:"date,gtefield=StartDate"` // Format: "YYYY-MM-DD", "MM-YYYY" or empty
+	Category    string   `json:"category,omitempty" validate:"enum=categories"`         // Defaults to the catalog service category
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=64"`
+}
+
+type UpdateSubscriptionRequest struct {
+	ServiceName string   `json:"service_name,omitempty" validate:"max=255"`
+	ServiceID   string   `json:"service_id,omitempty" validate:"uuid"`
+	Price       *int     `json:"price,omitempty" validate:"min=0"` // 0 makes the subscription free
+	StartDate   string   `json:"start_date,omitempty" validate:"date"`
+	EndDate     string   `json:"end_date,omitempty" validate:"nullable,date,gtefield=StartDate"` // "null" clears the end date
+	Category    string   `json:"category,omitempty" validate:"nullable,enum=categories"`         // "null" clears the category
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=64"`          // Replaces existing tags when present
+}
+
+// SubscriptionPatch is a JSON merge patch (RFC 7396) of a subscription used
+// by the v2 API. Absent fields are left alone; null removes end_date,
+// category and tags, the other fields cannot be removed.
+type SubscriptionPatch struct {
+	ServiceName string   `json:"service_name,omitempty" validate:"max=255"`
+	ServiceID   string   `json:"service_id,omitempty" validate:"uuid"`
+	Price       *int     `json:"price,omitempty" validate:"min=0"`
+	StartDate   string   `json:"start_date,omitempty" validate:"date"`
+	EndDate     *string  `json:"end_date,omitempty" validate:"date,gtefield=StartDate"`
+	Category    *string  `json:"category,omitempty" validate:"enum=categories"`
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=64"`
+
+	// Send null for the field, overriding its value. Only used when
+	// encoding: decoded nulls leave them false.
+	ClearEndDate  bool `json:"-"`
+	ClearCategory bool `json:"-"`
+	ClearTags     bool `json:"-"`
+}
+
+// MarshalJSON encodes the patch with null for the cleared fields
+func (p SubscriptionPatch) MarshalJSON() ([]byte, error) {
+	type plain SubscriptionPatch
+	data, err := json.Marshal(plain(p))
+	if err != nil || !(p.ClearEndDate || p.ClearCategory || p.ClearTags) {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	null := json.RawMessage("null")
+	if p.ClearEndDate {
+		fields["end_date"] = null
+	}
+	if p.ClearCategory {
+		fields["category"] = null
+	}
+	if p.ClearTags {
+		fields["tags"] = null
+	}
+	return json.Marshal(fields)
+}
+
+type SubscriptionFilter struct {
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	ServiceName *string    `json:"service_name,omitempty"`
+	Category    *string    `json:"category,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+}
+
+type AggregationRequest struct {
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	ServiceName *string    `json:"service_name,omitempty" validate:"max=255"`
+	Category    *string    `json:"category,omitempty" validate:"enum=categories"`
+	Tags        []string   `json:"tags,omitempty" validate:"max=20,dive,required,max=64"` // Subscriptions must carry all tags
+	StartDate   string     `json:"start_date" validate:"required,date"`                   // Format: "YYYY-MM-DD" or "MM-YYYY"
+	EndDate     string     `json:"end_date" validate:"required,date,gtefield=StartDate"`  // Format: "YYYY-MM-DD" or "MM-YYYY"
+	Prorate     bool       `json:"prorate,omitempty"`                                     // Charge partial months by days
+	GroupBy     string     `json:"group_by,omitempty" validate:"enum=group_by"`           // "service", "category" or "tag"
+}
+
+type AggregationResponse struct {
+	TotalCost int64              `json:"total_cost"`
+	Period    string             `json:"period"`
+	UserID    *uuid.UUID         `json:"user_id,omitempty"`
+	Prorated  bool               `json:"prorated,omitempty"`
+	GroupBy   string             `json:"group_by,omitempty"`
+	Groups    []AggregationGroup `json:"groups,omitempty"`
+}
+
+type AggregationGroup struct {
+	Key       string `json:"key"`
+	TotalCost int64  `json:"total_cost"`
+}
+
+// PriceChange is a price the subscription switches to on the effective date
+type PriceChange struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	TenantID       uuid.UUID `json:"-"`
+	EffectiveDate  time.Time `json:"effective_date"`
+	Price          int       `json:"price"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PricePeriod is a price the subscription had before an applied change,
+// in effect up to the day before ValidUntil
+type PricePeriod struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	TenantID       uuid.UUID `json:"-"`
+	ValidUntil     time.Time `json:"valid_until"`
+	Price          int       `json:"price"`
+}
+
+type SchedulePriceChangeRequest struct {
+	EffectiveDate string `json:"effective_date" validate:"required,date"` // Format: "YYYY-MM-DD" or "MM-YYYY", after today
+	Price         int    `json:"price" validate:"min=0"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"-"`
+	Name      *string   `json:"name,omitempty"`
+	Email     *string   `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateUserRequest struct {
+	ID    string  `json:"id,omitempty" validate:"uuid"` // Optional, generated when empty
+	Name  *string `json:"name,omitempty" validate:"max=255"`
+	Email *string `json:"email,omitempty" validate:"email,max=255"`
+}
+
+type UpdateUserRequest struct {
+	Name  *string `json:"name,omitempty" validate:"max=255"`
+	Email *string `json:"email,omitempty" validate:"email,max=255"`
+}
+
+type Household struct {
+	ID        uuid.UUID   `json:"id"`
+	TenantID  uuid.UUID   `json:"-"`
+	Name      string      `json:"name"`
+	Members   []uuid.UUID `json:"members"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type CreateHouseholdRequest struct {
+	Name    string   `json:"name" validate:"required,max=255"`
+	Members []string `json:"members,omitempty" validate:"dive,uuid"` // User IDs
+}
+
+type AddHouseholdMemberRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
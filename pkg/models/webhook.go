@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses; failed deliveries exhausted their attempts
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   uuid.UUID `json:"-"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CreatedWebhook is returned once on creation with the signing secret
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,max=2048,url"`
+	EventTypes []string `json:"event_types" validate:"required,dive,enum=events"`
+	Secret     string   `json:"secret,omitempty" validate:"min=16,max=255"` // Generated when empty
+}
+
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url,omitempty" validate:"max=2048,url"`
+	EventTypes []string `json:"event_types,omitempty" validate:"dive,enum=events"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID       `json:"id"`
+	TenantID      uuid.UUID       `json:"-"`
+	WebhookID     uuid.UUID       `json:"webhook_id"`
+	EventID       uuid.UUID       `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastStatus    *int            `json:"last_status,omitempty"`
+	LastError     *string         `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}